@@ -2,7 +2,12 @@ module github.com/pixare40/interactive-commit
 
 go 1.24.3
 
-require github.com/spf13/cobra v1.9.1
+require (
+	github.com/gorilla/websocket v1.5.3
+	github.com/spf13/cobra v1.9.1
+	golang.org/x/sync v0.19.0
+	golang.org/x/text v0.34.0
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
@@ -0,0 +1,124 @@
+package audio
+
+import (
+	"regexp"
+	"strings"
+)
+
+// featuringDelimiter matches the explicit ways sources credit several artists:
+// "A feat. B", "A ft. B", "A featuring B", "A (feat. B)" and "A; B"
+var featuringDelimiter = regexp.MustCompile(`(?i)\s*(?:;|[(\[]?\b(?:feat\.?|ft\.|featuring)\s)\s*`)
+
+// joinerDelimiter also matches "A, B", "A & B" and "A x B", which are just as
+// often part of one name ("Years & Years", "Nick Cave & The Bad Seeds")
+var joinerDelimiter = regexp.MustCompile(`(?i)\s*(?:,|;|&|[(\[]?\b(?:feat\.?|ft\.|featuring)\s)\s*|\s+[x×]\s+`)
+
+// closingBracket pairs the brackets a delimiter can open
+var closingBracket = map[byte]string{'(': ")", '[': "]"}
+
+// groupedNames are acts whose names contain a delimiter but are one artist
+var groupedNames = []string{
+	"Earth, Wind & Fire",
+	"Crosby, Stills, Nash & Young",
+	"Crosby, Stills & Nash",
+	"Tyler, The Creator",
+	"Simon & Garfunkel",
+	"Hall & Oates",
+	"Mumford & Sons",
+	"Marina & The Diamonds",
+	"Peter, Bjorn and John",
+	"Sam & Dave",
+	"Ike & Tina Turner",
+	"Kool & The Gang",
+	"Derek & The Dominos",
+	"Brooks & Dunn",
+	"Chase & Status",
+	"Above & Beyond",
+}
+
+// SplitArtists parses a combined artist field into individual artists. Only
+// featuring credits and ";" separate artists, unless joiners is set, which
+// splits on ",", "&" and " x " too - keeping known group names like
+// "Simon & Garfunkel" intact, and names made of one word twice like
+// "Years & Years" whole.
+func SplitArtists(artist string, joiners bool) []string {
+	if strings.TrimSpace(artist) == "" {
+		return nil
+	}
+
+	delimiter := featuringDelimiter
+	if joiners {
+		delimiter = joinerDelimiter
+	}
+
+	// Shield group names from the delimiter, then restore them per artist
+	protected := artist
+	var restore []string
+	for _, name := range groupedNames {
+		idx := strings.Index(strings.ToLower(protected), strings.ToLower(name))
+		if idx < 0 {
+			continue
+		}
+		token := "\x00" + string(rune('A'+len(restore))) + "\x00"
+		restore = append(restore, protected[idx:idx+len(name)])
+		protected = protected[:idx] + token + protected[idx+len(name):]
+	}
+
+	var parts []string
+	opened := "" // The closing bracket the delimiter before this part opened
+	start := 0
+	for _, match := range append(delimiter.FindAllStringIndex(protected, -1), []int{len(protected), len(protected)}) {
+		part := strings.TrimSpace(protected[start:match[0]])
+		for i, name := range restore {
+			part = strings.Replace(part, "\x00"+string(rune('A'+i))+"\x00", name, 1)
+		}
+		// "A (feat. B)" leaves the bracket it opened at the end of B
+		if opened != "" {
+			part = strings.TrimSpace(strings.TrimSuffix(part, opened))
+		}
+		parts = append(parts, part)
+
+		opened = ""
+		if i := strings.IndexAny(protected[match[0]:match[1]], "(["); i >= 0 {
+			opened = closingBracket[protected[match[0]+i]]
+		}
+		start = match[1]
+	}
+
+	var artists []string
+	seen := map[string]bool{}
+	for _, part := range parts {
+		if part == "" || seen[strings.ToLower(part)] {
+			continue
+		}
+		seen[strings.ToLower(part)] = true
+		artists = append(artists, part)
+	}
+
+	// "Years & Years" and "Duran Duran"-style names aren't two artists
+	if len(parts) > 1 && len(artists) == 1 {
+		return []string{strings.TrimSpace(artist)}
+	}
+	return artists
+}
+
+// GroupArtists fills media.Artists and rewrites media.Artist so multi-artist
+// tracks read the same whatever the source: joined with separator, or just
+// the first artist when primaryOnly is set. joiners is as for SplitArtists.
+// Videos and podcasts are left alone since their "artist" is a channel or
+// show name.
+func GroupArtists(media *MediaInfo, separator string, primaryOnly, joiners bool) {
+	if media == nil || media.Type == "video" || media.Type == "podcast" {
+		return
+	}
+
+	media.Artists = SplitArtists(media.Artist, joiners)
+	switch {
+	case len(media.Artists) == 0:
+		return
+	case primaryOnly:
+		media.Artist = media.Artists[0]
+	case len(media.Artists) > 1:
+		media.Artist = strings.Join(media.Artists, separator)
+	}
+}
@@ -0,0 +1,103 @@
+package audio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArtists(t *testing.T) {
+	tests := []struct {
+		artist  string
+		joiners bool
+		want    []string
+	}{
+		// Featuring credits split by default
+		{"Daft Punk feat. Pharrell Williams", false, []string{"Daft Punk", "Pharrell Williams"}},
+		{"Calvin Harris ft. Rihanna", false, []string{"Calvin Harris", "Rihanna"}},
+		{"Gorillaz featuring De La Soul", false, []string{"Gorillaz", "De La Soul"}},
+		{"Mark Ronson (feat. Bruno Mars)", false, []string{"Mark Ronson", "Bruno Mars"}},
+		{"Mark Ronson [Feat. Bruno Mars]", false, []string{"Mark Ronson", "Bruno Mars"}},
+		{"Artist A; Artist B", false, []string{"Artist A", "Artist B"}},
+
+		// Joiners are part of the name unless asked
+		{"Years & Years", false, []string{"Years & Years"}},
+		{"Nick Cave & The Bad Seeds", false, []string{"Nick Cave & The Bad Seeds"}},
+		{"Tyler, The Creator", false, []string{"Tyler, The Creator"}},
+		{"Skrillex x Diplo", false, []string{"Skrillex x Diplo"}},
+		{"Sunn O)))", false, []string{"Sunn O)))"}},
+		{"Sunn O))) feat. Boris", false, []string{"Sunn O)))", "Boris"}},
+		{"Boris (feat. Sunn O)))", false, []string{"Boris", "Sunn O))"}},
+		{"Duran Duran", false, []string{"Duran Duran"}},
+		{"", false, nil},
+
+		// With joiners
+		{"Skrillex, Diplo", true, []string{"Skrillex", "Diplo"}},
+		{"Skrillex & Diplo", true, []string{"Skrillex", "Diplo"}},
+		{"Skrillex x Diplo", true, []string{"Skrillex", "Diplo"}},
+		{"Skrillex × Diplo", true, []string{"Skrillex", "Diplo"}},
+		{"A, B & C feat. D", true, []string{"A", "B", "C", "D"}},
+		{"Years & Years", true, []string{"Years & Years"}},
+		{"Simon & Garfunkel", true, []string{"Simon & Garfunkel"}},
+		{"Earth, Wind & Fire feat. The Emotions", true, []string{"Earth, Wind & Fire", "The Emotions"}},
+		{"Sunn O)))", true, []string{"Sunn O)))"}},
+		{"Skrillex, Diplo, Skrillex", true, []string{"Skrillex", "Diplo"}},
+	}
+
+	for _, tt := range tests {
+		if got := SplitArtists(tt.artist, tt.joiners); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitArtists(%q, %v) = %q, want %q", tt.artist, tt.joiners, got, tt.want)
+		}
+	}
+}
+
+func TestGroupArtists(t *testing.T) {
+	tests := []struct {
+		name        string
+		media       MediaInfo
+		primaryOnly bool
+		joiners     bool
+		want        string
+	}{
+		{"featuring joined", MediaInfo{Artist: "A feat. B", Type: "song"}, false, false, "A · B"},
+		{"band name kept", MediaInfo{Artist: "Nick Cave & The Bad Seeds", Type: "song"}, false, false, "Nick Cave & The Bad Seeds"},
+		{"joiners opted in", MediaInfo{Artist: "A & B", Type: "song"}, false, true, "A · B"},
+		{"primary only", MediaInfo{Artist: "A feat. B", Type: "song"}, true, false, "A"},
+		{"video channel untouched", MediaInfo{Artist: "A feat. B", Type: "video"}, false, false, "A feat. B"},
+	}
+
+	for _, tt := range tests {
+		media := tt.media
+		GroupArtists(&media, " · ", tt.primaryOnly, tt.joiners)
+		if media.Artist != tt.want {
+			t.Errorf("%s: Artist = %q, want %q", tt.name, media.Artist, tt.want)
+		}
+	}
+}
+
+func TestCollapseVariousArtists(t *testing.T) {
+	tests := []struct {
+		name  string
+		media MediaInfo
+		names []string
+		want  string
+	}{
+		{"only the credit", MediaInfo{Artist: "Various Artists", Type: "song"}, nil, ""},
+		{"case and abbreviations", MediaInfo{Artist: "various artists", Type: "song"}, nil, ""},
+		{"VA", MediaInfo{Artist: "V.A.", Type: "song"}, nil, ""},
+		{"track artist kept", MediaInfo{Artist: "Various Artists, Daft Punk", Type: "song"}, nil, "Daft Punk"},
+		{"track artists kept", MediaInfo{Artist: "Various Artists; Daft Punk; Justice", Type: "song"}, nil, "Daft Punk, Justice"},
+		{"no credit", MediaInfo{Artist: "Daft Punk", Type: "song"}, nil, "Daft Punk"},
+		{"name that only starts like one", MediaInfo{Artist: "Various Cruelties", Type: "song"}, nil, "Various Cruelties"},
+		{"configured names replace the defaults", MediaInfo{Artist: "Verschiedene Interpreten", Type: "song"}, []string{"Verschiedene Interpreten"}, ""},
+		{"configured names only", MediaInfo{Artist: "VA", Type: "song"}, []string{"Verschiedene Interpreten"}, "VA"},
+		{"podcast untouched", MediaInfo{Artist: "Various", Type: "podcast"}, nil, "Various"},
+	}
+
+	for _, tt := range tests {
+		media := tt.media
+		CollapseVariousArtists(&media, tt.names)
+		if media.Artist != tt.want {
+			t.Errorf("%s: Artist = %q, want %q", tt.name, media.Artist, tt.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package audio
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// browserPlayers are MPRIS player names of web browsers, whose "player" is
+// really whichever site is playing
+var browserPlayers = []string{"firefox", "chromium", "chrome", "brave", "vivaldi", "msedge", "opera"}
+
+// mediaSite is a site browsers play media from
+type mediaSite struct {
+	Source string
+	Type   string
+}
+
+// mediaSites maps page hosts to the site playing, checked by domain suffix
+var mediaSites = map[string]mediaSite{
+	"music.youtube.com": {"YouTube Music", "song"},
+	"youtube.com":       {"YouTube", "video"},
+	"youtu.be":          {"YouTube", "video"},
+	"soundcloud.com":    {"SoundCloud", "song"},
+	"open.spotify.com":  {"Spotify", "song"},
+	"bandcamp.com":      {"Bandcamp", "song"},
+	"music.apple.com":   {"Apple Music", "song"},
+	"deezer.com":        {"Deezer", "song"},
+	"tidal.com":         {"TIDAL", "song"},
+	"mixcloud.com":      {"Mixcloud", "song"},
+	"twitch.tv":         {"Twitch", "livestream"},
+	"vimeo.com":         {"Vimeo", "video"},
+}
+
+// artworkHosts recognize the site from its image CDN, for browsers like Firefox
+// that share the artwork but not the page URL over MPRIS
+var artworkHosts = map[string]string{
+	"ytimg.com":    "youtube.com",
+	"sndcdn.com":   "soundcloud.com",
+	"scdn.co":      "open.spotify.com",
+	"bcbits.com":   "bandcamp.com",
+	"mzstatic.com": "music.apple.com",
+}
+
+// isBrowserPlayer reports whether an MPRIS player name belongs to a web browser
+func isBrowserPlayer(playerName string) bool {
+	player := strings.ToLower(playerName)
+	for _, browser := range browserPlayers {
+		if strings.HasPrefix(player, browser) {
+			return true
+		}
+	}
+	return false
+}
+
+// mapBrowserSite names the site playing in a browser (e.g. "YouTube" rather
+// than "Firefox") from the page URL, or failing that, the artwork URL
+func mapBrowserSite(media *MediaInfo, playerName string) {
+	if !isBrowserPlayer(playerName) {
+		return
+	}
+
+	host, ok := matchHost(media.URL, mediaSites)
+	if !ok {
+		var artworkHost string
+		if artworkHost, ok = matchHost(media.ArtworkURL, artworkHosts); ok {
+			host = artworkHosts[artworkHost]
+		}
+	}
+	if !ok {
+		return
+	}
+
+	site := mediaSites[host]
+	media.Source = site.Source
+	media.Type = site.Type
+}
+
+// matchHost returns the longest key of hosts that rawURL's host equals or is a
+// subdomain of, so "music.youtube.com" wins over "youtube.com"
+func matchHost[V any](rawURL string, hosts map[string]V) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	match := ""
+	for suffix := range hosts {
+		if (host == suffix || strings.HasSuffix(host, "."+suffix)) && len(suffix) > len(match) {
+			match = suffix
+		}
+	}
+	return match, match != ""
+}
+
+// youTubeMusicSuffix ends the page title a browser shares for YouTube Music
+const youTubeMusicSuffix = " - YouTube Music"
+
+// mapYouTubeMusic tidies YouTube Music played in a browser tab, as a PWA or in
+// a desktop client. When all the browser shares is the page title, it reads
+// "Song - Artist - YouTube Music"; the artist may be an "Artist - Topic" channel.
+func mapYouTubeMusic(media *MediaInfo, playerName string) {
+	client := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(playerName))
+	fromTitle := strings.HasSuffix(media.Title, youTubeMusicSuffix)
+	if !fromTitle && media.Source != "YouTube Music" && !strings.HasPrefix(client, "youtubemusic") {
+		return
+	}
+
+	if fromTitle {
+		media.Title = strings.TrimSpace(strings.TrimSuffix(media.Title, youTubeMusicSuffix))
+		if idx := strings.LastIndex(media.Title, " - "); idx > 0 && media.Artist == "" {
+			media.Title, media.Artist = media.Title[:idx], media.Title[idx+len(" - "):]
+		}
+	}
+	media.Artist = strings.TrimSuffix(media.Artist, " - Topic")
+	media.Source = "YouTube Music"
+	media.Type = "song"
+}
+
+// FirefoxWithoutMPRIS reports whether Firefox is running on Linux without an
+// MPRIS player, which happens when media.hardwaremediakeys.enabled is off
+// (or simply when nothing is playing in it)
+func FirefoxWithoutMPRIS(ctx context.Context) bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	if _, err := lookPath("playerctl"); err != nil {
+		return false // No MPRIS detection to miss out on
+	}
+
+	processes, err := listProcesses(ctx)
+	if err != nil {
+		return false
+	}
+	running := false
+	for _, args := range processes {
+		if filepath.Base(args[0]) == "firefox" || filepath.Base(args[0]) == "firefox-bin" {
+			running = true
+			break
+		}
+	}
+	if !running {
+		return false
+	}
+
+	output, err := commandOutput(ctx, "playerctl", "--list-all")
+	if err != nil {
+		return true // playerctl reports an error when there are no players at all
+	}
+	for _, player := range strings.Fields(string(output)) {
+		if strings.HasPrefix(player, "firefox") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package audio
+
+import (
+	"bufio"
+	"context"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// callApps name the apps that record from the microphone during a call,
+// matched against what PulseAudio/PipeWire reports. Browsers only ask for
+// the microphone for calls in the page, like Google Meet or Teams on the web.
+var callApps = []struct{ match, name string }{
+	{"zoom", "Zoom"},
+	{"teams", "Microsoft Teams"},
+	{"slack", "Slack"},
+	{"discord", "Discord"},
+	{"skype", "Skype"},
+	{"webex", "Webex"},
+	{"jitsi", "Jitsi Meet"},
+	{"chrom", "a browser call"}, // Chrome and Chromium
+	{"firefox", "a browser call"},
+	{"edge", "a browser call"},
+	{"brave", "a browser call"},
+	{"vivaldi", "a browser call"},
+	{"opera", "a browser call"},
+}
+
+// zoomMeetingProcess only runs while Zoom is in a meeting on macOS and Windows
+const zoomMeetingProcess = "CptHost"
+
+// ActiveCall names the video-call app the user is in a call with, best effort:
+// on Linux an app recording from the microphone, on macOS and WSL Zoom's
+// in-meeting process. ok is false when no call is found, including where
+// calls can't be told apart from the app merely being open.
+func ActiveCall(ctx context.Context) (app string, ok bool) {
+	switch {
+	case runtime.GOOS == "darwin":
+		processes, err := listProcesses(ctx)
+		if err != nil {
+			return "", false
+		}
+		for _, args := range processes {
+			if filepath.Base(args[0]) == zoomMeetingProcess {
+				return "Zoom", true
+			}
+		}
+	case (&WSLWindowsDetector{}).isWSL():
+		output, err := commandOutput(ctx, "tasklist.exe", "/FI", "IMAGENAME eq "+zoomMeetingProcess+".exe", "/NH")
+		if err == nil && strings.Contains(string(output), zoomMeetingProcess) {
+			return "Zoom", true
+		}
+	case runtime.GOOS == "linux":
+		return microphoneCall(ctx)
+	}
+	return "", false
+}
+
+// microphoneCall finds a call app among those recording from the microphone
+// through PulseAudio or PipeWire
+func microphoneCall(ctx context.Context) (string, bool) {
+	output, err := commandOutput(ctx, "pactl", "list", "source-outputs")
+	if err != nil {
+		return "", false
+	}
+
+	// Each recording stream lists properties like
+	//   application.name = "ZOOM VoiceEngine"
+	//   application.process.binary = "zoom"
+	scanner := bufio.NewScanner(strings.NewReader(toUTF8(output)))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " = ")
+		if !ok || (key != "application.name" && key != "application.process.binary") {
+			continue
+		}
+		value = strings.ToLower(strings.Trim(value, `"`))
+		for _, app := range callApps {
+			if strings.Contains(value, app.match) {
+				return app.name, true
+			}
+		}
+	}
+	return "", false
+}
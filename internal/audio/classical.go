@@ -0,0 +1,42 @@
+package audio
+
+import (
+	"regexp"
+	"strings"
+)
+
+// movementMarker matches tempo markings and catalogue numbers typical of classical titles
+var movementMarker = regexp.MustCompile(`(?i)\b(allegro|allegretto|adagio|adagietto|andante|andantino|presto|prestissimo|largo|larghetto|lento|grave|vivace|moderato|scherzo|menuetto|minuet|rondo)\b|\bop\.\s*\d|\bBWV\s*\d|\bK\.\s*\d|\bHob\.|\bD\.\s*\d`)
+
+// movementNumber matches a leading roman numeral movement number like "I. " or "IV. "
+var movementNumber = regexp.MustCompile(`^[IVX]+\.\s`)
+
+// ClassifyClassical marks classical tracks and fills in work/movement from the title.
+// A track counts as classical when its genre says so or its title carries movement markers.
+func ClassifyClassical(media *MediaInfo) {
+	if media == nil || media.Type == "podcast" || media.Type == "video" {
+		return
+	}
+
+	if !strings.Contains(strings.ToLower(media.Genre), "classical") && !movementMarker.MatchString(media.Title) {
+		return
+	}
+
+	media.Type = "classical"
+
+	// Titles usually read "Work: Movement", e.g. "Symphony No. 5 in C Minor, Op. 67: I. Allegro con brio"
+	if media.Work == "" {
+		if idx := strings.LastIndex(media.Title, ": "); idx > 0 {
+			media.Work = strings.TrimSpace(media.Title[:idx])
+			if media.Movement == "" {
+				media.Movement = strings.TrimSpace(media.Title[idx+2:])
+			}
+		} else if movementNumber.MatchString(media.Title) && media.Album != "" {
+			// A bare "II. Adagio" title - the album is the best guess at the work
+			media.Work = media.Album
+			media.Movement = media.Title
+		} else {
+			media.Work = media.Title
+		}
+	}
+}
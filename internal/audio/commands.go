@@ -0,0 +1,252 @@
+package audio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CommandRunner runs the external tools detectors read from (playerctl,
+// osascript, PowerShell, ...). Swapping it lets a detection be recorded and
+// replayed offline through the same parsing code.
+type CommandRunner interface {
+	// Output runs name with args and returns its stdout. A failed command
+	// returns an *exec.ExitError carrying stderr, as exec.Cmd.Output does.
+	Output(ctx context.Context, name string, args ...string) ([]byte, error)
+	LookPath(name string) (string, error)
+}
+
+// Commands runs every subprocess detection needs
+var Commands CommandRunner = execRunner{}
+
+// commandOutput runs a detector's command through Commands
+func commandOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return Commands.Output(ctx, name, args...)
+}
+
+// lookPath finds a detector's tool through Commands
+func lookPath(name string) (string, error) {
+	return Commands.LookPath(name)
+}
+
+// execRunner runs real processes
+type execRunner struct{}
+
+// commandWaitDelay is how long a command killed at its deadline may keep its
+// output open (through a child it started) before it's given up on, so a
+// hung tool can't hold up the commit past the detection budget
+const commandWaitDelay = 500 * time.Millisecond
+
+func (execRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = commandWaitDelay
+	return cmd.Output()
+}
+
+func (execRunner) LookPath(name string) (string, error) {
+	return exec.LookPath(name)
+}
+
+// recordingFile indexes a recording; each command's stdout is saved beside it
+// exactly as the tool printed it
+const recordingFile = "commands.json"
+
+// Recording is the raw output of every command one detection ran
+type Recording struct {
+	Platform string            `json:"platform"` // GOOS it was recorded on
+	Recorded time.Time         `json:"recorded"`
+	Lookups  map[string]bool   `json:"lookups"` // Tools looked up on PATH, and whether they were found
+	Commands []RecordedCommand `json:"commands"`
+}
+
+// RecordedCommand is one command run and what it printed
+type RecordedCommand struct {
+	Command  []string `json:"command"`
+	Stdout   string   `json:"stdout"`              // File holding stdout, relative to the recording
+	Stderr   string   `json:"stderr,omitempty"`    // Only kept when the command failed
+	ExitCode int      `json:"exit_code,omitempty"` // Non-zero when the command failed
+	Error    string   `json:"error,omitempty"`     // Why it couldn't run at all, e.g. not on PATH
+
+	stdout []byte
+}
+
+// Recorder runs commands through another runner, keeping their output
+type Recorder struct {
+	runner CommandRunner
+
+	mu        sync.Mutex
+	recording Recording
+}
+
+// NewRecorder records the commands runner runs
+func NewRecorder(runner CommandRunner) *Recorder {
+	return &Recorder{
+		runner:    runner,
+		recording: Recording{Platform: runtime.GOOS, Recorded: time.Now(), Lookups: map[string]bool{}},
+	}
+}
+
+func (r *Recorder) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	output, err := r.runner.Output(ctx, name, args...)
+
+	command := RecordedCommand{Command: append([]string{name}, args...), stdout: output}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		command.Stderr = string(exitErr.Stderr)
+		command.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		command.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	r.recording.Commands = append(r.recording.Commands, command)
+	r.mu.Unlock()
+	return output, err
+}
+
+func (r *Recorder) LookPath(name string) (string, error) {
+	path, err := r.runner.LookPath(name)
+
+	r.mu.Lock()
+	r.recording.Lookups[name] = err == nil
+	r.mu.Unlock()
+	return path, err
+}
+
+// unsafeFileChars are replaced in the stdout file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Save writes the recording into dir, creating it if needed
+func (r *Recorder) Save(dir string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for i := range r.recording.Commands {
+		command := &r.recording.Commands[i]
+		name := unsafeFileChars.ReplaceAllString(filepath.Base(command.Command[0]), "_")
+		command.Stdout = fmt.Sprintf("%03d-%s.out", i+1, name)
+		if err := os.WriteFile(filepath.Join(dir, command.Stdout), command.stdout, 0644); err != nil {
+			return err
+		}
+	}
+
+	content, err := json.MarshalIndent(r.recording, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, recordingFile), append(content, '\n'), 0644)
+}
+
+// Replayer answers commands from a recording instead of running them. A
+// command run more often than it was recorded gets its last answer again.
+type Replayer struct {
+	Recording
+
+	mu      sync.Mutex
+	answers map[string][]RecordedCommand
+}
+
+// LoadReplay reads a recording saved by a Recorder
+func LoadReplay(dir string) (*Replayer, error) {
+	content, err := os.ReadFile(filepath.Join(dir, recordingFile))
+	if err != nil {
+		return nil, fmt.Errorf("no recording in %s: %w", dir, err)
+	}
+
+	replay := &Replayer{answers: map[string][]RecordedCommand{}}
+	if err := json.Unmarshal(content, &replay.Recording); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Join(dir, recordingFile), err)
+	}
+
+	for _, command := range replay.Commands {
+		if len(command.Command) == 0 {
+			continue
+		}
+		if command.Stdout != "" {
+			if command.stdout, err = os.ReadFile(filepath.Join(dir, command.Stdout)); err != nil {
+				return nil, err
+			}
+		}
+		key := commandKey(command.Command[0], command.Command[1:])
+		replay.answers[key] = append(replay.answers[key], command)
+	}
+	return replay, nil
+}
+
+// commandKey identifies a command line
+func commandKey(name string, args []string) string {
+	return strings.Join(append([]string{name}, args...), "\x00")
+}
+
+func (r *Replayer) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	r.mu.Lock()
+	key := commandKey(name, args)
+	answers := r.answers[key]
+	if len(answers) == 0 {
+		r.mu.Unlock()
+		return nil, &exec.Error{Name: name, Err: errors.New("not in the recording")}
+	}
+	command := answers[0]
+	if len(answers) > 1 {
+		r.answers[key] = answers[1:]
+	}
+	r.mu.Unlock()
+
+	switch {
+	case command.Error != "":
+		return command.stdout, errors.New(command.Error)
+	case command.ExitCode != 0:
+		return command.stdout, &replayedExit{
+			code:      command.ExitCode,
+			ExitError: &exec.ExitError{ProcessState: &os.ProcessState{}, Stderr: []byte(command.Stderr)},
+		}
+	}
+	return command.stdout, nil
+}
+
+// LookPath finds a tool the recording looked up, or one it ran
+func (r *Replayer) LookPath(name string) (string, error) {
+	found, ok := r.Lookups[name]
+	if !ok {
+		r.mu.Lock()
+		for key := range r.answers {
+			if strings.HasPrefix(key, name+"\x00") {
+				found = true
+				break
+			}
+		}
+		r.mu.Unlock()
+	}
+	if !found {
+		return "", &exec.Error{Name: name, Err: exec.ErrNotFound}
+	}
+	return name, nil
+}
+
+// replayedExit is a recorded command failure. The ExitError carries stderr
+// for detectors that look at it, but a replay has no real process state.
+type replayedExit struct {
+	code int
+	*exec.ExitError
+}
+
+func (e *replayedExit) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+func (e *replayedExit) Unwrap() error {
+	return e.ExitError
+}
@@ -0,0 +1,153 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// coverColorTimeout bounds downloading and decoding the cover art, so a slow
+// image host can't hold up the commit
+const coverColorTimeout = 2 * time.Second
+
+// maxCoverBytes is the largest cover image read. Album art is well under it.
+const maxCoverBytes = 10 << 20
+
+// ColorStore remembers the color found for each cover, so the image is only
+// downloaded once
+type ColorStore interface {
+	LoadColor(artworkURL string) (color string, ok bool)
+	SaveColor(artworkURL, color string)
+}
+
+// UseColorStore caches cover colors in store
+func (am *AudioManager) UseColorStore(store ColorStore) {
+	am.colors = store
+}
+
+// coverColor sets media.CoverColor from its artwork. Any failure, or a track
+// without artwork, leaves it empty.
+func (am *AudioManager) coverColor(ctx context.Context, media *MediaInfo) {
+	if media.ArtworkURL == "" {
+		return
+	}
+	if am.colors != nil {
+		if color, ok := am.colors.LoadColor(media.ArtworkURL); ok {
+			media.CoverColor = color
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, coverColorTimeout)
+	defer cancel()
+	cover, err := loadCover(ctx, media.ArtworkURL)
+	if err != nil {
+		return
+	}
+
+	media.CoverColor = DominantColor(cover)
+	if am.colors != nil {
+		am.colors.SaveColor(media.ArtworkURL, media.CoverColor)
+	}
+}
+
+// loadCover decodes the image at artworkURL: a web address, or a file:// URL
+// as MPRIS players give for art they cache locally
+func loadCover(ctx context.Context, artworkURL string) (image.Image, error) {
+	u, err := url.Parse(artworkURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.ReadCloser
+	switch u.Scheme {
+	case "file":
+		if body, err = os.Open(u.Path); err != nil {
+			return nil, err
+		}
+	case "http", "https":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, artworkURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("cover art request returned %s", resp.Status)
+		}
+		body = resp.Body
+	default:
+		return nil, fmt.Errorf("unsupported cover art URL %s", artworkURL)
+	}
+	defer body.Close()
+
+	cover, _, err := image.Decode(io.LimitReader(body, maxCoverBytes))
+	return cover, err
+}
+
+// DominantColor returns the most common color of img as "#rrggbb". Colors are
+// counted in coarse buckets so shades of the same color add up, and the
+// bucket's average is returned. Near-black and near-white, which borders and
+// backgrounds tend to be, only win when nothing else is there.
+func DominantColor(img image.Image) string {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return ""
+	}
+
+	// About 64x64 samples is plenty, whatever the cover's size
+	step := max(1, max(bounds.Dx(), bounds.Dy())/64)
+
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+	buckets := map[int]*bucket{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a < 0x8000 {
+				continue // Transparent
+			}
+			r, g, b = r>>8, g>>8, b>>8
+
+			key := int(r>>5)<<6 | int(g>>5)<<3 | int(b>>5)
+			if buckets[key] == nil {
+				buckets[key] = &bucket{}
+			}
+			bk := buckets[key]
+			bk.count++
+			bk.r += int(r)
+			bk.g += int(g)
+			bk.b += int(b)
+		}
+	}
+
+	// Ties go to the lowest bucket, so the same cover always gives one color
+	var best *bucket
+	bestScore, bestKey := -1, 0
+	for key, bk := range buckets {
+		score := bk.count
+		if brightness := (bk.r + bk.g + bk.b) / (3 * bk.count); brightness < 24 || brightness > 232 {
+			score /= 8
+		}
+		if score > bestScore || score == bestScore && key < bestKey {
+			best, bestScore, bestKey = bk, score, key
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return fmt.Sprintf("#%02x%02x%02x", best.r/best.count, best.g/best.count, best.b/best.count)
+}
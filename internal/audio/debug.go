@@ -0,0 +1,80 @@
+package audio
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// debugDetectors builds every detector by a short key, including opt-in ones
+// the config hasn't enabled, for ForceDetectorOrder
+func (am *AudioManager) debugDetectors() map[string]Detector {
+	return map[string]Detector{
+		"spotify-api": &SpotifyAPIDetector{AudioFeatures: am.cfg.Spotify.AudioFeatures},
+		"mpris":       &MPRISDetector{PreferredPlayers: am.cfg.PreferredPlayers},
+		"kdeconnect":  &KDEConnectDetector{},
+		"wsl":         &WSLWindowsDetector{BypassExecutionPolicy: am.cfg.PowerShellBypass},
+		"macos":       &MacOSDetector{},
+		"fifo":        &FifoDetector{Path: am.cfg.FifoPath},
+		"obs":         &OBSDetector{URL: am.cfg.OBS.URL, Password: am.cfg.OBS.Password},
+		"mpv":         &MPVDetector{SocketPath: am.cfg.MPVSocket},
+		"mpd":         &MPDDetector{Host: am.cfg.MPDHost},
+		"roon":        &RoonDetector{URL: am.cfg.Roon.URL, Zone: am.cfg.Roon.Zone},
+		"procscan":    &ProcessScanDetector{Players: am.cfg.ProcessScan},
+		"remote":      &RemoteDetector{Target: am.cfg.Remote.SSH, Command: am.cfg.Remote.Command},
+	}
+}
+
+// DetectorKeys lists the keys ForceDetectorOrder accepts
+func (am *AudioManager) DetectorKeys() []string {
+	var keys []string
+	for key := range am.debugDetectors() {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ForceDetectorOrder is a support tool for reproducing bug reports: it replaces
+// the detectors with exactly the ones named, in that order, and runs them even
+// when IsAvailable says no, so their raw errors show on any platform.
+func (am *AudioManager) ForceDetectorOrder(keys []string) error {
+	all := am.debugDetectors()
+
+	var detectors []Detector
+	for _, key := range keys {
+		detector, ok := all[strings.TrimSpace(key)]
+		if !ok {
+			return fmt.Errorf("unknown detector %q (available: %s)", key, strings.Join(am.DetectorKeys(), ", "))
+		}
+		detectors = append(detectors, detector)
+	}
+	if len(detectors) == 0 {
+		return fmt.Errorf("no detectors given")
+	}
+
+	am.detectors = detectors
+	am.forced = true
+	return nil
+}
+
+// isAvailable reports whether detector should run: always, once forced
+func (am *AudioManager) isAvailable(detector Detector) bool {
+	return am.describe(detector).Available
+}
+
+// describe reports on detector; a forced one is always available. A detector
+// that panics while checking is unavailable, as in runDetector.
+func (am *AudioManager) describe(detector Detector) (info DetectorInfo) {
+	if am.forced {
+		return DetectorInfo{Name: detector.Name(), Available: true}
+	}
+	if !am.cfg.RaiseDetectorPanics {
+		defer func() {
+			if r := recover(); r != nil {
+				info = DetectorInfo{Name: detector.Name()}.unavailable("crashed checking availability: %v", r)
+			}
+		}()
+	}
+	return detector.Describe()
+}
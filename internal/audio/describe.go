@@ -0,0 +1,49 @@
+package audio
+
+import (
+	"fmt"
+)
+
+// DetectorInfo explains a detector's availability: what it needs and, when it
+// can't run, why not
+type DetectorInfo struct {
+	Name      string   `json:"name"`
+	Requires  []string `json:"requires,omitempty"` // External tools and services, e.g. "playerctl"
+	Available bool     `json:"available"`
+	Reason    string   `json:"reason,omitempty"` // Why it's unavailable, e.g. "playerctl not found on PATH"
+
+	// OtherPlatform is set for detectors that never run on this operating system
+	OtherPlatform bool `json:"other_platform,omitempty"`
+}
+
+// unavailable returns info with the reason it can't run
+func (info DetectorInfo) unavailable(reason string, args ...interface{}) DetectorInfo {
+	info.Available = false
+	info.Reason = fmt.Sprintf(reason, args...)
+	return info
+}
+
+// otherPlatform returns info for a detector made for another operating system
+func (info DetectorInfo) otherPlatform(platform string) DetectorInfo {
+	info = info.unavailable("only runs on %s", platform)
+	info.OtherPlatform = true
+	return info
+}
+
+// missingTool returns the reason a required command can't be used, or "" when it's on PATH
+func missingTool(name string) string {
+	if _, err := lookPath(name); err != nil {
+		return name + " not found on PATH"
+	}
+	return ""
+}
+
+// DescribeDetectors reports on every configured detector in priority order,
+// including those that can't run here
+func (am *AudioManager) DescribeDetectors() []DetectorInfo {
+	var infos []DetectorInfo
+	for _, detector := range am.detectors {
+		infos = append(infos, am.describe(detector))
+	}
+	return infos
+}
@@ -3,23 +3,101 @@ package audio
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"os/exec"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/pixare40/interactive-commit/internal/config"
+	"golang.org/x/sync/singleflight"
 )
 
+// ErrAutomationDenied is returned when macOS refuses to let us control an app via AppleScript
+var ErrAutomationDenied = errors.New("macOS Automation permission denied")
+
+// ErrExecutionPolicy is returned when PowerShell's execution policy blocks our detection script
+var ErrExecutionPolicy = errors.New("PowerShell execution policy blocked detection")
+
 // MediaInfo represents currently playing media
 type MediaInfo struct {
-	Title    string
-	Artist   string
-	Album    string
-	Source   string // "Spotify", "YouTube", "VLC", etc.
-	Type     string // "song", "podcast", "video", etc.
-	Duration time.Duration
-	Position time.Duration
+	Title    string        `json:"title"`
+	Artist   string        `json:"artist,omitempty"`
+	Artists  []string      `json:"artists,omitempty"` // Artist split into individual artists
+	Album    string        `json:"album,omitempty"`
+	Source   string        `json:"source,omitempty"` // "Spotify", "YouTube", "VLC", etc.
+	Type     string        `json:"type,omitempty"`   // "song", "podcast", "video", etc.
+	Duration time.Duration `json:"duration,omitempty"`
+	Position time.Duration `json:"position,omitempty"`
+	URL      string        `json:"url,omitempty"` // Page or stream URL, when the player exposes one
+	Genre    string        `json:"genre,omitempty"`
+
+	// Playlist is the playlist or station the track is playing from, when known
+	Playlist string `json:"playlist,omitempty"`
+
+	// Context names what Spotify is playing from and ContextType says what it is:
+	// "playlist", "album", "artist" or "collection" (Liked Songs)
+	Context     string `json:"context,omitempty"`
+	ContextType string `json:"context_type,omitempty"`
+
+	// ArtworkURL points at the cover art, when the player exposes one
+	ArtworkURL string `json:"artwork_url,omitempty"`
+
+	// CoverColor is the cover art's dominant color as "#rrggbb", captured
+	// with include_cover_color
+	CoverColor string `json:"cover_color,omitempty"`
+
+	// Detector names the detector that found the media, e.g. "MPRIS/playerctl"
+	Detector string `json:"detector,omitempty"`
+
+	// OutputDevice is the device playing the audio when it isn't this computer,
+	// e.g. a Spotify Connect speaker
+	OutputDevice string `json:"output_device,omitempty"`
+
+	// TrackURI identifies the track at its service, e.g. "spotify:track:4uLU6hMCjMI75M1A2tKUQC"
+	TrackURI string `json:"track_uri,omitempty"`
+
+	// Explicit is set for tracks the service flags as explicit. Sources that
+	// don't say leave it false.
+	Explicit bool `json:"explicit,omitempty"`
+
+	// Local is set for a file from the user's own library, as opposed to a
+	// track streamed from a service or the web
+	Local bool `json:"local,omitempty"`
+
+	// Quality describes the stream, e.g. "FLAC 96kHz/24bit" or "MP3 320kbps", when
+	// the player reports its format
+	Quality string `json:"quality,omitempty"`
+
+	// Volume (percent) and Muted describe the system output, captured with skip_if_muted
+	Volume int  `json:"volume,omitempty"`
+	Muted  bool `json:"muted,omitempty"`
+
+	// InCall names the video-call app in a call while this played, captured
+	// with skip_during_calls
+	InCall string `json:"in_call,omitempty"`
+
+	// Tempo (BPM) and Mood come from Spotify's audio features, when enabled
+	Tempo int    `json:"tempo,omitempty"`
+	Mood  string `json:"mood,omitempty"`
+
+	// Classical music is described by composer, work and movement rather than artist and song
+	Composer string `json:"composer,omitempty"`
+	Work     string `json:"work,omitempty"`
+	Movement string `json:"movement,omitempty"`
+}
+
+// Clone returns a copy of m that shares nothing with it
+func (m *MediaInfo) Clone() *MediaInfo {
+	clone := *m
+	clone.Artists = append([]string(nil), m.Artists...)
+	return &clone
 }
 
 // Detector interface for different audio detection methods
@@ -27,102 +105,223 @@ type Detector interface {
 	Detect(ctx context.Context) (*MediaInfo, error)
 	Name() string
 	IsAvailable() bool
+	Describe() DetectorInfo
 }
 
 // MPRISDetector detects audio via MPRIS (Linux native)
-type MPRISDetector struct{}
+type MPRISDetector struct {
+	// PreferredPlayers are MPRIS player names ("spotify", "firefox") to report
+	// first, in order, when several players have media
+	PreferredPlayers []string
+}
 
 func (m *MPRISDetector) Name() string {
 	return "MPRIS/playerctl"
 }
 
 func (m *MPRISDetector) IsAvailable() bool {
-	// Only available on Linux
+	return m.Describe().Available
+}
+
+func (m *MPRISDetector) Describe() DetectorInfo {
+	info := DetectorInfo{Name: m.Name(), Requires: []string{"playerctl"}, Available: true}
 	if runtime.GOOS != "linux" {
-		return false
+		return info.otherPlatform("Linux")
+	}
+	if reason := missingTool("playerctl"); reason != "" {
+		return info.unavailable("%s", reason)
 	}
+	return info
+}
 
-	// Check if playerctl is installed
-	_, err := exec.LookPath("playerctl")
-	return err == nil
+// playerctlFields are the metadata keys fetched in a single playerctl call, in order
+var playerctlFields = []string{
+	"title", "artist", "album", "playerName", "status",
+	"mpris:length", "position", "xesam:url", "xesam:genre", "xesam:composer",
+	"mpris:artUrl",
 }
 
+// playerctlSeparator delimits fields in the playerctl output. The ASCII unit
+// separator can't appear in real titles, unlike "|" or " - ".
+const playerctlSeparator = "\x1f"
+
 func (m *MPRISDetector) Detect(ctx context.Context) (*MediaInfo, error) {
-	// Try to get metadata from playerctl
-	title, err := m.getPlayerctlMetadata(ctx, "title")
+	// Fetch everything in one subprocess instead of one per field
+	placeholders := make([]string, len(playerctlFields))
+	for i, field := range playerctlFields {
+		placeholders[i] = "{{" + field + "}}"
+	}
+
+	output, err := m.metadata(ctx, strings.Join(placeholders, playerctlSeparator))
 	if err != nil {
-		return nil, err
+		return nil, err // Includes "No players found"
 	}
 
-	if title == "" {
-		return nil, nil // No media playing
+	return m.parseMetadata(output), nil
+}
+
+// metadata runs playerctl metadata with format. playerctl picks the player
+// itself; with PreferredPlayers every player is asked and the line of the
+// one to report is returned, "" when none has media.
+func (m *MPRISDetector) metadata(ctx context.Context, format string) (string, error) {
+	if len(m.PreferredPlayers) == 0 {
+		output, err := commandOutput(ctx, "playerctl", "metadata", "--format", format)
+		return toUTF8(output), err
+	}
+
+	output, err := commandOutput(ctx, "playerctl", "--all-players", "metadata", "--format",
+		"{{playerName}}"+playerctlSeparator+"{{status}}"+playerctlSeparator+format)
+	if err != nil {
+		return "", err
+	}
+	return pickPlayer(strings.Split(strings.TrimRight(toUTF8(output), "\r\n"), "\n"), m.PreferredPlayers), nil
+}
+
+// pickPlayer chooses among playerctl lines that start with the player name
+// and status: a preferred player that is playing, then a paused one, then
+// any playing player, then the first listed. Stopped players never count.
+// The returned line has the name and status removed.
+func pickPlayer(lines []string, preferred []string) string {
+	type player struct {
+		name, status, rest string
+	}
+	var players []player
+	for _, line := range lines {
+		fields := strings.SplitN(line, playerctlSeparator, 3)
+		if len(fields) != 3 || fields[1] == "Stopped" {
+			continue
+		}
+		players = append(players, player{name: fields[0], status: fields[1], rest: fields[2]})
+	}
+
+	for _, status := range []string{"Playing", "Paused"} {
+		for _, want := range preferred {
+			for _, p := range players {
+				// Browsers register as e.g. "firefox.instance_1_52"
+				name, _, _ := strings.Cut(p.name, ".")
+				if p.status == status && strings.EqualFold(name, want) {
+					return p.rest
+				}
+			}
+		}
+	}
+	for _, p := range players {
+		if p.status == "Playing" {
+			return p.rest
+		}
 	}
+	if len(players) > 0 {
+		return players[0].rest
+	}
+	return ""
+}
 
-	artist, _ := m.getPlayerctlMetadata(ctx, "artist")
-	album, _ := m.getPlayerctlMetadata(ctx, "album")
+// parseMetadata turns the delimited playerctl output into media info
+func (m *MPRISDetector) parseMetadata(output string) *MediaInfo {
+	values := strings.Split(strings.TrimRight(output, "\r\n"), playerctlSeparator)
+	fields := make(map[string]string, len(playerctlFields))
+	for i, field := range playerctlFields {
+		if i < len(values) {
+			fields[field] = strings.TrimSpace(values[i])
+		}
+	}
 
-	// Try to determine the source player
-	source, _ := m.getActivePlayer(ctx)
+	if fields["title"] == "" || fields["status"] == "Stopped" {
+		return nil // No media playing
+	}
+
+	source := cleanPlayerName(fields["playerName"])
 	if source == "" {
 		source = "Unknown"
 	}
 
-	return &MediaInfo{
-		Title:  title,
-		Artist: artist,
-		Album:  album,
-		Source: source,
-		Type:   "song", // TODO: Better type detection
-	}, nil
+	media := &MediaInfo{
+		Title:    fields["title"],
+		Artist:   fields["artist"],
+		Album:    fields["album"],
+		Source:   source,
+		Type:     "song", // TODO: Better type detection
+		Duration: parseMicroseconds(fields["mpris:length"]),
+		Position: parseMicroseconds(fields["position"]),
+		URL:      fields["xesam:url"],
+		Genre:    fields["xesam:genre"],
+		Composer: fields["xesam:composer"],
+		Local:    isLocalURL(fields["xesam:url"]),
+
+		ArtworkURL: fields["mpris:artUrl"],
+	}
+
+	mapBrowserSite(media, fields["playerName"])
+	mapYouTubeMusic(media, fields["playerName"])
+	mapPodcast(media, fields["playerName"])
+	return media
 }
 
-func (m *MPRISDetector) getPlayerctlMetadata(ctx context.Context, key string) (string, error) {
-	cmd := exec.CommandContext(ctx, "playerctl", "metadata", key)
-	output, err := cmd.Output()
+// parseMicroseconds converts MPRIS microsecond values, zero when missing
+func parseMicroseconds(value string) time.Duration {
+	micros, err := strconv.ParseInt(value, 10, 64)
 	if err != nil {
-		return "", err
+		return 0
 	}
-
-	return strings.TrimSpace(string(output)), nil
+	return time.Duration(micros) * time.Microsecond
 }
 
-func (m *MPRISDetector) getActivePlayer(ctx context.Context) (string, error) {
-	cmd := exec.CommandContext(ctx, "playerctl", "--list-all")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
+// cleanPlayerName turns an MPRIS player name like "chromium.instance1234" into "Chromium"
+func cleanPlayerName(player string) string {
+	if player == "" {
+		return ""
 	}
+	if idx := strings.Index(player, "."); idx > 0 {
+		player = player[:idx]
+	}
+	return strings.ToUpper(player[:1]) + strings.ToLower(player[1:])
+}
 
-	players := strings.Split(strings.TrimSpace(string(output)), "\n")
-	if len(players) > 0 && players[0] != "" {
-		// Return the first active player, clean up the name
-		player := players[0]
-		if strings.Contains(player, ".") {
-			parts := strings.Split(player, ".")
-			return strings.ToUpper(parts[0][:1]) + strings.ToLower(parts[0][1:]), nil
+// WSLWindowsDetector detects Windows audio from within WSL2
+type WSLWindowsDetector struct {
+	// BypassExecutionPolicy passes -ExecutionPolicy Bypass to PowerShell
+	BypassExecutionPolicy bool
+}
+
+// jsonLine picks our JSON result out of PowerShell's output, ignoring anything
+// else printed to stdout (e.g. banners from machine-wide profile scripts)
+func jsonLine(output string) string {
+	lines := strings.Split(output, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		// A byte order mark can lead the output when the console encoding changes
+		line := strings.TrimSpace(strings.TrimPrefix(lines[i], "\ufeff"))
+		if strings.HasPrefix(line, "{") && strings.HasSuffix(line, "}") {
+			return line
 		}
-		return strings.ToUpper(player[:1]) + strings.ToLower(player[1:]), nil
 	}
-
-	return "", nil
+	return ""
 }
 
-// WSLWindowsDetector detects Windows audio from within WSL2
-type WSLWindowsDetector struct{}
+// isExecutionPolicyError reports whether PowerShell refused to run because of its execution policy
+func isExecutionPolicyError(stderr string) bool {
+	lower := strings.ToLower(stderr)
+	return strings.Contains(lower, "running scripts is disabled") ||
+		strings.Contains(lower, "executionpolicy") ||
+		strings.Contains(lower, "pssecurityexception")
+}
 
 func (w *WSLWindowsDetector) Name() string {
 	return "WSL2/Windows Media Session"
 }
 
 func (w *WSLWindowsDetector) IsAvailable() bool {
-	// Check if we're in WSL
+	return w.Describe().Available
+}
+
+func (w *WSLWindowsDetector) Describe() DetectorInfo {
+	info := DetectorInfo{Name: w.Name(), Requires: []string{"WSL", "powershell.exe"}, Available: true}
 	if !w.isWSL() {
-		return false
+		return info.otherPlatform("WSL")
 	}
-
-	// Check if we can access PowerShell
-	_, err := exec.LookPath("powershell.exe")
-	return err == nil
+	if reason := missingTool("powershell.exe"); reason != "" {
+		return info.unavailable("%s (is Windows interop enabled?)", reason)
+	}
+	return info
 }
 
 func (w *WSLWindowsDetector) isWSL() bool {
@@ -141,6 +340,14 @@ func (w *WSLWindowsDetector) isWSL() bool {
 func (w *WSLWindowsDetector) Detect(ctx context.Context) (*MediaInfo, error) {
 	// Use window titles approach - much more reliable than Windows Media Session API from WSL2
 	scriptText := `
+# Emit UTF-8 (without a BOM) rather than the console's OEM code page, so accented
+# names survive the trip to WSL. Hosts without a console refuse this; carry on then.
+$utf8 = New-Object System.Text.UTF8Encoding $false
+try {
+    [Console]::OutputEncoding = $utf8
+    [Console]::InputEncoding = $utf8
+} catch {}
+$OutputEncoding = $utf8
 try {
     # Check Spotify
     $spotify = Get-Process -Name 'Spotify' -ErrorAction SilentlyContinue | Where-Object { $_.MainWindowTitle -and $_.MainWindowTitle -ne 'Spotify' }
@@ -247,18 +454,35 @@ try {
 }
 `
 
-	// Execute PowerShell script
-	cmd := exec.CommandContext(ctx, "powershell.exe", "-Command", scriptText)
-	output, err := cmd.Output()
+	// Execute PowerShell script. -NoProfile skips slow (and noisy) user profiles,
+	// -NonInteractive makes any prompt fail fast instead of hanging the commit, and
+	// bypassing the execution policy keeps locked-down machines from refusing our script.
+	args := []string{"-NoProfile", "-NonInteractive"}
+	if w.BypassExecutionPolicy {
+		args = append(args, "-ExecutionPolicy", "Bypass")
+	}
+	args = append(args, "-Command", scriptText)
+
+	output, err := commandOutput(ctx, "powershell.exe", args...)
 	if err != nil {
 		// Get stderr for debugging
-		if exitErr, ok := err.(*exec.ExitError); ok {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			if isExecutionPolicyError(string(exitErr.Stderr)) {
+				return nil, fmt.Errorf("%w: %s", ErrExecutionPolicy, strings.TrimSpace(string(exitErr.Stderr)))
+			}
 			return nil, fmt.Errorf("PowerShell failed: %w, stderr: %s", err, string(exitErr.Stderr))
 		}
 		return nil, fmt.Errorf("failed to query Windows Media Session: %w", err)
 	}
 
-	outputStr := strings.TrimSpace(string(output))
+	return w.parseOutput(output)
+}
+
+// parseOutput reads the media from the script's output: one JSON line,
+// possibly after warnings and a byte order mark, or none when nothing plays
+func (w *WSLWindowsDetector) parseOutput(output []byte) (*MediaInfo, error) {
+	outputStr := jsonLine(toUTF8(output))
 	if outputStr == "" {
 		return nil, nil // No media playing
 	}
@@ -275,6 +499,11 @@ try {
 		return nil, fmt.Errorf("failed to parse media session data: %w", err)
 	}
 
+	// A matched window whose title couldn't be parsed is not useful media
+	if !isUsableTitle(result.Title) {
+		return nil, nil
+	}
+
 	// Clean up source name
 	source := w.cleanSourceName(result.Source)
 
@@ -359,6 +588,30 @@ func (w *WSLWindowsDetector) determineMediaType(title, source string) string {
 	return "song"
 }
 
+// browserChromeTitles are window titles that name the app rather than any media
+var browserChromeTitles = map[string]bool{
+	"google chrome":   true,
+	"microsoft edge":  true,
+	"firefox":         true,
+	"mozilla firefox": true,
+	"safari":          true,
+	"new tab":         true,
+	"youtube":         true,
+	"youtube music":   true,
+	"spotify":         true,
+	"spotify premium": true,
+	"spotify free":    true,
+}
+
+// isUsableTitle reports whether a parsed title names actual media
+func isUsableTitle(title string) bool {
+	trimmed := strings.TrimSpace(title)
+	if trimmed == "" {
+		return false
+	}
+	return !browserChromeTitles[strings.ToLower(trimmed)]
+}
+
 // MacOSDetector detects audio on macOS using AppleScript
 type MacOSDetector struct{}
 
@@ -367,25 +620,44 @@ func (m *MacOSDetector) Name() string {
 }
 
 func (m *MacOSDetector) IsAvailable() bool {
-	// Only available on macOS
+	return m.Describe().Available
+}
+
+func (m *MacOSDetector) Describe() DetectorInfo {
+	info := DetectorInfo{Name: m.Name(), Requires: []string{"osascript"}, Available: true}
 	if runtime.GOOS != "darwin" {
-		return false
+		return info.otherPlatform("macOS")
 	}
-
-	// Check if osascript is available
-	_, err := exec.LookPath("osascript")
-	return err == nil
+	if reason := missingTool("osascript"); reason != "" {
+		return info.unavailable("%s", reason)
+	}
+	return info
 }
 
 func (m *MacOSDetector) Detect(ctx context.Context) (*MediaInfo, error) {
 	// Try music apps first (they're more reliable)
-	media, err := m.detectMusicApps(ctx)
-	if err == nil && media != nil {
+	media, musicErr := m.detectMusicApps(ctx)
+	if musicErr == nil && media != nil {
 		return media, nil
 	}
 
 	// Fallback to browser detection
-	return m.detectBrowserMedia(ctx)
+	media, browserErr := m.detectBrowserMedia(ctx)
+	if media != nil || browserErr != nil {
+		return media, browserErr
+	}
+
+	// Nothing found - report a permission problem rather than silence
+	return nil, musicErr
+}
+
+// isAutomationDenied reports whether osascript failed with errAEEventNotPermitted (-1743)
+func (m *MacOSDetector) isAutomationDenied(err error) bool {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return strings.Contains(string(exitErr.Stderr), "-1743")
+	}
+	return false
 }
 
 func (m *MacOSDetector) detectMusicApps(ctx context.Context) (*MediaInfo, error) {
@@ -398,16 +670,19 @@ func (m *MacOSDetector) detectMusicApps(ctx context.Context) (*MediaInfo, error)
 		{"iTunes", "iTunes"},
 	}
 
+	var deniedErr error
 	for _, app := range apps {
 		// First check if the app is actually playing
 		playerStateCmd := fmt.Sprintf(`tell application "%s" to player state`, app.name)
-		cmd := exec.CommandContext(ctx, "osascript", "-e", playerStateCmd)
-		output, err := cmd.Output()
+		output, err := commandOutput(ctx, "osascript", "-e", playerStateCmd)
 		if err != nil {
+			if m.isAutomationDenied(err) {
+				deniedErr = fmt.Errorf("%w for %s", ErrAutomationDenied, app.name)
+			}
 			continue // App not running or accessible
 		}
 
-		playerState := strings.TrimSpace(string(output))
+		playerState := strings.TrimSpace(toUTF8(output))
 		if playerState != "playing" {
 			continue // Not currently playing
 		}
@@ -417,45 +692,104 @@ func (m *MacOSDetector) detectMusicApps(ctx context.Context) (*MediaInfo, error)
 		artistCmd := fmt.Sprintf(`tell application "%s" to artist of current track`, app.name)
 		albumCmd := fmt.Sprintf(`tell application "%s" to album of current track`, app.name)
 
-		titleResult, titleErr := exec.CommandContext(ctx, "osascript", "-e", titleCmd).Output()
-		artistResult, artistErr := exec.CommandContext(ctx, "osascript", "-e", artistCmd).Output()
-		albumResult, albumErr := exec.CommandContext(ctx, "osascript", "-e", albumCmd).Output()
+		titleResult, titleErr := commandOutput(ctx, "osascript", "-e", titleCmd)
+		artistResult, artistErr := commandOutput(ctx, "osascript", "-e", artistCmd)
+		albumResult, albumErr := commandOutput(ctx, "osascript", "-e", albumCmd)
 
 		if titleErr != nil {
 			continue
 		}
 
-		title := strings.TrimSpace(string(titleResult))
-		if title == "" || title == "missing value" {
+		title := tagValue(toUTF8(titleResult))
+		if !isUsableTitle(title) {
 			continue
 		}
 
 		artist := ""
 		if artistErr == nil {
-			artist = strings.TrimSpace(string(artistResult))
-			if artist == "missing value" {
-				artist = ""
-			}
+			artist = tagValue(toUTF8(artistResult))
 		}
 
 		album := ""
 		if albumErr == nil {
-			album = strings.TrimSpace(string(albumResult))
-			if album == "missing value" {
-				album = ""
-			}
+			album = tagValue(toUTF8(albumResult))
 		}
 
-		return &MediaInfo{
+		media := &MediaInfo{
 			Title:  title,
 			Artist: artist,
 			Album:  album,
 			Source: app.source,
 			Type:   "song",
-		}, nil
+		}
+
+		// Optional tags, read in one go - Spotify doesn't expose all of these,
+		// so each is empty when it fails
+		expressions := []string{"genre of current track", "composer of current track", "work of current track", "movement of current track"}
+		if app.name != "Spotify" {
+			expressions = append(expressions, "class of current track", "name of current playlist")
+		} else {
+			expressions = append(expressions, "spotify url of current track")
+		}
+		values := m.trackProperties(ctx, app.name, expressions)
+		media.Genre, media.Composer, media.Work, media.Movement = values[0], values[1], values[2], values[3]
+
+		if app.name != "Spotify" {
+			// Library files are "file track"s; the catalog and radio are "shared" or "URL" tracks
+			media.Local = values[4] == "file track"
+			media.Playlist = values[5]
+			if libraryPlaylists[media.Playlist] || media.Playlist == album {
+				media.Playlist = "" // Playing an album or the plain library has no playlist to report
+			}
+		} else {
+			media.Local = isLocalURL(values[4])
+		}
+
+		return media, nil
 	}
 
-	return nil, nil
+	return nil, deniedErr
+}
+
+// libraryPlaylists are what Apple Music reports when playing from the library
+// rather than a playlist the user picked
+var libraryPlaylists = map[string]bool{"Library": true, "Music": true, "Songs": true}
+
+// trackProperties evaluates AppleScript expressions such as "genre of
+// current track" in one osascript call, returning a value for each, empty when
+// it's unavailable. Some sources (e.g. Up Next or shared libraries) have no
+// current playlist and error, so each is wrapped to never fail detection.
+func (m *MacOSDetector) trackProperties(ctx context.Context, appName string, expressions []string) []string {
+	output, err := commandOutput(ctx, "osascript", "-e", propertiesScript(appName, expressions))
+	if err != nil {
+		return make([]string, len(expressions))
+	}
+	return parseProperties(toUTF8(output), len(expressions))
+}
+
+// propertySeparator joins propertiesScript's values: the unit separator,
+// "character id 31" to AppleScript, which no tag contains
+const propertySeparator = "\x1f"
+
+// propertiesScript builds the script trackProperties runs: every expression's
+// value as text, joined with propertySeparator
+func propertiesScript(appName string, expressions []string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "tell application %q\nset values to {}\n", appName)
+	for _, expression := range expressions {
+		fmt.Fprintf(&sb, "try\nset end of values to (%s) as text\non error\nset end of values to \"\"\nend try\n", expression)
+	}
+	sb.WriteString("set AppleScript's text item delimiters to character id 31\nreturn values as text\nend tell")
+	return sb.String()
+}
+
+// parseProperties splits propertiesScript's output into n cleaned up values
+func parseProperties(output string, n int) []string {
+	values := make([]string, n)
+	for i, value := range strings.SplitN(strings.TrimRight(output, "\r\n"), propertySeparator, n) {
+		values[i] = tagValue(value)
+	}
+	return values
 }
 
 func (m *MacOSDetector) detectBrowserMedia(ctx context.Context) (*MediaInfo, error) {
@@ -463,13 +797,16 @@ func (m *MacOSDetector) detectBrowserMedia(ctx context.Context) (*MediaInfo, err
 
 	for _, browserName := range browsers {
 		script := fmt.Sprintf(`tell application "System Events" to tell process "%s" to name of every window`, browserName)
-		cmd := exec.CommandContext(ctx, "osascript", "-e", script)
-		output, err := cmd.Output()
+		output, err := commandOutput(ctx, "osascript", "-e", script)
 		if err != nil {
+			if m.isAutomationDenied(err) {
+				// System Events is shared by every browser, no point trying the rest
+				return nil, fmt.Errorf("%w for System Events", ErrAutomationDenied)
+			}
 			continue // Browser not running
 		}
 
-		windowTitles := strings.TrimSpace(string(output))
+		windowTitles := strings.TrimSpace(toUTF8(output))
 		if windowTitles == "" {
 			continue
 		}
@@ -481,14 +818,22 @@ func (m *MacOSDetector) detectBrowserMedia(ctx context.Context) (*MediaInfo, err
 		}
 
 		// Parse the best media window
-		title, artist := m.parseMediaTitle(mediaWindows[0])
+		title, artist, source := m.parseMediaTitle(mediaWindows[0])
+		if !isUsableTitle(title) {
+			continue
+		}
+
+		mediaType := "video"
+		if source != "YouTube" {
+			mediaType = "song"
+		}
 
 		return &MediaInfo{
 			Title:  title,
 			Artist: artist,
 			Album:  "",
-			Source: "YouTube",
-			Type:   "video",
+			Source: source,
+			Type:   mediaType,
 		}, nil
 	}
 
@@ -544,7 +889,8 @@ func (m *MacOSDetector) findMediaWindows(windowTitles string) []string {
 	var regularWindows []string
 
 	for _, windowTitle := range lines {
-		if strings.Contains(windowTitle, "YouTube") || strings.Contains(windowTitle, "Music") {
+		if strings.Contains(windowTitle, "YouTube") || strings.Contains(windowTitle, "Music") ||
+			strings.Contains(windowTitle, "Spotify") || strings.Contains(windowTitle, "SoundCloud") {
 			if strings.Contains(windowTitle, "Audio playing") {
 				priorityWindows = append(priorityWindows, windowTitle)
 			} else {
@@ -560,7 +906,30 @@ func (m *MacOSDetector) findMediaWindows(windowTitles string) []string {
 	return mediaWindows
 }
 
-func (m *MacOSDetector) parseMediaTitle(windowTitle string) (title, artist string) {
+// siteSuffixes identify the streaming site from the end of a browser tab title.
+// Longer suffixes come first so " - YouTube Music" isn't mistaken for " - YouTube".
+var siteSuffixes = []struct {
+	suffix string
+	source string
+}{
+	{" - YouTube Music", "YouTube Music"},
+	{" - YouTube", "YouTube"},
+	{" | Spotify", "Spotify"},
+	{" - Spotify", "Spotify"},
+	{" | SoundCloud", "SoundCloud"},
+}
+
+// siteSuffix returns the index in siteSuffixes of the suffix title ends with, or -1
+func siteSuffix(title string) int {
+	for i, site := range siteSuffixes {
+		if strings.HasSuffix(title, site.suffix) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *MacOSDetector) parseMediaTitle(windowTitle string) (title, artist, source string) {
 	// Clean up the window title first
 	cleanTitle := windowTitle
 
@@ -583,36 +952,63 @@ func (m *MacOSDetector) parseMediaTitle(windowTitle string) (title, artist strin
 
 	// Remove browser suffixes
 	cleanTitle = strings.ReplaceAll(cleanTitle, "- Google Chrome", "")
-	cleanTitle = strings.ReplaceAll(cleanTitle, "- YouTube", "")
 
-	// Remove user indicators like "– Kabaji"
-	if strings.Contains(cleanTitle, "–") {
-		parts := strings.Split(cleanTitle, "–")
-		if len(parts) > 1 {
-			cleanTitle = strings.TrimSpace(parts[0])
-		}
-	}
+	// Remove the profile name Chrome appends, like "– Kabaji", but not an
+	// en dash in the song's own title
 	cleanTitle = strings.TrimSpace(cleanTitle)
+	if idx := strings.LastIndex(cleanTitle, " – "); idx > 0 && siteSuffix(strings.TrimSpace(cleanTitle[:idx])) >= 0 {
+		cleanTitle = strings.TrimSpace(cleanTitle[:idx])
+	}
 
-	// Split by " - " to separate artist and title
-	parts := strings.Split(cleanTitle, " - ")
+	// Drop YouTube's unread notification counter, e.g. "(3) Artist - Song"
+	cleanTitle = strings.TrimSpace(notificationCounter.ReplaceAllString(cleanTitle, ""))
 
-	if len(parts) >= 2 {
-		// The last part is the title, everything before is the artist(s)
-		title = strings.TrimSpace(parts[len(parts)-1])
-		artist = strings.TrimSpace(strings.Join(parts[:len(parts)-1], " - "))
-	} else {
-		// If no hyphen, the whole thing is the title
+	// Recognize the site suffix first, so a " - " inside the title itself
+	// isn't confused with the separator before the site name
+	source = "YouTube"
+	if i := siteSuffix(cleanTitle); i >= 0 {
+		cleanTitle = strings.TrimSpace(strings.TrimSuffix(cleanTitle, siteSuffixes[i].suffix))
+		source = siteSuffixes[i].source
+	}
+
+	switch source {
+	case "YouTube Music":
+		// "Song - Artist": the artist is the last segment
+		if idx := strings.LastIndex(cleanTitle, " - "); idx > 0 {
+			title = cleanTitle[:idx]
+			artist = cleanTitle[idx+len(" - "):]
+		}
+	case "Spotify":
+		// The web player uses "Song • Artist"
+		if parts := strings.SplitN(cleanTitle, " • ", 2); len(parts) == 2 {
+			title, artist = parts[0], parts[1]
+		}
+	default:
+		// YouTube uploads follow "Artist - Title", and the title may itself
+		// contain " - "; some uploaders use an en dash instead
+		if parts := strings.SplitN(cleanTitle, " - ", 2); len(parts) == 2 {
+			artist, title = parts[0], parts[1]
+		} else if parts := strings.SplitN(cleanTitle, " – ", 2); len(parts) == 2 {
+			artist, title = parts[0], parts[1]
+		}
+	}
+
+	if title == "" {
+		// No separator, the whole thing is the title
 		title = cleanTitle
 		artist = "Unknown"
 	}
 
 	// Final cleanup on title to remove extras
-	title = m.cleanupTitle(title)
+	title = m.cleanupTitle(strings.TrimSpace(title))
+	artist = tagValue(artist)
 
-	return title, artist
+	return title, artist, source
 }
 
+// notificationCounter matches the "(3) " unread badge YouTube prefixes to tab titles
+var notificationCounter = regexp.MustCompile(`^\(\d+\)\s*`)
+
 func (m *MacOSDetector) cleanupTitle(title string) string {
 	// Remove common video/audio indicators using simple string replacements
 	titleLower := strings.ToLower(title)
@@ -662,14 +1058,35 @@ func (m *MacOSDetector) cleanupTitle(title string) string {
 	return strings.TrimSpace(title)
 }
 
+// sharedDetectTimeout bounds a detection shared between callers, which no
+// one caller's deadline can end
+const sharedDetectTimeout = 10 * time.Second
+
 // AudioManager orchestrates multiple detectors
 type AudioManager struct {
 	detectors []Detector
+	cfg       *config.Config
+
+	// inflight shares one detection between overlapping callers in this process
+	inflight singleflight.Group
+
+	// forced skips IsAvailable checks, set by ForceDetectorOrder
+	forced bool
+
+	// tracks caches detections when set, see UseTrackStore
+	tracks   TrackStore
+	trackTTL time.Duration
+
+	// colors caches cover colors when set, see UseColorStore
+	colors ColorStore
 }
 
 // NewAudioManager creates a new audio manager with platform-specific detectors
-func NewAudioManager() *AudioManager {
-	am := &AudioManager{}
+func NewAudioManager(cfg *config.Config) *AudioManager {
+	if cfg == nil {
+		cfg = config.Default()
+	}
+	am := &AudioManager{cfg: cfg}
 
 	// Add detectors based on platform
 	am.addDetectors()
@@ -682,32 +1099,354 @@ func (am *AudioManager) addDetectors() {
 	// TODO: Add platform detection
 	// For now, add all detectors and let them self-disable if unavailable
 
-	am.detectors = append(am.detectors, &MPRISDetector{})
-	am.detectors = append(am.detectors, &WSLWindowsDetector{})
+	// The Web API sees playback on every device, so it goes first once set up
+	if am.cfg.Spotify.ClientID != "" {
+		am.detectors = append(am.detectors, &SpotifyAPIDetector{AudioFeatures: am.cfg.Spotify.AudioFeatures})
+	}
+
+	am.detectors = append(am.detectors, &MPRISDetector{PreferredPlayers: am.cfg.PreferredPlayers})
+	am.detectors = append(am.detectors, &KDEConnectDetector{})
+	am.detectors = append(am.detectors, &WSLWindowsDetector{BypassExecutionPolicy: am.cfg.PowerShellBypass})
 	am.detectors = append(am.detectors, &MacOSDetector{})
+
+	// Opt-in integrations only run when configured
+	if am.cfg.FifoPath != "" {
+		am.detectors = append(am.detectors, &FifoDetector{Path: am.cfg.FifoPath})
+	}
+	if am.cfg.OBS.URL != "" {
+		am.detectors = append(am.detectors, &OBSDetector{URL: am.cfg.OBS.URL, Password: am.cfg.OBS.Password})
+	}
+
+	if am.cfg.MPVSocket != "" {
+		am.detectors = append(am.detectors, &MPVDetector{SocketPath: am.cfg.MPVSocket})
+	}
+	if am.cfg.MPDHost != "" {
+		am.detectors = append(am.detectors, &MPDDetector{Host: am.cfg.MPDHost})
+	}
+	if am.cfg.Roon.URL != "" {
+		am.detectors = append(am.detectors, &RoonDetector{URL: am.cfg.Roon.URL, Zone: am.cfg.Roon.Zone})
+	}
+
+	// Another machine, asked once nothing here is found
+	if am.cfg.Remote.SSH != "" {
+		am.detectors = append(am.detectors, &RemoteDetector{Target: am.cfg.Remote.SSH, Command: am.cfg.Remote.Command})
+	}
+
+	// Last resort: terminal players found among running processes
+	if len(am.cfg.ProcessScan) > 0 {
+		am.detectors = append(am.detectors, &ProcessScanDetector{Players: am.cfg.ProcessScan})
+	}
 }
 
-// Detect tries all available detectors and returns the first successful result
+// Detect tries all available detectors and returns the first successful result.
+// A detector error always falls through to the next detector; a clean empty
+// result only does so unless stop_on_empty is configured, in which case
+// Detect returns nil media and a nil error.
+// Concurrent calls share a single in-flight detection instead of each spawning
+// their own round of subprocesses.
 func (am *AudioManager) Detect(ctx context.Context) (*MediaInfo, error) {
-	for _, detector := range am.detectors {
-		if !detector.IsAvailable() {
-			continue
+	media, err := am.DetectRaw(ctx)
+	if err != nil || media == nil {
+		return media, err
+	}
+	am.Finish(ctx, media)
+	return media, nil
+}
+
+// DetectRaw is Detect without Finish: the media as the detector reported
+// it, for storing and finishing later under whichever config reads it back
+func (am *AudioManager) DetectRaw(ctx context.Context) (*MediaInfo, error) {
+	// The detection outlives whichever caller started it, so it runs under a
+	// timeout of its own; each caller stops waiting when its ctx is done
+	results := am.inflight.DoChan("detect", func() (media interface{}, err error) {
+		// A panic on this goroutine couldn't be recovered by any caller, so
+		// it's handed to them as an error like a detector's
+		if !am.cfg.RaiseDetectorPanics {
+			defer func() {
+				if r := recover(); r != nil {
+					media, err = (*MediaInfo)(nil), fmt.Errorf("%w: %v", ErrDetectorPanic, r)
+				}
+			}()
+		}
+		detectCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sharedDetectTimeout)
+		defer cancel()
+		return am.detect(detectCtx)
+	})
+
+	var result singleflight.Result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result = <-results:
+	}
+	shared := result.Val.(*MediaInfo)
+	if result.Err != nil || shared == nil {
+		return nil, result.Err
+	}
+
+	// Hand each caller its own copy so one can't mutate another's result
+	return shared.Clone(), nil
+}
+
+// detect runs the detectors in priority order and returns the first
+// detector's raw media, or the track store's copy of it
+func (am *AudioManager) detect(ctx context.Context) (*MediaInfo, error) {
+	available := am.ListDetectors()
+
+	var permissionErr error
+	tried := 0
+	for _, detector := range available {
+		if am.cfg.MaxDetectors > 0 && tried == am.cfg.MaxDetectors {
+			break
+		}
+		tried++
+
+		var trackID string
+		if am.tracks != nil {
+			cached, id, playing := am.cachedTrack(ctx, detector)
+			if cached != nil {
+				return cached, nil
+			}
+			if !playing {
+				if am.cfg.StopOnEmpty {
+					return nil, nil
+				}
+				continue
+			}
+			trackID = id
 		}
 
-		media, err := detector.Detect(ctx)
+		// The only detector is called directly, without a goroutine to abandon it
+		media, err := am.runDetector(ctx, detector, len(available) == 1)
 		if err == nil && media != nil {
+			media.Detector = detector.Name()
+			if am.tracks != nil {
+				am.tracks.SaveTrack(detector.Name(), trackID, media.Clone())
+			}
 			return media, nil
 		}
+		if err != nil {
+			// The detector failed (network, missing tool, ...) - try the next one
+			if errors.Is(err, ErrAutomationDenied) || errors.Is(err, ErrExecutionPolicy) {
+				permissionErr = err
+			}
+			continue
+		}
+
+		// The detector worked and cleanly reported nothing playing.
+		// With stop_on_empty we trust it instead of asking lower-priority detectors.
+		if am.cfg.StopOnEmpty {
+			return nil, nil
+		}
 	}
 
-	return nil, fmt.Errorf("no audio detected from any source")
+	return nil, noAudioError(permissionErr)
+}
+
+// ErrOverMaxDetectors marks a detector DetectAll didn't run because
+// max_detectors higher-priority ones were already tried
+var ErrOverMaxDetectors = errors.New("not tried, max_detectors reached")
+
+// ErrDetectorPanic marks a detector that crashed rather than returning an error
+var ErrDetectorPanic = errors.New("detector crashed")
+
+// runDetector runs one detector, turning a panic into an error so a broken
+// integration can't abort the commit with a stack trace. With
+// raise_detector_panics the panic goes through, for debugging.
+// With per_detector_timeout_ms the detector gets at most that long of the
+// overall budget, and one that ignores its deadline is abandoned, so a hung
+// player can't starve the detectors after it. With direct there's nothing
+// after it to starve: the detector runs on this goroutine, which is safe
+// because every detector's waits end with ctx - commands are killed at the
+// deadline (see commandWaitDelay), and sockets and requests carry it.
+func (am *AudioManager) runDetector(ctx context.Context, detector Detector, direct bool) (*MediaInfo, error) {
+	if am.cfg.PerDetectorTimeoutMS > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(am.cfg.PerDetectorTimeoutMS)*time.Millisecond)
+		defer cancel()
+	}
+
+	if direct {
+		media, err := am.callDetector(ctx, detector)
+		if ctx.Err() != nil && media == nil {
+			return nil, fmt.Errorf("%s: %w", detector.Name(), ctx.Err())
+		}
+		return media, err
+	}
+
+	type result struct {
+		media *MediaInfo
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		media, err := am.callDetector(ctx, detector)
+		done <- result{media, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.media, r.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", detector.Name(), ctx.Err())
+	}
+}
+
+// callDetector calls the detector, recovering from a panic unless
+// raise_detector_panics is set
+func (am *AudioManager) callDetector(ctx context.Context, detector Detector) (media *MediaInfo, err error) {
+	if !am.cfg.RaiseDetectorPanics {
+		defer func() {
+			if r := recover(); r != nil {
+				media, err = nil, fmt.Errorf("%w: %s: %v", ErrDetectorPanic, detector.Name(), r)
+			}
+		}()
+	}
+	return detector.Detect(ctx)
+}
+
+// DetectorResult is what a single detector reported
+type DetectorResult struct {
+	Detector string
+	Media    *MediaInfo // Nil when the detector found nothing or failed
+	Err      error
+}
+
+// DetectAll asks every available detector, unlike Detect which stops at the first
+// answer. It returns the media Detect would have chosen plus each detector's result
+// in priority order, so callers can explain the choice. Only the chosen media is
+// normalized and enriched.
+func (am *AudioManager) DetectAll(ctx context.Context) (*MediaInfo, []DetectorResult, error) {
+	var results []DetectorResult
+	var selected *MediaInfo
+	var permissionErr error
+	decided := false
+
+	tried := 0
+	for _, detector := range am.detectors {
+		if !am.isAvailable(detector) {
+			continue
+		}
+		if am.cfg.MaxDetectors > 0 && tried == am.cfg.MaxDetectors {
+			results = append(results, DetectorResult{Detector: detector.Name(), Err: ErrOverMaxDetectors})
+			continue
+		}
+		tried++
+
+		media, err := am.runDetector(ctx, detector, false)
+		if err != nil {
+			media = nil
+		}
+		results = append(results, DetectorResult{Detector: detector.Name(), Media: media, Err: err})
+
+		if decided {
+			continue
+		}
+		switch {
+		case media != nil:
+			selected = media.Clone()
+			selected.Detector = detector.Name()
+			am.Finish(ctx, selected)
+			decided = true
+		case err != nil:
+			if errors.Is(err, ErrAutomationDenied) || errors.Is(err, ErrExecutionPolicy) {
+				permissionErr = err
+			}
+		case am.cfg.StopOnEmpty:
+			decided = true
+		}
+	}
+
+	if selected == nil && !decided {
+		return nil, results, noAudioError(permissionErr)
+	}
+	return selected, results, nil
+}
+
+// Finish post-processes raw media from a detector under this manager's
+// config: normalizing, artist handling, redaction and enrichment
+func (am *AudioManager) Finish(ctx context.Context, media *MediaInfo) {
+	Normalize(media, am.cfg.TitleCase)
+	if am.cfg.InferArtistFromTitle {
+		InferArtist(media)
+	}
+	if am.cfg.CollapseVariousArtists {
+		CollapseVariousArtists(media, am.cfg.VariousArtists)
+	}
+	GroupArtists(media, am.cfg.ArtistsSeparator, am.cfg.PrimaryArtistOnly, am.cfg.SplitArtistJoiners)
+	ClassifyClassical(media)
+	if am.cfg.RedactExplicit {
+		RedactExplicit(media)
+	}
+	am.enrich(ctx, media)
+}
+
+// ErrNoAudio is returned when no detector found anything playing
+var ErrNoAudio = errors.New("no audio detected from any source")
+
+// noAudioError is returned when no detector found anything. Permission
+// problems are actionable, so they are surfaced to the caller.
+func noAudioError(permissionErr error) error {
+	if permissionErr != nil {
+		return fmt.Errorf("%w: %w", ErrNoAudio, permissionErr)
+	}
+	return ErrNoAudio
+}
+
+// enrich adds optional, slower metadata that the config has opted into
+func (am *AudioManager) enrich(ctx context.Context, media *MediaInfo) {
+	if am.cfg.MixTracklist {
+		resolveMixTrack(ctx, am.cfg.YouTubeAPIKey, media)
+	}
+	if am.cfg.IncludeCoverColor {
+		am.coverColor(ctx, media)
+	}
+
+	am.liveState(ctx, media)
+}
+
+// liveState reads what can change while a track plays, for the options
+// that need it
+func (am *AudioManager) liveState(ctx context.Context, media *MediaInfo) {
+	// Without a way to read the volume, the track is assumed audible
+	if am.cfg.SkipIfMuted {
+		if state, err := SystemVolume(ctx); err == nil {
+			media.Volume = state.Volume
+			media.Muted = state.Muted || state.Volume == 0
+		}
+	}
+
+	if am.cfg.SkipDuringCalls {
+		media.InCall, _ = ActiveCall(ctx)
+	}
+}
+
+// isLocalURL reports whether a player's track URL names a file on disk:
+// a file:// URL, one served from this machine (a media server on localhost),
+// or Spotify's address for a file added to its library ("spotify:local:..."
+// or open.spotify.com/local/...). Streams have web addresses, or none at all.
+func isLocalURL(rawURL string) bool {
+	if strings.HasPrefix(rawURL, "spotify:local:") {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "file" {
+		return true
+	}
+	if host := u.Hostname(); host == "localhost" || net.ParseIP(host).IsLoopback() {
+		return true
+	}
+	return u.Host == "open.spotify.com" && strings.HasPrefix(u.Path, "/local/")
 }
 
 // ListDetectors returns all available detectors
 func (am *AudioManager) ListDetectors() []Detector {
 	var available []Detector
 	for _, detector := range am.detectors {
-		if detector.IsAvailable() {
+		if am.isAvailable(detector) {
 			available = append(available, detector)
 		}
 	}
@@ -0,0 +1,532 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/config"
+)
+
+// fakeDetector answers with media or err, after delay, and counts its calls.
+// With block it waits for ctx to end instead, like a player that never answers.
+type fakeDetector struct {
+	name  string
+	media *MediaInfo
+	err   error
+	delay time.Duration
+	block bool
+	calls atomic.Int32
+}
+
+func (f *fakeDetector) Name() string      { return f.name }
+func (f *fakeDetector) IsAvailable() bool { return true }
+
+func (f *fakeDetector) Describe() DetectorInfo {
+	return DetectorInfo{Name: f.name, Available: true}
+}
+
+func (f *fakeDetector) Detect(ctx context.Context) (*MediaInfo, error) {
+	f.calls.Add(1)
+	if f.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if f.delay > 0 {
+		time.Sleep(f.delay)
+	}
+	if f.err != nil || f.media == nil {
+		return nil, f.err
+	}
+	media := *f.media
+	return &media, nil
+}
+
+func newTestManager(cfg *config.Config, detectors ...Detector) *AudioManager {
+	return &AudioManager{cfg: cfg, detectors: detectors}
+}
+
+func song() *MediaInfo {
+	return &MediaInfo{Title: "Nightcall", Artist: "Kavinsky", Source: "Test", Type: "song"}
+}
+
+func TestSingleDetectorKeepsPerDetectorTimeout(t *testing.T) {
+	cfg := config.Default()
+	cfg.PerDetectorTimeoutMS = 50
+	am := newTestManager(cfg, &fakeDetector{name: "hung", block: true})
+
+	start := time.Now()
+	media, err := am.Detect(context.Background())
+	if media != nil {
+		t.Errorf("got %+v from a detector that never answered", media)
+	}
+	if err == nil {
+		t.Error("expected an error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("a hung detector held detection for %v", elapsed)
+	}
+}
+
+func TestSingleDetectorStopsAtContextDeadline(t *testing.T) {
+	am := newTestManager(config.Default(), &fakeDetector{name: "hung", block: true})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := am.runDetector(ctx, am.detectors[0], true); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("a hung detector held detection for %v", elapsed)
+	}
+}
+
+func TestSlowDetectorIsAbandonedForTheNext(t *testing.T) {
+	cfg := config.Default()
+	cfg.PerDetectorTimeoutMS = 50
+	next := &fakeDetector{name: "next", media: song()}
+	am := newTestManager(cfg, &fakeDetector{name: "hung", block: true}, next)
+
+	media, err := am.Detect(context.Background())
+	if err != nil || media == nil || media.Title != "Nightcall" {
+		t.Fatalf("Detect() = %+v, %v; want the next detector's track", media, err)
+	}
+	if media.Detector != "next" {
+		t.Errorf("Detector = %q, want %q", media.Detector, "next")
+	}
+}
+
+func TestErrorVersusEmpty(t *testing.T) {
+	tests := []struct {
+		name        string
+		first       *fakeDetector
+		stopOnEmpty bool
+		wantTrack   bool
+	}{
+		{"error falls through", &fakeDetector{name: "first", err: errors.New("network down")}, false, true},
+		{"error falls through with stop_on_empty", &fakeDetector{name: "first", err: errors.New("network down")}, true, true},
+		{"empty falls through", &fakeDetector{name: "first"}, false, true},
+		{"empty stops with stop_on_empty", &fakeDetector{name: "first"}, true, false},
+	}
+
+	for _, tt := range tests {
+		cfg := config.Default()
+		cfg.StopOnEmpty = tt.stopOnEmpty
+		next := &fakeDetector{name: "next", media: song()}
+		am := newTestManager(cfg, tt.first, next)
+
+		media, err := am.Detect(context.Background())
+		if tt.wantTrack {
+			if err != nil || media == nil || media.Detector != "next" {
+				t.Errorf("%s: Detect() = %+v, %v; want the next detector's track", tt.name, media, err)
+			}
+			continue
+		}
+		if media != nil || err != nil {
+			t.Errorf("%s: Detect() = %+v, %v; want nothing and no error", tt.name, media, err)
+		}
+		if next.calls.Load() != 0 {
+			t.Errorf("%s: the next detector was asked", tt.name)
+		}
+	}
+}
+
+func TestMaxDetectors(t *testing.T) {
+	cfg := config.Default()
+	cfg.MaxDetectors = 2
+	first := &fakeDetector{name: "first"}
+	second := &fakeDetector{name: "second"}
+	third := &fakeDetector{name: "third", media: song()}
+	am := newTestManager(cfg, first, second, third)
+
+	media, err := am.Detect(context.Background())
+	if media != nil {
+		t.Errorf("got %+v from a detector past max_detectors", media)
+	}
+	if err == nil {
+		t.Error("expected no audio when the capped detectors found nothing")
+	}
+	if first.calls.Load() != 1 || second.calls.Load() != 1 || third.calls.Load() != 0 {
+		t.Errorf("calls = %d, %d, %d; want the first two in priority order", first.calls.Load(), second.calls.Load(), third.calls.Load())
+	}
+
+	_, results, err := am.DetectAll(context.Background())
+	if !errors.Is(err, ErrNoAudio) {
+		t.Errorf("DetectAll err = %v, want ErrNoAudio", err)
+	}
+	if len(results) != 3 || results[0].Detector != "first" || results[1].Detector != "second" || !errors.Is(results[2].Err, ErrOverMaxDetectors) {
+		t.Errorf("DetectAll results = %+v, want the third marked over max_detectors", results)
+	}
+	if third.calls.Load() != 0 {
+		t.Error("DetectAll ran the detector past max_detectors")
+	}
+}
+
+// panickingDetector crashes whenever it's asked
+type panickingDetector struct{ fakeDetector }
+
+func (p *panickingDetector) Detect(ctx context.Context) (*MediaInfo, error) {
+	p.calls.Add(1)
+	panic("index out of range")
+}
+
+func TestDetectorPanicIsRecovered(t *testing.T) {
+	crashing := &panickingDetector{fakeDetector{name: "crashing"}}
+	next := &fakeDetector{name: "next", media: song()}
+	am := newTestManager(config.Default(), crashing, next)
+
+	media, err := am.Detect(context.Background())
+	if err != nil || media == nil || media.Detector != "next" {
+		t.Fatalf("Detect() = %+v, %v; want the next detector's track", media, err)
+	}
+
+	_, results, err := am.DetectAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || !errors.Is(results[0].Err, ErrDetectorPanic) {
+		t.Errorf("results = %+v, want the first to be ErrDetectorPanic", results)
+	}
+	if results[1].Media == nil {
+		t.Errorf("the detector after the crash wasn't asked: %+v", results[1])
+	}
+
+	// The only detector is called directly, and is recovered there too
+	if _, err := am.runDetector(context.Background(), crashing, true); !errors.Is(err, ErrDetectorPanic) {
+		t.Errorf("direct call err = %v, want ErrDetectorPanic", err)
+	}
+}
+
+// crashingStore is a TrackStore that panics, outside any detector
+type crashingStore struct{}
+
+func (crashingStore) LoadTrack(detector string) (string, *MediaInfo, time.Time, bool) {
+	panic("corrupt cache")
+}
+
+func (crashingStore) SaveTrack(detector, id string, media *MediaInfo) {}
+
+func TestSharedDetectionPanicReachesCaller(t *testing.T) {
+	am := newTestManager(config.Default(), &fakeDetector{name: "plain", media: song()})
+	am.UseTrackStore(crashingStore{}, time.Minute)
+
+	if _, err := am.Detect(context.Background()); !errors.Is(err, ErrDetectorPanic) {
+		t.Errorf("err = %v, want ErrDetectorPanic", err)
+	}
+}
+
+func TestRaiseDetectorPanics(t *testing.T) {
+	cfg := config.Default()
+	cfg.RaiseDetectorPanics = true
+	crashing := &panickingDetector{fakeDetector{name: "crashing"}}
+	am := newTestManager(cfg, crashing)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("raise_detector_panics didn't let the panic through")
+		}
+	}()
+	am.runDetector(context.Background(), crashing, true)
+}
+
+func TestConcurrentDetectsShareOneDetection(t *testing.T) {
+	detector := &fakeDetector{name: "slow", media: song(), delay: 100 * time.Millisecond}
+	am := newTestManager(config.Default(), detector)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	results := make([]*MediaInfo, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			results[i], _ = am.Detect(context.Background())
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if calls := detector.calls.Load(); calls != 1 {
+		t.Errorf("detection ran %d times for %d overlapping calls, want 1", calls, callers)
+	}
+	for i, media := range results {
+		if media == nil || media.Title != "Nightcall" {
+			t.Fatalf("caller %d got %+v", i, media)
+		}
+	}
+	if results[0] == results[1] {
+		t.Error("callers share one MediaInfo; each should get its own copy")
+	}
+}
+
+func TestSharedDetectionOutlivesCanceledCaller(t *testing.T) {
+	playing := song()
+	playing.Artists = []string{"Kavinsky", "Lovefoxxx"}
+	detector := &fakeDetector{name: "slow", media: playing, delay: 100 * time.Millisecond}
+	am := newTestManager(config.Default(), detector)
+
+	// The first caller gives up before the detection it started finishes
+	impatient, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	errs := make(chan error, 1)
+	go func() {
+		_, err := am.DetectRaw(impatient)
+		errs <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+
+	media, err := am.DetectRaw(context.Background())
+	if err != nil || media == nil || media.Title != "Nightcall" {
+		t.Fatalf("second caller got %+v, %v; want the shared track", media, err)
+	}
+	if err := <-errs; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("first caller err = %v, want its own deadline", err)
+	}
+	if calls := detector.calls.Load(); calls != 1 {
+		t.Errorf("detection ran %d times, want 1", calls)
+	}
+
+	// Each caller's Artists is its own
+	other, _ := am.DetectRaw(context.Background())
+	media.Artists[0] = "changed"
+	if other.Artists[0] != "Kavinsky" {
+		t.Errorf("callers share Artists: %v", other.Artists)
+	}
+}
+
+func TestIsLocalURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"file:///home/ada/Music/Nightcall.flac", true},
+		{"file://localhost/home/ada/Music/Nightcall.flac", true},
+		{"http://localhost:8096/Audio/42/stream.flac", true},
+		{"http://127.0.0.1:6600/Nightcall.flac", true},
+		{"http://[::1]:8096/Audio/42/stream.flac", true},
+		{"spotify:local:Kavinsky:OutRun:Nightcall:258", true},
+		{"https://open.spotify.com/local/Kavinsky/OutRun/Nightcall/258", true},
+		{"https://open.spotify.com/track/0U0ldCRmgCqhVvD6ksG63j", false},
+		{"https://radio.example/live.ogg", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isLocalURL(tt.url); got != tt.want {
+			t.Errorf("isLocalURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestMacOSTrackProperties(t *testing.T) {
+	script := propertiesScript("Music", []string{"genre of current track", "name of current playlist"})
+	if strings.Count(script, "on error") != 2 || !strings.HasPrefix(script, `tell application "Music"`) {
+		t.Errorf("script doesn't guard each property:\n%s", script)
+	}
+
+	tests := []struct {
+		output string
+		want   []string
+	}{
+		{"Synthwave\x1fmissing value\x1f\x1f\x1ffile track\x1fDrive\n", []string{"Synthwave", "", "", "", "file track", "Drive"}},
+		{"Synthwave\n", []string{"Synthwave", "", "", "", "", ""}},
+		{"", []string{"", "", "", "", "", ""}},
+	}
+	for _, tt := range tests {
+		got := parseProperties(tt.output, len(tt.want))
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("parseProperties(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+// The single-detector path calls the detector on the caller's goroutine; the
+// benchmarks compare it with the path that runs each detector in a goroutine
+// it can abandon. Run with: go test ./internal/audio -run - -bench Detect
+func BenchmarkDetectSingleDetector(b *testing.B) {
+	am := newTestManager(config.Default(), &fakeDetector{name: "only", media: song()})
+	ctx := context.Background()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := am.detect(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDetectSeveralDetectors(b *testing.B) {
+	am := newTestManager(config.Default(), &fakeDetector{name: "first", media: song()}, &fakeDetector{name: "second", media: song()})
+	ctx := context.Background()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := am.detect(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParseMediaTitle(t *testing.T) {
+	tests := []struct {
+		window string
+		title  string
+		artist string
+		source string
+	}{
+		{"Daft Punk - Harder, Better, Faster, Stronger (Official Video) - YouTube - Google Chrome", "Harder, Better, Faster, Stronger", "Daft Punk", "YouTube"},
+		{"(3) Kavinsky - Nightcall (Drive Original Movie Soundtrack) - YouTube", "Nightcall (Drive Original Movie Soundtrack)", "Kavinsky", "YouTube"},
+		{"Pink Floyd - Shine On You Crazy Diamond (Pts. 1-5) - 2011 Remaster - YouTube", "Shine On You Crazy Diamond (Pts. 1-5) - 2011 Remaster", "Pink Floyd", "YouTube"},
+		{"Daft Punk - Around the World - YouTube - 841 MB - Google Chrome", "Around the World", "Daft Punk", "YouTube"},
+		{"AC/DC - Back In Black (Official Video) - YouTube", "Back In Black", "AC/DC", "YouTube"},
+		{"Mark Ronson - Uptown Funk ft. Bruno Mars - YouTube", "Uptown Funk", "Mark Ronson", "YouTube"},
+		{"lofi hip hop radio - beats to relax/study to - YouTube", "beats to relax/study to", "lofi hip hop radio", "YouTube"},
+		{"Justice - D.A.N.C.E. | Live - YouTube", "D.A.N.C.E. | Live", "Justice", "YouTube"},
+		{"Tame Impala – The Less I Know The Better - YouTube", "The Less I Know The Better", "Tame Impala", "YouTube"},
+		{"Tame Impala – The Less I Know The Better - YouTube – Kabaji", "The Less I Know The Better", "Tame Impala", "YouTube"},
+		{"Nightcall - Kavinsky - YouTube Music", "Nightcall", "Kavinsky", "YouTube Music"},
+		{"Another Brick in the Wall, Pt. 2 - 2011 Remaster - Pink Floyd - YouTube Music", "Another Brick in the Wall, Pt. 2 - 2011 Remaster", "Pink Floyd", "YouTube Music"},
+		{"Midnight City • M83 | Spotify", "Midnight City", "M83", "Spotify"},
+		{"Some Podcast Episode 42 - YouTube", "Some Podcast Episode 42", "Unknown", "YouTube"},
+	}
+
+	m := &MacOSDetector{}
+	for _, tt := range tests {
+		title, artist, source := m.parseMediaTitle(tt.window)
+		if title != tt.title || artist != tt.artist || source != tt.source {
+			t.Errorf("parseMediaTitle(%q) = %q, %q, %q; want %q, %q, %q", tt.window, title, artist, source, tt.title, tt.artist, tt.source)
+		}
+	}
+}
+
+func TestWSLOutputKeepsAccents(t *testing.T) {
+	tests := []struct {
+		fixture string
+		title   string
+		artist  string
+	}{
+		// The script's output with its UTF-8 console encoding, led by a byte order mark
+		{"testdata/powershell-utf8.txt", "Sæglópur", "Sigur Rós"},
+		// A host that refused the encoding change, writing Windows-1252
+		{"testdata/powershell-cp1252.txt", "Jóga", "Björk"},
+	}
+
+	w := &WSLWindowsDetector{}
+	for _, tt := range tests {
+		output, err := os.ReadFile(tt.fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		media, err := w.parseOutput(output)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.fixture, err)
+		}
+		if media == nil || media.Title != tt.title || media.Artist != tt.artist {
+			t.Errorf("%s: got %+v, want %q by %q", tt.fixture, media, tt.title, tt.artist)
+		}
+	}
+}
+
+func TestWSLOutputWithoutMedia(t *testing.T) {
+	media, err := (&WSLWindowsDetector{}).parseOutput([]byte("\r\n"))
+	if media != nil || err != nil {
+		t.Errorf("parseOutput() = %+v, %v; want nothing", media, err)
+	}
+}
+
+// powershellRunner answers powershell.exe with output, keeping the arguments it was run with
+type powershellRunner struct {
+	output string
+	args   []string
+}
+
+func (p *powershellRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	p.args = args
+	return []byte(p.output), nil
+}
+
+func (p *powershellRunner) LookPath(name string) (string, error) {
+	return "/mnt/c/Windows/System32/WindowsPowerShell/v1.0/" + name, nil
+}
+
+func TestWSLScriptPrintsOneJSONLine(t *testing.T) {
+	// A machine-wide profile can still print around our result, despite -NoProfile
+	runner := &powershellRunner{output: "Loading corporate profile...\r\n" +
+		`{"Title":"Nightcall","Artist":"Kavinsky","Source":"Spotify","Album":""}` + "\r\n" +
+		"WARNING: Update available\r\n"}
+	useCommands(t, runner)
+
+	media, err := (&WSLWindowsDetector{}).Detect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media == nil || media.Title != "Nightcall" || media.Artist != "Kavinsky" || media.Source != "Spotify" {
+		t.Errorf("Detect() = %+v", media)
+	}
+
+	if !slices.Contains(runner.args, "-NoProfile") || !slices.Contains(runner.args, "-NonInteractive") {
+		t.Errorf("PowerShell args = %q, want -NoProfile -NonInteractive", runner.args[:len(runner.args)-1])
+	}
+	script := runner.args[len(runner.args)-1]
+
+	// Every result is printed compressed, so it's one line jsonLine can find
+	printed := strings.Count(script, "| ConvertTo-Json")
+	if compressed := strings.Count(script, "| ConvertTo-Json -Compress"); printed == 0 || compressed != printed {
+		t.Errorf("%d of %d results printed with -Compress", compressed, printed)
+	}
+
+	// And every one has the keys parseOutput reads
+	results := regexp.MustCompile(`\$result = @\{([^}]*)\}`).FindAllStringSubmatch(script, -1)
+	if len(results) == 0 {
+		t.Fatal("no results in the script")
+	}
+	for _, result := range results {
+		var keys []string
+		for _, pair := range strings.Split(result[1], ";") {
+			key, _, _ := strings.Cut(pair, "=")
+			keys = append(keys, strings.TrimSpace(key))
+		}
+		slices.Sort(keys)
+		if want := []string{"Album", "Artist", "Source", "Title"}; !slices.Equal(keys, want) {
+			t.Errorf("result keys = %q, want %q: %s", keys, want, result[0])
+		}
+	}
+}
+
+func TestJSONLine(t *testing.T) {
+	const result = `{"Title":"Nightcall","Artist":"Kavinsky","Source":"Spotify","Album":""}`
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"alone", result + "\r\n", result},
+		{"after a profile banner", "Loading corporate profile...\r\n" + result + "\r\n", result},
+		{"before a warning", result + "\r\nWARNING: Update available\r\n", result},
+		{"byte order mark", "\ufeff" + result + "\r\n", result},
+		{"the last of two", `{"Title":"Old"}` + "\n" + result + "\n", result},
+		{"no result", "Loading corporate profile...\r\n", ""},
+		{"empty", "", ""},
+		{"unterminated", `{"Title":"Nightcall"` + "\r\n", ""},
+	}
+
+	for _, tt := range tests {
+		if got := jsonLine(tt.output); got != tt.want {
+			t.Errorf("%s: jsonLine() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWSLOutputMalformedJSON(t *testing.T) {
+	if _, err := (&WSLWindowsDetector{}).parseOutput([]byte(`{"Title": Nightcall}` + "\r\n")); err == nil {
+		t.Error("expected an error for malformed JSON")
+	}
+}
@@ -0,0 +1,36 @@
+package audio
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// cp1252High maps bytes 0x80-0x9F, the range where Windows-1252 differs from
+// Latin-1. Bytes Windows-1252 leaves undefined become the replacement character.
+var cp1252High = [32]rune{
+	'€', utf8.RuneError, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', utf8.RuneError, 'Ž', utf8.RuneError,
+	utf8.RuneError, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', utf8.RuneError, 'ž', 'Ÿ',
+}
+
+// toUTF8 turns subprocess output into valid UTF-8. Output that isn't UTF-8
+// is taken to be Windows-1252 (a superset of Latin-1), which is what players
+// in legacy locales and Windows consoles most often emit, so "Bj\xf6rk" reads "Björk".
+func toUTF8(output []byte) string {
+	if utf8.Valid(output) {
+		return string(output)
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(output) + len(output)/2)
+	for _, b := range output {
+		switch {
+		case b < 0x80:
+			sb.WriteByte(b)
+		case b < 0xA0:
+			sb.WriteRune(cp1252High[b-0x80])
+		default:
+			sb.WriteRune(rune(b)) // Latin-1 matches the first 256 code points
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,25 @@
+package audio
+
+import "testing"
+
+func TestToUTF8(t *testing.T) {
+	tests := []struct {
+		name   string
+		output []byte
+		want   string
+	}{
+		{"latin-1 artist", []byte("Bj\xf6rk"), "Björk"},
+		{"latin-1 title", []byte("Beyonc\xe9 - D\xe9j\xe0 Vu"), "Beyoncé - Déjà Vu"},
+		{"windows-1252 quotes and dash", []byte("\x93Hyper\x94 \x96 Bj\xf6rk"), "“Hyper” – Björk"},
+		{"undefined windows-1252 byte", []byte("A\x81B"), "A�B"},
+		{"already UTF-8", []byte("Björk"), "Björk"},
+		{"ASCII", []byte("Kavinsky"), "Kavinsky"},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		if got := toUTF8(tt.output); got != tt.want {
+			t.Errorf("%s: toUTF8(%q) = %q, want %q", tt.name, tt.output, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package audio
+
+import "strings"
+
+// ExplicitTitle stands in for the title of explicit tracks with redact_explicit
+const ExplicitTitle = "an explicit track"
+
+// RedactExplicit hides what an explicit track is called: the title becomes
+// ExplicitTitle, and the link and URI that would name it are dropped, along
+// with an album named after the track, as singles are, whatever its case.
+// Tracks not known to be explicit are left alone.
+func RedactExplicit(media *MediaInfo) {
+	if media == nil || !media.Explicit {
+		return
+	}
+	if media.Title != "" && strings.Contains(strings.ToLower(media.Album), strings.ToLower(media.Title)) {
+		media.Album = ""
+	}
+	media.Title = ExplicitTitle
+	media.URL = ""
+	media.TrackURI = ""
+}
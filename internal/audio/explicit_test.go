@@ -0,0 +1,34 @@
+package audio
+
+import "testing"
+
+func TestRedactExplicit(t *testing.T) {
+	tests := []struct {
+		name      string
+		media     MediaInfo
+		wantTitle string
+		wantAlbum string
+	}{
+		{"explicit", MediaInfo{Title: "Swear Words", Album: "Late Night Tales", Explicit: true}, ExplicitTitle, "Late Night Tales"},
+		{"explicit single", MediaInfo{Title: "Swear Words", Album: "Swear Words", Explicit: true}, ExplicitTitle, ""},
+		{"single in another case", MediaInfo{Title: "Swear Words", Album: "SWEAR WORDS (Deluxe)", Explicit: true}, ExplicitTitle, ""},
+		{"clean", MediaInfo{Title: "Nightcall", Album: "Nightcall", Explicit: false}, "Nightcall", "Nightcall"},
+	}
+
+	for _, tt := range tests {
+		media := tt.media
+		media.URL, media.TrackURI = "https://open.spotify.com/track/abc", "spotify:track:abc"
+		RedactExplicit(&media)
+		if media.Title != tt.wantTitle || media.Album != tt.wantAlbum {
+			t.Errorf("%s: title, album = %q, %q; want %q, %q", tt.name, media.Title, media.Album, tt.wantTitle, tt.wantAlbum)
+		}
+		if tt.media.Explicit && (media.URL != "" || media.TrackURI != "") {
+			t.Errorf("%s: the link still names the track: %q, %q", tt.name, media.URL, media.TrackURI)
+		}
+		if !tt.media.Explicit && media.URL == "" {
+			t.Errorf("%s: a clean track lost its link", tt.name)
+		}
+	}
+
+	RedactExplicit(nil) // Nothing playing
+}
@@ -0,0 +1,109 @@
+package audio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// fifoReadTimeout bounds how long we wait for a daemon to write to the FIFO
+const fifoReadTimeout = 200 * time.Millisecond
+
+// FifoDetector reads now-playing info written by a user's own daemon to a FIFO or file.
+// Each line is either JSON ({"title": ..., "artist": ..., "source": ...})
+// or "Title||Artist||Source"; the latest line wins.
+type FifoDetector struct {
+	Path string
+}
+
+func (f *FifoDetector) Name() string {
+	return "FIFO/file (" + f.Path + ")"
+}
+
+func (f *FifoDetector) IsAvailable() bool {
+	return f.Describe().Available
+}
+
+func (f *FifoDetector) Describe() DetectorInfo {
+	info := DetectorInfo{Name: f.Name(), Requires: []string{"fifo_path"}, Available: true}
+	if f.Path == "" {
+		return info.unavailable("fifo_path isn't set")
+	}
+	if _, err := os.Stat(f.Path); err != nil {
+		return info.unavailable("%s doesn't exist - is your daemon running?", f.Path)
+	}
+	return info
+}
+
+func (f *FifoDetector) Detect(ctx context.Context) (*MediaInfo, error) {
+	// Non-blocking open so a FIFO without a writer doesn't hang us
+	file, err := os.OpenFile(f.Path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	deadline := time.Now().Add(fifoReadTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	// Regular files don't support deadlines, they just read to EOF
+	file.SetReadDeadline(deadline)
+
+	data, err := io.ReadAll(file)
+	if err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+		return nil, err
+	}
+
+	return parseFifoLine(lastLine(toUTF8(data))), nil
+}
+
+// lastLine returns the final non-empty line of s
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// parseFifoLine parses a JSON object or a "Title||Artist||Source" line
+func parseFifoLine(line string) *MediaInfo {
+	if line == "" {
+		return nil
+	}
+
+	var media MediaInfo
+	if strings.HasPrefix(line, "{") {
+		if err := json.Unmarshal([]byte(line), &media); err != nil {
+			return nil
+		}
+	} else {
+		parts := strings.Split(line, "||")
+		media.Title = strings.TrimSpace(parts[0])
+		if len(parts) > 1 {
+			media.Artist = strings.TrimSpace(parts[1])
+		}
+		if len(parts) > 2 {
+			media.Source = strings.TrimSpace(parts[2])
+		}
+	}
+
+	if !isUsableTitle(media.Title) {
+		return nil
+	}
+	if media.Source == "" {
+		media.Source = "Custom"
+	}
+	if media.Type == "" {
+		media.Type = "song"
+	}
+	return &media
+}
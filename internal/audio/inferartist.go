@@ -0,0 +1,81 @@
+package audio
+
+import (
+	"regexp"
+	"strings"
+)
+
+// titleSeparators split "Artist - Song" video titles
+var titleSeparators = []string{" - ", " – ", " — "}
+
+// musicMarkers are the trailing tags music uploads carry, e.g. "(Official Video)"
+var musicMarkers = regexp.MustCompile(`(?i)\s*[\[(](official\s+(music\s+)?(video|audio|visualizer)|lyrics?(\s+video)?|audio|video|visualizer|hd|4k|remastered(\s+\d{4})?)[\])]`)
+
+// nonArtistWords start the first half of titles that aren't "Artist - Song"
+var nonArtistWords = map[string]bool{
+	"how": true, "why": true, "what": true, "when": true, "where": true, "who": true,
+	"top": true, "best": true, "episode": true, "ep": true, "part": true, "chapter": true,
+	"lecture": true, "day": true, "week": true, "live": true,
+}
+
+// nonMusicWords in the second half give away tutorials, reviews and the like
+var nonMusicWords = []string{"tutorial", "review", "explained", "guide", "podcast", "interview", "trailer", "gameplay", "walkthrough", "unboxing"}
+
+// maxArtistWords keeps sentences from being mistaken for artist names
+const maxArtistWords = 5
+
+// InferArtist splits an artist off a video's "Artist - Song" title. It only
+// touches videos without an artist and is conservative: the title must have
+// exactly one separator and neither half may look like something other than music.
+func InferArtist(media *MediaInfo) {
+	if media == nil || media.Type != "video" || media.Artist != "" {
+		return
+	}
+
+	artist, song, ok := splitArtistTitle(media.Title)
+	if !ok {
+		return
+	}
+
+	media.Artist = artist
+	media.Title = song
+	media.Type = "song"
+}
+
+func splitArtistTitle(title string) (artist, song string, ok bool) {
+	var parts []string
+	for _, sep := range titleSeparators {
+		if split := strings.Split(title, sep); len(split) > 1 {
+			if parts != nil || len(split) != 2 {
+				return "", "", false // Several separators: too ambiguous to guess
+			}
+			parts = split
+		}
+	}
+	if parts == nil {
+		return "", "", false
+	}
+
+	artist = strings.TrimSpace(parts[0])
+	song = strings.TrimSpace(musicMarkers.ReplaceAllString(parts[1], ""))
+	if artist == "" || song == "" {
+		return "", "", false
+	}
+
+	words := strings.Fields(artist)
+	if len(words) > maxArtistWords || nonArtistWords[strings.ToLower(words[0])] {
+		return "", "", false
+	}
+	if strings.ContainsAny(artist, ":?|#") {
+		return "", "", false
+	}
+
+	lowerSong := strings.ToLower(song)
+	for _, word := range nonMusicWords {
+		if strings.Contains(lowerSong, word) {
+			return "", "", false
+		}
+	}
+
+	return artist, song, true
+}
@@ -0,0 +1,56 @@
+package audio
+
+import "testing"
+
+func TestInferArtist(t *testing.T) {
+	tests := []struct {
+		title  string
+		artist string // Empty when the title must be left whole
+		song   string
+	}{
+		// Music uploads
+		{"Daft Punk - Harder, Better, Faster, Stronger (Official Video)", "Daft Punk", "Harder, Better, Faster, Stronger"},
+		{"Kavinsky - Nightcall [Official Audio]", "Kavinsky", "Nightcall"},
+		{"Tame Impala – The Less I Know The Better", "Tame Impala", "The Less I Know The Better"},
+		{"Sigur Rós — Hoppípolla (Lyrics)", "Sigur Rós", "Hoppípolla"},
+
+		// Everything else
+		{"How to Center a Div - CSS Tutorial", "", ""},
+		{"Top 10 Synthwave Tracks - 2024", "", ""},
+		{"Episode 42 - The One Where We Talk About Go", "", ""},
+		{"Framework Laptop 16 - Review", "", ""},
+		{"Go 1.22: What's New - Explained", "", ""},
+		{"Elden Ring - Gameplay Walkthrough - Part 1", "", ""},
+		{"Why I Switched to Linux After Twenty Years of Windows - My Story", "", ""},
+		{"lofi hip hop radio 📚 beats to relax/study to", "", ""},
+	}
+
+	for _, tt := range tests {
+		media := &MediaInfo{Title: tt.title, Type: "video", Source: "YouTube"}
+		InferArtist(media)
+
+		if tt.artist == "" {
+			if media.Artist != "" || media.Title != tt.title || media.Type != "video" {
+				t.Errorf("%q was split into %q by %q (%s)", tt.title, media.Title, media.Artist, media.Type)
+			}
+			continue
+		}
+		if media.Artist != tt.artist || media.Title != tt.song || media.Type != "song" {
+			t.Errorf("%q = %q by %q (%s), want %q by %q (song)", tt.title, media.Title, media.Artist, media.Type, tt.song, tt.artist)
+		}
+	}
+}
+
+func TestInferArtistLeavesKnownArtists(t *testing.T) {
+	media := &MediaInfo{Title: "Daft Punk - Around the World", Artist: "DaftPunkVEVO", Type: "video"}
+	InferArtist(media)
+	if media.Artist != "DaftPunkVEVO" || media.Title != "Daft Punk - Around the World" {
+		t.Errorf("an existing artist was replaced: %+v", media)
+	}
+
+	song := &MediaInfo{Title: "Kavinsky - Nightcall", Type: "song"}
+	InferArtist(song)
+	if song.Artist != "" {
+		t.Errorf("a song's title was split: %+v", song)
+	}
+}
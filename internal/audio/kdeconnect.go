@@ -0,0 +1,140 @@
+package audio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	kdeConnectService   = "org.kde.kdeconnect"
+	kdeConnectDevices   = "/modules/kdeconnect/devices/"
+	kdeConnectMedia     = "org.kde.kdeconnect.device.mprisremote"
+	kdeConnectDeviceAPI = "org.kde.kdeconnect.device"
+)
+
+// KDEConnectDetector reads the media playing on a paired phone, relayed by
+// KDE Connect's media control plugin, over the user's D-Bus session
+type KDEConnectDetector struct{}
+
+func (k *KDEConnectDetector) Name() string {
+	return "KDE Connect (phone)"
+}
+
+func (k *KDEConnectDetector) IsAvailable() bool {
+	return k.Describe().Available
+}
+
+func (k *KDEConnectDetector) Describe() DetectorInfo {
+	info := DetectorInfo{Name: k.Name(), Requires: []string{"busctl", "KDE Connect"}, Available: true}
+	if runtime.GOOS != "linux" {
+		return info.otherPlatform("Linux")
+	}
+	if reason := missingTool("busctl"); reason != "" {
+		return info.unavailable("%s", reason)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	devices, err := k.devices(ctx)
+	if err != nil {
+		return info.unavailable("the KDE Connect daemon isn't running")
+	}
+	if len(devices) == 0 {
+		return info.unavailable("no paired phone is reachable")
+	}
+	return info
+}
+
+func (k *KDEConnectDetector) Detect(ctx context.Context) (*MediaInfo, error) {
+	devices, err := k.devices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, device := range devices {
+		path := kdeConnectDevices + device + "/mprisremote"
+
+		// Fails when media sharing is turned off for this device
+		var isPlaying bool
+		var title, artist, album string
+		var length, position int64
+		err := busctlProperties(ctx, path, kdeConnectMedia,
+			[]string{"isPlaying", "title", "artist", "album", "length", "position"},
+			&isPlaying, &title, &artist, &album, &length, &position)
+		if err != nil || !isPlaying || title == "" {
+			continue
+		}
+
+		// Name the phone, falling back to KDE Connect's device ID
+		source := device
+		var name string
+		if err := busctlProperties(ctx, kdeConnectDevices+device, kdeConnectDeviceAPI, []string{"name"}, &name); err == nil && name != "" {
+			source = name
+		}
+
+		return &MediaInfo{
+			Title:    title,
+			Artist:   artist,
+			Album:    album,
+			Source:   source,
+			Type:     "song",
+			Duration: time.Duration(length) * time.Millisecond,
+			Position: time.Duration(position) * time.Millisecond,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// devices lists the IDs of paired devices that are currently reachable
+func (k *KDEConnectDetector) devices(ctx context.Context) ([]string, error) {
+	output, err := commandOutput(ctx, "busctl", "--user", "--json=short", "call",
+		kdeConnectService, "/modules/kdeconnect", "org.kde.kdeconnect.daemon",
+		"devices", "bb", "true", "true")
+	if err != nil {
+		return nil, fmt.Errorf("KDE Connect not running: %w", err)
+	}
+
+	var reply struct {
+		Data [][]string `json:"data"`
+	}
+	if err := json.Unmarshal(output, &reply); err != nil {
+		return nil, fmt.Errorf("unexpected busctl output: %w", err)
+	}
+	if len(reply.Data) == 0 {
+		return nil, nil
+	}
+	return reply.Data[0], nil
+}
+
+// busctlProperties reads D-Bus properties of a KDE Connect object into out, in order
+func busctlProperties(ctx context.Context, path, iface string, properties []string, out ...interface{}) error {
+	args := append([]string{"--user", "--json=short", "get-property", kdeConnectService, path, iface}, properties...)
+	output, err := commandOutput(ctx, "busctl", args...)
+	if err != nil {
+		return err
+	}
+
+	// One JSON object per property: {"type":"s","data":"..."}
+	lines := strings.Split(strings.TrimSpace(toUTF8(output)), "\n")
+	if len(lines) != len(out) {
+		return fmt.Errorf("expected %d properties from busctl, got %d", len(out), len(lines))
+	}
+	for i, line := range lines {
+		var value struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(line), &value); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(value.Data, out[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
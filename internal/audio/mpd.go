@@ -0,0 +1,182 @@
+package audio
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mpdTimeout bounds the whole exchange with MPD, so a dead server can't stall a commit
+const mpdTimeout = time.Second
+
+// MPDDetector asks the Music Player Daemon what it's playing over its text
+// protocol. Host is "host:port", a Unix socket path, or either prefixed with
+// "password@", the way MPD_HOST is written.
+type MPDDetector struct {
+	Host string
+}
+
+func (m *MPDDetector) Name() string {
+	return "MPD (" + m.address() + ")"
+}
+
+func (m *MPDDetector) IsAvailable() bool {
+	return m.Describe().Available
+}
+
+func (m *MPDDetector) Describe() DetectorInfo {
+	info := DetectorInfo{Name: m.Name(), Requires: []string{"mpd"}, Available: true}
+	if m.Host == "" {
+		return info.unavailable("mpd_host isn't set")
+	}
+	// Whether MPD is listening is left to Detect, under the caller's deadline
+	return info
+}
+
+func (m *MPDDetector) Detect(ctx context.Context) (*MediaInfo, error) {
+	conn, err := m.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	status, err := conn.command("status")
+	if err != nil {
+		return nil, err
+	}
+	if status["state"] != "play" {
+		return nil, nil // Stopped or paused
+	}
+
+	song, err := conn.command("currentsong")
+	if err != nil {
+		return nil, err
+	}
+	return mpdMedia(status, song), nil
+}
+
+// mpdMedia builds the track from MPD's status and currentsong replies
+func mpdMedia(status, song map[string]string) *MediaInfo {
+	file := song["file"]
+	media := &MediaInfo{
+		Title:    song["Title"],
+		Artist:   song["Artist"],
+		Album:    song["Album"],
+		Source:   "MPD",
+		Type:     "song",
+		Duration: mpdSeconds(status["duration"]),
+		Position: mpdSeconds(status["elapsed"]),
+		Genre:    song["Genre"],
+		Composer: song["Composer"],
+		Quality:  mpdQuality(status["audio"], status["bitrate"], file),
+	}
+
+	// MPD gives library songs as paths relative to its music directory
+	media.Local = !strings.Contains(file, "://") || isLocalURL(file)
+	if strings.HasPrefix(file, "http://") || strings.HasPrefix(file, "https://") {
+		media.URL = file
+		if media.Title == "" {
+			media.Title = song["Name"] // Radio streams often only name the station
+		}
+	} else if media.Title == "" {
+		media.Title, media.Artist = titleFromFilename(file)
+	}
+	return media
+}
+
+// mpdSeconds reads MPD's fractional seconds, e.g. "123.456"
+func mpdSeconds(value string) time.Duration {
+	seconds, _ := strconv.ParseFloat(value, 64)
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// address is Host without its password
+func (m *MPDDetector) address() string {
+	if i := strings.LastIndex(m.Host, "@"); i >= 0 {
+		return m.Host[i+1:]
+	}
+	return m.Host
+}
+
+// mpdConn is a connection to MPD that has passed its greeting
+type mpdConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (m *MPDDetector) connect(ctx context.Context) (*mpdConn, error) {
+	deadline := time.Now().Add(mpdTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	address := m.address()
+	network := "tcp"
+	if strings.HasPrefix(address, "/") || strings.HasPrefix(address, "@") {
+		network = "unix"
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, fmt.Errorf("MPD isn't listening on %s", address)
+	}
+	conn.SetDeadline(deadline)
+
+	c := &mpdConn{Conn: conn, reader: bufio.NewReader(conn)}
+	greeting, err := c.reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(greeting, "OK MPD ") {
+		conn.Close()
+		return nil, fmt.Errorf("%s isn't an MPD server", address)
+	}
+
+	if i := strings.LastIndex(m.Host, "@"); i > 0 {
+		if _, err := c.command("password " + mpdQuote(m.Host[:i])); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// command sends one command and reads its "Key: value" reply up to "OK"
+func (c *mpdConn) command(command string) (map[string]string, error) {
+	if _, err := fmt.Fprintf(c, "%s\n", command); err != nil {
+		return nil, err
+	}
+	return readMPDReply(c.reader)
+}
+
+// readMPDReply reads "Key: value" lines until "OK", or fails on "ACK [...] message".
+// A key that repeats (several Artist tags) keeps its first value.
+func readMPDReply(reader *bufio.Reader) (map[string]string, error) {
+	reply := map[string]string{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\n")
+
+		if line == "OK" {
+			return reply, nil
+		}
+		if strings.HasPrefix(line, "ACK ") {
+			return nil, fmt.Errorf("MPD: %s", strings.TrimPrefix(line, "ACK "))
+		}
+		if key, value, ok := strings.Cut(line, ": "); ok {
+			if _, seen := reply[key]; !seen {
+				reply[key] = value
+			}
+		}
+	}
+}
+
+// mpdQuote quotes an argument for the MPD protocol
+func mpdQuote(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
@@ -0,0 +1,182 @@
+package audio
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// mpvTimeout bounds the whole exchange with mpv, so a dead socket can't stall a commit
+const mpvTimeout = time.Second
+
+// MPVDetector asks mpv what it's playing over its JSON IPC socket,
+// enabled in mpv with --input-ipc-server=<path>
+type MPVDetector struct {
+	SocketPath string
+}
+
+func (m *MPVDetector) Name() string {
+	return "mpv IPC (" + m.SocketPath + ")"
+}
+
+func (m *MPVDetector) IsAvailable() bool {
+	return m.Describe().Available
+}
+
+func (m *MPVDetector) Describe() DetectorInfo {
+	info := DetectorInfo{Name: m.Name(), Requires: []string{"mpv --input-ipc-server"}, Available: true}
+	if m.SocketPath == "" {
+		return info.unavailable("mpv_socket isn't set")
+	}
+
+	// The socket file outlives a crashed mpv, so make sure something answers
+	ctx, cancel := context.WithTimeout(context.Background(), mpvTimeout)
+	defer cancel()
+
+	conn, err := m.connect(ctx)
+	if err != nil {
+		return info.unavailable("mpv isn't listening on %s", m.SocketPath)
+	}
+	defer conn.Close()
+
+	var idle bool
+	if err := conn.get("idle-active", &idle); err != nil {
+		return info.unavailable("mpv didn't answer on %s", m.SocketPath)
+	}
+	return info
+}
+
+func (m *MPVDetector) Detect(ctx context.Context) (*MediaInfo, error) {
+	conn, err := m.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var idle bool
+	if err := conn.get("idle-active", &idle); err != nil {
+		return nil, err
+	}
+	if idle {
+		return nil, nil // mpv is open with nothing loaded
+	}
+
+	var title string
+	if err := conn.get("media-title", &title); err != nil {
+		return nil, err
+	}
+
+	// Properties a stream may not have are simply left empty
+	var metadata map[string]string
+	var position, duration float64
+	var path, codec string
+	var sampleRate int
+	var bitrate float64
+	conn.get("metadata", &metadata)
+	conn.get("time-pos", &position)
+	conn.get("duration", &duration)
+	conn.get("path", &path)
+	conn.get("audio-codec-name", &codec)
+	conn.get("audio-params/samplerate", &sampleRate)
+	conn.get("audio-bitrate", &bitrate)
+
+	media := &MediaInfo{
+		Title:    title,
+		Artist:   mpvTag(metadata, "artist"),
+		Album:    mpvTag(metadata, "album"),
+		Source:   "mpv",
+		Type:     "song",
+		Duration: time.Duration(duration * float64(time.Second)),
+		Position: time.Duration(position * float64(time.Second)),
+		Genre:    mpvTag(metadata, "genre"),
+		Composer: mpvTag(metadata, "composer"),
+		Quality:  formatQuality(codec, sampleRate, 0, int(bitrate)),
+	}
+
+	// mpv gives local files as plain paths; anything else has a protocol
+	media.Local = path != "" && !strings.Contains(path, "://") || isLocalURL(path)
+
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		media.URL = path
+	} else if media.Title == filepath.Base(path) && media.Artist == "" {
+		// Untagged local file: media-title is just the file name
+		media.Title, media.Artist = titleFromFilename(path)
+	}
+
+	return media, nil
+}
+
+// mpvTag looks up a metadata tag; containers differ in tag case ("artist", "ARTIST")
+func mpvTag(metadata map[string]string, key string) string {
+	for k, v := range metadata {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// mpvConn is a connection to mpv's IPC socket
+type mpvConn struct {
+	net.Conn
+	reader    *bufio.Reader
+	requestID int
+}
+
+func (m *MPVDetector) connect(ctx context.Context) (*mpvConn, error) {
+	deadline := time.Now().Add(mpvTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", m.SocketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mpv: %w", err)
+	}
+	conn.SetDeadline(deadline)
+
+	return &mpvConn{Conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// get reads a property into out
+func (c *mpvConn) get(property string, out interface{}) error {
+	c.requestID++
+	request := map[string]interface{}{
+		"command":    []string{"get_property", property},
+		"request_id": c.requestID,
+	}
+	if err := json.NewEncoder(c).Encode(request); err != nil {
+		return err
+	}
+
+	// Skip any events until our reply arrives
+	for {
+		line, err := c.reader.ReadBytes('\n')
+		if err != nil {
+			return err
+		}
+
+		var reply struct {
+			Event     string          `json:"event"`
+			RequestID int             `json:"request_id"`
+			Error     string          `json:"error"`
+			Data      json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(line, &reply); err != nil {
+			continue
+		}
+		if reply.Event != "" || reply.RequestID != c.requestID {
+			continue
+		}
+		if reply.Error != "success" {
+			return fmt.Errorf("mpv %s: %s", property, reply.Error)
+		}
+		return json.Unmarshal(reply.Data, out)
+	}
+}
@@ -0,0 +1,126 @@
+package audio
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Normalize cleans up detector output so every source looks alike:
+// it trims and collapses whitespace, empties placeholder tags like
+// "missing value", strips YouTube channel artifacts like
+// " - Topic" and "VEVO" from the artist, and, when titleCase is set,
+// rewrites fields shouted in ALL CAPS in title case.
+func Normalize(media *MediaInfo, titleCase bool) {
+	if media == nil {
+		return
+	}
+
+	media.Title = collapseSpaces(media.Title)
+	media.Artist = cleanArtist(tagValue(media.Artist))
+	media.Album = tagValue(media.Album)
+	media.Source = collapseSpaces(media.Source)
+
+	if titleCase {
+		media.Title = titleCaseIfShouting(media.Title)
+		media.Artist = titleCaseIfShouting(media.Artist)
+		media.Album = titleCaseIfShouting(media.Album)
+	}
+}
+
+// placeholderTags are what players report instead of an empty tag: AppleScript's
+// "missing value", and "unknown artist" in the languages Music.app and
+// Spotify are localized to. Compared lowercased.
+var placeholderTags = map[string]bool{
+	"missing value":         true,
+	"unknown artist":        true,
+	"unknown album":         true,
+	"artiste inconnu":       true,
+	"album inconnu":         true,
+	"unbekannter interpret": true,
+	"unbekanntes album":     true,
+	"artista desconocido":   true,
+	"álbum desconocido":     true,
+	"artista sconosciuto":   true,
+	"album sconosciuto":     true,
+	"artista desconhecido":  true,
+	"álbum desconhecido":    true,
+	"onbekende artiest":     true,
+	"onbekend album":        true,
+	"不明なアーティスト":             true,
+	"不明なアルバム":               true,
+	"未知艺人":                  true,
+	"未知专辑":                  true,
+	"неизвестный исполнитель": true,
+	"неизвестный альбом":      true,
+}
+
+// tagValue cleans up a tag read from a player: whitespace and invisible
+// characters are trimmed, and placeholders for a missing tag become empty,
+// so a line never reads "Song" by  (Spotify)
+func tagValue(value string) string {
+	value = collapseSpaces(strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Cf, r) { // Zero-width spaces, BOMs and the like
+			return -1
+		}
+		return r
+	}, value))
+	if placeholderTags[strings.ToLower(value)] {
+		return ""
+	}
+	return value
+}
+
+// collapseSpaces trims and replaces runs of whitespace with a single space
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// cleanArtist removes YouTube channel decorations from an artist name
+func cleanArtist(artist string) string {
+	// YouTube Music auto-generated channels: "Artist - Topic"
+	artist = strings.TrimSuffix(artist, " - Topic")
+
+	// Label channels: "ArtistVEVO" or "Artist VEVO"
+	if strings.HasSuffix(artist, "VEVO") && len(artist) > len("VEVO") {
+		artist = strings.TrimSpace(strings.TrimSuffix(artist, "VEVO"))
+	}
+
+	// Artists' own channels: "Artist Official" or "Artist - Official"
+	for _, suffix := range []string{" - Official", " Official"} {
+		if trimmed := strings.TrimSuffix(artist, suffix); trimmed != artist && trimmed != "" {
+			artist = trimmed
+			break
+		}
+	}
+
+	return artist
+}
+
+// titleCaseIfShouting title-cases s only when it has no lowercase letters,
+// leaving deliberate styling like "deadmau5" alone, and keeps words with
+// punctuation inside, like "AC/DC" or "R.E.M.", as they are
+func titleCaseIfShouting(s string) string {
+	hasUpper := false
+	for _, r := range s {
+		if unicode.IsLower(r) {
+			return s
+		}
+		if unicode.IsUpper(r) {
+			hasUpper = true
+		}
+	}
+	if !hasUpper {
+		return s
+	}
+
+	words := strings.Fields(s)
+	for i, word := range words {
+		if strings.ContainsAny(strings.TrimRight(word, ".,!?"), "./") {
+			continue
+		}
+		runes := []rune(strings.ToLower(word))
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,73 @@
+package audio
+
+import "testing"
+
+func TestCleanArtist(t *testing.T) {
+	tests := []struct {
+		artist string
+		want   string
+	}{
+		{"Kavinsky - Topic", "Kavinsky"},
+		{"DaftPunkVEVO", "DaftPunk"},
+		{"Adele VEVO", "Adele"},
+		{"VEVO", "VEVO"},
+		{"Arctic Monkeys Official", "Arctic Monkeys"},
+		{"Tame Impala - Official", "Tame Impala"},
+		{"Official", "Official"},
+		{"Official Hige Dandism", "Official Hige Dandism"},
+		{"Topic", "Topic"},
+		{"Justice", "Justice"},
+	}
+
+	for _, tt := range tests {
+		if got := cleanArtist(tt.artist); got != tt.want {
+			t.Errorf("cleanArtist(%q) = %q, want %q", tt.artist, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		media     MediaInfo
+		titleCase bool
+		want      MediaInfo
+	}{
+		{
+			name:  "YouTube Music channel and spacing",
+			media: MediaInfo{Title: "  Nightcall   (Drive) ", Artist: " Kavinsky - Topic ", Album: "missing value"},
+			want:  MediaInfo{Title: "Nightcall (Drive)", Artist: "Kavinsky"},
+		},
+		{
+			name:      "shouting title-cased",
+			media:     MediaInfo{Title: "ONE MORE TIME", Artist: "DAFT PUNK VEVO"},
+			titleCase: true,
+			want:      MediaInfo{Title: "One More Time", Artist: "Daft Punk"},
+		},
+		{
+			name:      "styled names kept",
+			media:     MediaInfo{Title: "THUNDERSTRUCK", Artist: "AC/DC"},
+			titleCase: true,
+			want:      MediaInfo{Title: "Thunderstruck", Artist: "AC/DC"},
+		},
+		{
+			name:      "mixed case left alone",
+			media:     MediaInfo{Title: "Strobe", Artist: "deadmau5"},
+			titleCase: true,
+			want:      MediaInfo{Title: "Strobe", Artist: "deadmau5"},
+		},
+		{
+			name:  "shouting kept without title_case",
+			media: MediaInfo{Title: "ONE MORE TIME", Artist: "DAFT PUNK"},
+			want:  MediaInfo{Title: "ONE MORE TIME", Artist: "DAFT PUNK"},
+		},
+	}
+
+	for _, tt := range tests {
+		media := tt.media
+		Normalize(&media, tt.titleCase)
+		if media.Title != tt.want.Title || media.Artist != tt.want.Artist || media.Album != tt.want.Album {
+			t.Errorf("%s: got %q / %q / %q, want %q / %q / %q", tt.name, media.Title, media.Artist, media.Album, tt.want.Title, tt.want.Artist, tt.want.Album)
+		}
+	}
+}
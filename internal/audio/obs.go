@@ -0,0 +1,240 @@
+package audio
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// obs-websocket v5 opcodes
+const (
+	obsOpHello           = 0
+	obsOpIdentify        = 1
+	obsOpIdentified      = 2
+	obsOpRequest         = 6
+	obsOpRequestResponse = 7
+)
+
+// OBSDetector reads the playing media source from OBS Studio via obs-websocket v5
+type OBSDetector struct {
+	URL      string
+	Password string
+}
+
+func (o *OBSDetector) Name() string {
+	return "OBS Studio (obs-websocket)"
+}
+
+func (o *OBSDetector) IsAvailable() bool {
+	return o.Describe().Available
+}
+
+func (o *OBSDetector) Describe() DetectorInfo {
+	info := DetectorInfo{Name: o.Name(), Requires: []string{"OBS Studio with obs-websocket"}, Available: true}
+	if o.URL == "" {
+		return info.unavailable("obs.url isn't set")
+	}
+
+	// Only available when OBS is running and accepts our credentials
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	conn, err := o.connect(ctx)
+	if err != nil {
+		return info.unavailable("%v", err)
+	}
+	conn.Close()
+	return info
+}
+
+func (o *OBSDetector) Detect(ctx context.Context) (*MediaInfo, error) {
+	conn, err := o.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var inputList struct {
+		Inputs []struct {
+			InputName            string `json:"inputName"`
+			UnversionedInputKind string `json:"unversionedInputKind"`
+		} `json:"inputs"`
+	}
+	if err := o.request(conn, "GetInputList", nil, &inputList); err != nil {
+		return nil, err
+	}
+
+	for _, input := range inputList.Inputs {
+		if input.UnversionedInputKind != "ffmpeg_source" && input.UnversionedInputKind != "vlc_source" {
+			continue
+		}
+
+		var status struct {
+			MediaState    string `json:"mediaState"`
+			MediaDuration int64  `json:"mediaDuration"`
+			MediaCursor   int64  `json:"mediaCursor"`
+		}
+		if err := o.request(conn, "GetMediaInputStatus", map[string]string{"inputName": input.InputName}, &status); err != nil {
+			continue
+		}
+		if status.MediaState != "OBS_MEDIA_STATE_PLAYING" {
+			continue
+		}
+
+		var settings struct {
+			InputSettings struct {
+				LocalFile string `json:"local_file"`
+				Input     string `json:"input"`
+				Playlist  []struct {
+					Value string `json:"value"`
+				} `json:"playlist"`
+			} `json:"inputSettings"`
+		}
+		if err := o.request(conn, "GetInputSettings", map[string]string{"inputName": input.InputName}, &settings); err != nil {
+			continue
+		}
+
+		file := settings.InputSettings.LocalFile
+		if file == "" {
+			file = settings.InputSettings.Input
+		}
+		if file == "" && len(settings.InputSettings.Playlist) > 0 {
+			file = settings.InputSettings.Playlist[0].Value
+		}
+
+		// Fall back to the source's name in OBS when no file is configured
+		title := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if file == "" {
+			title = input.InputName
+		}
+
+		artist := ""
+		if parts := strings.SplitN(title, " - ", 2); len(parts) == 2 {
+			artist = strings.TrimSpace(parts[0])
+			title = strings.TrimSpace(parts[1])
+		}
+
+		return &MediaInfo{
+			Title:    title,
+			Artist:   artist,
+			Source:   "OBS",
+			Type:     "song",
+			Duration: time.Duration(status.MediaDuration) * time.Millisecond,
+			Position: time.Duration(status.MediaCursor) * time.Millisecond,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// connect opens a websocket to OBS and completes the Hello/Identify handshake
+func (o *OBSDetector) connect(ctx context.Context) (*websocket.Conn, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, o.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to OBS: %w", err)
+	}
+
+	// Never let a stuck OBS hold up the commit beyond our budget
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetReadDeadline(deadline)
+		conn.SetWriteDeadline(deadline)
+	}
+
+	var hello struct {
+		Op int `json:"op"`
+		D  struct {
+			RPCVersion     int `json:"rpcVersion"`
+			Authentication *struct {
+				Challenge string `json:"challenge"`
+				Salt      string `json:"salt"`
+			} `json:"authentication"`
+		} `json:"d"`
+	}
+	if err := conn.ReadJSON(&hello); err != nil || hello.Op != obsOpHello {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected greeting from OBS: %v", err)
+	}
+
+	identify := map[string]interface{}{"rpcVersion": 1}
+	if auth := hello.D.Authentication; auth != nil {
+		identify["authentication"] = obsAuthString(o.Password, auth.Salt, auth.Challenge)
+	}
+	if err := conn.WriteJSON(map[string]interface{}{"op": obsOpIdentify, "d": identify}); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to identify with OBS: %w", err)
+	}
+
+	var identified struct {
+		Op int `json:"op"`
+	}
+	if err := conn.ReadJSON(&identified); err != nil || identified.Op != obsOpIdentified {
+		conn.Close()
+		return nil, fmt.Errorf("OBS rejected authentication")
+	}
+
+	return conn, nil
+}
+
+// request sends an obs-websocket request and decodes its responseData into out
+func (o *OBSDetector) request(conn *websocket.Conn, requestType string, data interface{}, out interface{}) error {
+	requestID := requestType + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	if data == nil {
+		data = map[string]string{}
+	}
+
+	msg := map[string]interface{}{
+		"op": obsOpRequest,
+		"d": map[string]interface{}{
+			"requestType": requestType,
+			"requestId":   requestID,
+			"requestData": data,
+		},
+	}
+	if err := conn.WriteJSON(msg); err != nil {
+		return err
+	}
+
+	// Skip any events until our response arrives
+	for {
+		var resp struct {
+			Op int `json:"op"`
+			D  struct {
+				RequestID     string `json:"requestId"`
+				RequestStatus struct {
+					Result  bool   `json:"result"`
+					Comment string `json:"comment"`
+				} `json:"requestStatus"`
+				ResponseData json.RawMessage `json:"responseData"`
+			} `json:"d"`
+		}
+		if err := conn.ReadJSON(&resp); err != nil {
+			return err
+		}
+		if resp.Op != obsOpRequestResponse || resp.D.RequestID != requestID {
+			continue
+		}
+		if !resp.D.RequestStatus.Result {
+			return fmt.Errorf("OBS %s failed: %s", requestType, resp.D.RequestStatus.Comment)
+		}
+		if len(resp.D.ResponseData) == 0 {
+			return nil
+		}
+		return json.Unmarshal(resp.D.ResponseData, out)
+	}
+}
+
+// obsAuthString computes the obs-websocket v5 authentication response
+func obsAuthString(password, salt, challenge string) string {
+	secret := sha256.Sum256([]byte(password + salt))
+	secretB64 := base64.StdEncoding.EncodeToString(secret[:])
+	auth := sha256.Sum256([]byte(secretB64 + challenge))
+	return base64.StdEncoding.EncodeToString(auth[:])
+}
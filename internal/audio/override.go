@@ -0,0 +1,43 @@
+package audio
+
+import (
+	"strings"
+)
+
+// OverrideEnvVar names the environment variable that forces a specific track
+const OverrideEnvVar = "INTERACTIVE_COMMIT_OVERRIDE"
+
+// ParseOverride turns a manual "Title by Artist" string into media info.
+// Strings without a " by " separator are treated entirely as the title.
+func ParseOverride(value string) *MediaInfo {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	title := value
+	artist := ""
+
+	// Split on the last " by " so titles like "Stand by Me" survive
+	if idx := strings.LastIndex(value, " by "); idx > 0 {
+		title = strings.TrimSpace(value[:idx])
+		artist = strings.TrimSpace(value[idx+len(" by "):])
+	}
+
+	// Allow the title to be quoted, e.g. "Song" by Artist
+	if len(title) >= 2 && strings.HasPrefix(title, `"`) && strings.HasSuffix(title, `"`) {
+		title = strings.TrimSpace(title[1 : len(title)-1])
+	}
+
+	if title == "" {
+		title = value
+		artist = ""
+	}
+
+	return &MediaInfo{
+		Title:  title,
+		Artist: artist,
+		Source: "Manual",
+		Type:   "song",
+	}
+}
@@ -0,0 +1,62 @@
+package audio
+
+import (
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// podcastPlayers are MPRIS players that only ever play podcasts, matched
+// against the lowercased playerctl player name (e.g. "gpodder", "org.gnome.Podcasts")
+var podcastPlayers = []string{"gpodder", "kasts", "podcasts", "vocal", "castero"}
+
+// longEpisode is the length above which a streamed audio file is taken for an episode
+const longEpisode = 20 * time.Minute
+
+// enclosureExtensions are the audio files podcast feeds publish episodes as
+var enclosureExtensions = map[string]bool{
+	".mp3": true, ".m4a": true, ".aac": true, ".ogg": true, ".oga": true, ".opus": true,
+}
+
+// mapPodcast makes MPRIS podcast metadata consistent: Type becomes "podcast" and
+// Album holds the show. Most podcast apps put the show in xesam:album, but some
+// only set xesam:artist, so the artist is used as the show when album is empty.
+func mapPodcast(media *MediaInfo, playerName string) {
+	if !isMPRISPodcast(media, playerName) {
+		return
+	}
+
+	media.Type = "podcast"
+	if media.Album == "" {
+		media.Album = media.Artist
+	}
+}
+
+// isMPRISPodcast recognizes an episode by its player, genre or URL, or failing
+// that, by being a long audio file streamed from the web like a feed enclosure
+func isMPRISPodcast(media *MediaInfo, playerName string) bool {
+	player := strings.ToLower(playerName)
+	for _, name := range podcastPlayers {
+		if strings.Contains(player, name) {
+			return true
+		}
+	}
+
+	if strings.Contains(strings.ToLower(media.Genre), "podcast") {
+		return true
+	}
+
+	u, err := url.Parse(media.URL)
+	if err != nil {
+		return false
+	}
+
+	// Spotify and others expose episodes as .../episode/<id>
+	if strings.Contains(u.Path, "/episode/") || strings.HasPrefix(media.URL, "spotify:episode:") {
+		return true
+	}
+
+	remote := u.Scheme == "http" || u.Scheme == "https"
+	return remote && media.Duration >= longEpisode && enclosureExtensions[strings.ToLower(path.Ext(u.Path))]
+}
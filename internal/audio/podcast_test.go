@@ -0,0 +1,83 @@
+package audio
+
+import (
+	"strings"
+	"testing"
+)
+
+// playerctlDump joins values in playerctlFields order, the way Detect's
+// playerctl call prints them
+func playerctlDump(values ...string) string {
+	return strings.Join(values, playerctlSeparator) + "\n"
+}
+
+func TestMPRISPodcastMapping(t *testing.T) {
+	const episodeLength = "2700000000" // 45 minutes
+	tests := []struct {
+		name   string
+		dump   string
+		typ    string
+		album  string
+		artist string
+	}{
+		{
+			"show in album",
+			playerctlDump("Episode 12: Tabs", "Ada", "Parser Talk", "gpodder", "Playing", episodeLength, "0", "file:///home/ada/gPodder/Downloads/ep12.mp3", "", "", ""),
+			"podcast", "Parser Talk", "Ada",
+		},
+		{
+			"show only in artist",
+			playerctlDump("Episode 12: Tabs", "Parser Talk", "", "org.kde.kasts", "Playing", episodeLength, "0", "", "", "", ""),
+			"podcast", "Parser Talk", "Parser Talk",
+		},
+		{
+			"no show at all",
+			playerctlDump("Episode 12: Tabs", "", "", "org.gnome.Podcasts", "Playing", "", "", "", "", "", ""),
+			"podcast", "", "",
+		},
+		{
+			"podcast genre",
+			playerctlDump("Episode 12: Tabs", "Parser Talk", "", "vlc", "Playing", "", "", "", "Podcast", "", ""),
+			"podcast", "Parser Talk", "Parser Talk",
+		},
+		{
+			"Spotify episode",
+			playerctlDump("Episode 12: Tabs", "Parser Talk", "", "spotify", "Playing", episodeLength, "0", "https://open.spotify.com/episode/4rOoJ6Egrf8K2IrywzwOMk", "", "", ""),
+			"podcast", "Parser Talk", "Parser Talk",
+		},
+		{
+			"long feed enclosure",
+			playerctlDump("Episode 12: Tabs", "Parser Talk", "", "vlc", "Playing", episodeLength, "0", "https://feeds.example/parser-talk/ep12.MP3?ref=rss", "", "", ""),
+			"podcast", "Parser Talk", "Parser Talk",
+		},
+		{
+			"short streamed song",
+			playerctlDump("Nightcall", "Kavinsky", "", "vlc", "Playing", "258000000", "0", "https://cdn.example/nightcall.mp3", "", "", ""),
+			"song", "", "Kavinsky",
+		},
+		{
+			"long local file",
+			playerctlDump("Live at Bercy", "Justice", "", "vlc", "Playing", episodeLength, "0", "file:///home/ada/Music/bercy.mp3", "", "", ""),
+			"song", "", "Justice",
+		},
+		{
+			"Spotify track",
+			playerctlDump("Nightcall", "Kavinsky", "OutRun", "spotify", "Playing", "258000000", "0", "https://open.spotify.com/track/0U0ldCRmgCqhVvD6ksG63j", "", "", ""),
+			"song", "OutRun", "Kavinsky",
+		},
+	}
+
+	for _, tt := range tests {
+		media := (&MPRISDetector{}).parseMetadata(tt.dump)
+		if media == nil {
+			t.Errorf("%s: nothing parsed", tt.name)
+			continue
+		}
+		if media.Type != tt.typ || media.Album != tt.album || media.Artist != tt.artist {
+			t.Errorf("%s: Type, Album, Artist = %q, %q, %q; want %q, %q, %q", tt.name, media.Type, media.Album, media.Artist, tt.typ, tt.album, tt.artist)
+		}
+		if media.Title != "Episode 12: Tabs" && media.Type == "podcast" {
+			t.Errorf("%s: episode title = %q", tt.name, media.Title)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package audio
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// mediaExtensions are file types a player argument must have to be taken for the track
+var mediaExtensions = map[string]bool{
+	".mp3": true, ".flac": true, ".ogg": true, ".oga": true, ".opus": true, ".m4a": true,
+	".aac": true, ".wav": true, ".wma": true, ".mka": true, ".mp4": true, ".mkv": true,
+	".webm": true, ".avi": true, ".mov": true,
+}
+
+// filenameTags are the bits of a file name that aren't part of the title:
+// leading track numbers ("01 - ", "07. ") and trailing bracketed IDs or
+// quality tags ("[dQw4w9WgXcQ]", "(320kbps)")
+var filenameTags = []*regexp.Regexp{
+	regexp.MustCompile(`^\d{1,3}\s*[-._]\s*`),
+	regexp.MustCompile(`\s*[\[(][^\])]*[\])]\s*$`),
+}
+
+// ProcessScanDetector is a last resort for terminal players without a
+// dedicated detector: it finds an allowlisted player among the running
+// processes and takes the track from its command line
+type ProcessScanDetector struct {
+	Players []string // Command names to look for, e.g. "mpv"
+}
+
+func (p *ProcessScanDetector) Name() string {
+	return "Process scan (" + strings.Join(p.Players, ", ") + ")"
+}
+
+func (p *ProcessScanDetector) IsAvailable() bool {
+	// Finding no player is reported as nothing playing
+	return true
+}
+
+func (p *ProcessScanDetector) Describe() DetectorInfo {
+	return DetectorInfo{Name: p.Name(), Available: true}
+}
+
+func (p *ProcessScanDetector) Detect(ctx context.Context) (*MediaInfo, error) {
+	processes, err := listProcesses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, args := range processes {
+		player := p.match(args[0])
+		if player == "" {
+			continue
+		}
+
+		// moc plays from a server process; ask it what's on
+		if player == "mocp" {
+			if media := mocNowPlaying(ctx); media != nil {
+				return media, nil
+			}
+			continue
+		}
+
+		if media := mediaFromArgs(player, args[1:]); media != nil {
+			return media, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// match returns the allowlisted player that command runs, or ""
+func (p *ProcessScanDetector) match(command string) string {
+	name := strings.TrimSuffix(filepath.Base(command), ".exe")
+	for _, player := range p.Players {
+		if name == player {
+			return player
+		}
+	}
+	return ""
+}
+
+// listProcesses returns the command line of every running process
+func listProcesses(ctx context.Context) ([][]string, error) {
+	var processes [][]string
+
+	if runtime.GOOS == "linux" {
+		// /proc keeps arguments NUL-separated, so paths with spaces survive
+		entries, err := filepath.Glob("/proc/[0-9]*/cmdline")
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			content, err := os.ReadFile(entry)
+			if err != nil || len(content) == 0 {
+				continue
+			}
+			processes = append(processes, strings.Split(strings.TrimRight(toUTF8(content), "\x00"), "\x00"))
+		}
+		return processes, nil
+	}
+
+	// Elsewhere ps only gives a space-joined command line
+	output, err := commandOutput(ctx, "ps", "-axww", "-o", "command=")
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(toUTF8(output), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			processes = append(processes, fields)
+		}
+	}
+	return processes, nil
+}
+
+// mediaFromArgs picks the track a player was started with: the last argument
+// that is a URL or a media file, so option values like "--volume 50" are ignored
+func mediaFromArgs(player string, args []string) *MediaInfo {
+	for i := len(args) - 1; i >= 0; i-- {
+		arg := args[i]
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		if u, err := url.Parse(arg); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			return &MediaInfo{
+				Title:  arg,
+				Source: player,
+				Type:   "song",
+				URL:    arg,
+			}
+		}
+
+		if mediaExtensions[strings.ToLower(filepath.Ext(arg))] {
+			title, artist := titleFromFilename(arg)
+			return &MediaInfo{
+				Title:  title,
+				Artist: artist,
+				Source: player,
+				Type:   "song",
+				Local:  true,
+			}
+		}
+	}
+	return nil
+}
+
+// titleFromFilename strips the directory, extension and tags from a file name
+// and splits "Artist - Title" when present
+func titleFromFilename(path string) (title, artist string) {
+	title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	for _, tag := range filenameTags {
+		title = tag.ReplaceAllString(title, "")
+	}
+	title = strings.TrimSpace(strings.ReplaceAll(title, "_", " "))
+
+	if parts := strings.SplitN(title, " - ", 2); len(parts) == 2 {
+		return strings.TrimSpace(parts[1]), strings.TrimSpace(parts[0])
+	}
+	return title, ""
+}
+
+// mocNowPlaying queries the moc server through its client
+func mocNowPlaying(ctx context.Context) *MediaInfo {
+	output, err := commandOutput(ctx, "mocp", "-Q", "%state\x1f%song\x1f%artist\x1f%album\x1f%file")
+	if err != nil {
+		return nil
+	}
+
+	fields := strings.Split(strings.TrimRight(toUTF8(output), "\r\n"), "\x1f")
+	if len(fields) < 5 || fields[0] != "PLAY" {
+		return nil
+	}
+
+	media := &MediaInfo{
+		Title:  fields[1],
+		Artist: fields[2],
+		Album:  fields[3],
+		Source: "moc",
+		Type:   "song",
+		Local:  fields[4] != "" && !strings.Contains(fields[4], "://"),
+	}
+	// Untagged files fall back to their file name
+	if media.Title == "" {
+		media.Title, media.Artist = titleFromFilename(fields[4])
+	}
+	return media
+}
@@ -0,0 +1,110 @@
+package audio
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// codecNames spells the codecs players report (ffmpeg's names) the way
+// they're usually written
+var codecNames = map[string]string{
+	"flac":    "FLAC",
+	"alac":    "ALAC",
+	"ape":     "APE",
+	"wavpack": "WavPack",
+	"tta":     "TTA",
+	"mp3":     "MP3",
+	"aac":     "AAC",
+	"opus":    "Opus",
+	"vorbis":  "Vorbis",
+	"wmav2":   "WMA",
+	"ac3":     "AC-3",
+	"eac3":    "E-AC-3",
+}
+
+// losslessCodecs are described by their sample rate; lossy ones by bitrate
+var losslessCodecs = map[string]bool{"flac": true, "alac": true, "ape": true, "wavpack": true, "tta": true, "pcm": true, "dsd": true}
+
+// formatQuality describes a stream for {{.Quality}}: "FLAC 96kHz/24bit" for
+// lossless audio, "MP3 320kbps" for lossy. sampleRate is in Hz, bitDepth in
+// bits per sample and bitrate in bits per second; zero or an empty codec
+// leaves that part out, and nothing known gives "".
+func formatQuality(codec string, sampleRate, bitDepth, bitrate int) string {
+	family := codecFamily(codec)
+
+	var parts []string
+	if name := codecNames[family]; name != "" {
+		parts = append(parts, name)
+	} else if family != "" {
+		parts = append(parts, strings.ToUpper(family))
+	}
+
+	if losslessCodecs[family] || family == "" {
+		if sampleRate > 0 {
+			label := sampleRateLabel(sampleRate)
+			if bitDepth > 0 {
+				label += fmt.Sprintf("/%dbit", bitDepth)
+			}
+			parts = append(parts, label)
+		}
+	} else if bitrate > 0 {
+		parts = append(parts, fmt.Sprintf("%dkbps", (bitrate+500)/1000))
+	}
+	return strings.Join(parts, " ")
+}
+
+// mpdQuality describes a stream from MPD's status. audio is its
+// "samplerate:bits:channels" format, e.g. "96000:24:2" ("f" bits for float,
+// "dsd64:2" for DSD), bitrate is in kbps, and the codec comes from the song's
+// file extension since MPD doesn't report it.
+func mpdQuality(audio, bitrate, file string) string {
+	file, _, _ = strings.Cut(file, "?") // Stream URLs may carry a query
+	codec := strings.TrimPrefix(strings.ToLower(path.Ext(file)), ".")
+	switch codec {
+	case "m4a":
+		codec = "" // ALAC or AAC, there's no telling
+	case "ogg", "oga":
+		codec = "vorbis"
+	case "wv":
+		codec = "wavpack"
+	case "wav", "aiff", "aif":
+		codec = "pcm"
+	case "dsf", "dff":
+		codec = "dsd"
+	default:
+		if codecNames[codec] == "" && !losslessCodecs[codec] {
+			codec = "" // Not an audio extension, e.g. a stream's ".php"
+		}
+	}
+
+	fields := strings.Split(audio, ":")
+	if strings.HasPrefix(fields[0], "dsd") {
+		// DSD is named by its rate multiple rather than a sample rate
+		return strings.ToUpper(fields[0])
+	}
+
+	sampleRate, _ := strconv.Atoi(fields[0])
+	var bitDepth int
+	if len(fields) == 3 {
+		bitDepth, _ = strconv.Atoi(fields[1]) // "f" (float) has no depth to show
+	}
+	kbps, _ := strconv.Atoi(bitrate)
+	return formatQuality(codec, sampleRate, bitDepth, kbps*1000)
+}
+
+// codecFamily folds codec variants into one name: "mp3float" is "mp3",
+// "pcm_s24le" is "pcm" and "dsd_lsbf" is "dsd"
+func codecFamily(codec string) string {
+	codec = strings.ToLower(strings.TrimSpace(codec))
+	if family, _, ok := strings.Cut(codec, "_"); ok && (family == "pcm" || family == "dsd") {
+		return family
+	}
+	return strings.TrimSuffix(codec, "float")
+}
+
+// sampleRateLabel writes a sample rate the way audiophiles do: "44.1kHz", "96kHz"
+func sampleRateLabel(hz int) string {
+	return strconv.FormatFloat(float64(hz)/1000, 'f', -1, 64) + "kHz"
+}
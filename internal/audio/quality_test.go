@@ -0,0 +1,138 @@
+package audio
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatQuality(t *testing.T) {
+	tests := []struct {
+		codec      string
+		sampleRate int
+		bitDepth   int
+		bitrate    int
+		want       string
+	}{
+		{"flac", 96000, 24, 0, "FLAC 96kHz/24bit"},
+		{"flac", 44100, 0, 0, "FLAC 44.1kHz"},
+		{"pcm_s24le", 192000, 24, 0, "PCM 192kHz/24bit"},
+		{"mp3float", 44100, 16, 320000, "MP3 320kbps"},
+		{"opus", 48000, 0, 127600, "Opus 128kbps"},
+		{"aac", 44100, 0, 0, "AAC"},
+		{"", 96000, 24, 0, "96kHz/24bit"},
+		{"xyzcodec", 44100, 16, 256000, "XYZCODEC 256kbps"},
+		{"", 0, 0, 0, ""},
+	}
+
+	for _, tt := range tests {
+		if got := formatQuality(tt.codec, tt.sampleRate, tt.bitDepth, tt.bitrate); got != tt.want {
+			t.Errorf("formatQuality(%q, %d, %d, %d) = %q, want %q", tt.codec, tt.sampleRate, tt.bitDepth, tt.bitrate, got, tt.want)
+		}
+	}
+}
+
+func TestMPDQuality(t *testing.T) {
+	tests := []struct {
+		audio   string
+		bitrate string
+		file    string
+		want    string
+	}{
+		{"96000:24:2", "2304", "Albums/Kind of Blue/01 So What.flac", "FLAC 96kHz/24bit"},
+		{"44100:16:2", "1411", "rip.wav", "PCM 44.1kHz/16bit"},
+		{"44100:f:2", "320", "Singles/track.mp3", "MP3 320kbps"},
+		{"48000:f:2", "160", "https://radio.example/live.ogg?sid=1", "Vorbis 160kbps"},
+		{"dsd64:2", "2822", "Albums/sacd/01.dsf", "DSD64"},
+		{"96000:24:2", "0", "https://radio.example/stream.php", "96kHz/24bit"},
+		{"44100:16:2", "256", "Albums/track.m4a", "44.1kHz/16bit"},
+		{"", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := mpdQuality(tt.audio, tt.bitrate, tt.file); got != tt.want {
+			t.Errorf("mpdQuality(%q, %q, %q) = %q, want %q", tt.audio, tt.bitrate, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestReadMPDReply(t *testing.T) {
+	reply, err := readMPDReply(bufio.NewReader(strings.NewReader("Artist: A\nArtist: B\nTitle: Song: Part 2\nOK\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply["Artist"] != "A" || reply["Title"] != "Song: Part 2" {
+		t.Errorf("reply = %v", reply)
+	}
+
+	if _, err := readMPDReply(bufio.NewReader(strings.NewReader("ACK [4@0] {status} you don't have permission\n"))); err == nil {
+		t.Error("expected an error for ACK")
+	}
+}
+
+// fakeMPD serves one connection with canned replies, keyed by command
+func fakeMPD(t *testing.T, replies map[string]string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("can't listen:", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("OK MPD 0.23.5\n"))
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			reply, ok := replies[scanner.Text()]
+			if !ok {
+				reply = "ACK [5@0] {} unknown command\n"
+			}
+			conn.Write([]byte(reply))
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestMPDDetect(t *testing.T) {
+	host := fakeMPD(t, map[string]string{
+		"password \"secret\"": "OK\n",
+		"status":              "state: play\nelapsed: 61.500\nduration: 245.000\naudio: 96000:24:2\nbitrate: 2304\nOK\n",
+		"currentsong":         "file: Albums/Kavinsky/Nightcall.flac\nTitle: Nightcall\nArtist: Kavinsky\nAlbum: OutRun\nOK\n",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	media, err := (&MPDDetector{Host: "secret@" + host}).Detect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media == nil || media.Title != "Nightcall" || media.Artist != "Kavinsky" || media.Album != "OutRun" {
+		t.Fatalf("Detect() = %+v", media)
+	}
+	if media.Quality != "FLAC 96kHz/24bit" {
+		t.Errorf("Quality = %q", media.Quality)
+	}
+	if media.Position != 61500*time.Millisecond || media.Duration != 245*time.Second {
+		t.Errorf("Position, Duration = %v, %v", media.Position, media.Duration)
+	}
+	if !media.Local {
+		t.Error("a library file should be local")
+	}
+}
+
+func TestMPDDetectPaused(t *testing.T) {
+	host := fakeMPD(t, map[string]string{"status": "state: pause\nOK\n"})
+
+	media, err := (&MPDDetector{Host: host}).Detect(context.Background())
+	if err != nil || media != nil {
+		t.Errorf("Detect() = %+v, %v; want nothing while paused", media, err)
+	}
+}
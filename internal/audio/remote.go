@@ -0,0 +1,123 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RemoteReport is what 'interactive-commit detect --json' prints, and what
+// RemoteDetector reads back from the other machine
+type RemoteReport struct {
+	Media *MediaInfo `json:"media"`           // Nil when nothing is playing
+	Error string     `json:"error,omitempty"` // Why detection failed
+}
+
+// sshConnectFailed is ssh's exit status when it couldn't connect or log in,
+// as opposed to the remote command failing
+const sshConnectFailed = 255
+
+// RemoteDetector asks interactive-commit on another machine what's playing
+// there, over SSH. The connection must not prompt: use a key or an agent.
+type RemoteDetector struct {
+	Target  string // ssh destination, e.g. "me@desktop" or a Host from ~/.ssh/config
+	Command string // interactive-commit on the remote PATH when empty
+}
+
+func (r *RemoteDetector) Name() string {
+	return fmt.Sprintf("Remote (%s)", r.Target)
+}
+
+func (r *RemoteDetector) IsAvailable() bool {
+	return r.Describe().Available
+}
+
+func (r *RemoteDetector) Describe() DetectorInfo {
+	info := DetectorInfo{Name: r.Name(), Requires: []string{"ssh", "interactive-commit on the remote machine"}, Available: true}
+	if r.Target == "" {
+		return info.unavailable("remote.ssh isn't set")
+	}
+	if err := r.checkTarget(); err != nil {
+		return info.unavailable("%v", err)
+	}
+	if reason := missingTool("ssh"); reason != "" {
+		return info.unavailable("%s", reason)
+	}
+	// Whether the machine answers is left to Detect, under the caller's
+	// deadline, so listing detectors never waits on the network
+	return info
+}
+
+func (r *RemoteDetector) Detect(ctx context.Context) (*MediaInfo, error) {
+	if err := r.checkTarget(); err != nil {
+		return nil, err
+	}
+
+	command := r.Command
+	if command == "" {
+		command = "interactive-commit"
+	}
+
+	// --local keeps the other machine from asking a remote of its own
+	var stderr bytes.Buffer
+	cmd := r.ssh(ctx, command, "detect", "--json", "--local")
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == sshConnectFailed {
+		return nil, fmt.Errorf("can't reach %s: %s", r.Target, sshError(stderr.Bytes(), err))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("remote detection on %s failed: %s", r.Target, sshError(stderr.Bytes(), err))
+	}
+
+	var report RemoteReport
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, fmt.Errorf("unexpected output from %s on %s (is it up to date?): %w", command, r.Target, err)
+	}
+	if report.Error != "" {
+		return nil, fmt.Errorf("%s: %s", r.Target, report.Error)
+	}
+	if report.Media == nil {
+		return nil, nil
+	}
+	return report.Media, nil
+}
+
+// checkTarget refuses a destination ssh would read as an option, such as
+// "-oProxyCommand=...", which runs a command on this machine
+func (r *RemoteDetector) checkTarget() error {
+	if strings.HasPrefix(r.Target, "-") {
+		return fmt.Errorf("remote.ssh %q isn't a host", r.Target)
+	}
+	return nil
+}
+
+// ssh builds an ssh command that never prompts and gives up connecting
+// within the time ctx has left
+func (r *RemoteDetector) ssh(ctx context.Context, args ...string) *exec.Cmd {
+	connectTimeout := 2
+	if deadline, ok := ctx.Deadline(); ok {
+		connectTimeout = max(1, int(time.Until(deadline).Seconds()))
+	}
+	// "--" ends the options, so the destination is never taken for one
+	sshArgs := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=" + strconv.Itoa(connectTimeout), "--", r.Target}
+	cmd := exec.CommandContext(ctx, "ssh", append(sshArgs, args...)...)
+	cmd.WaitDelay = commandWaitDelay
+	return cmd
+}
+
+// sshError is the last line ssh printed, or else the exit error
+func sshError(output []byte, err error) string {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+		return last
+	}
+	return err.Error()
+}
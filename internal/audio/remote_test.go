@@ -0,0 +1,50 @@
+package audio
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeSSH puts an ssh on PATH that can't connect anywhere and records each run
+func fakeSSH(t *testing.T) (runs string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ssh is a shell script")
+	}
+	dir := t.TempDir()
+	runs = filepath.Join(dir, "runs")
+	script := "#!/bin/sh\necho run >> " + runs + "\necho 'ssh: connect to host desktop port 22: Connection refused' >&2\nexit 255\n"
+	if err := os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return runs
+}
+
+func TestRemoteDescribeDoesNotConnect(t *testing.T) {
+	runs := fakeSSH(t)
+	remote := &RemoteDetector{Target: "me@desktop"}
+
+	if info := remote.Describe(); !info.Available {
+		t.Errorf("Describe() = %+v, want available with ssh on PATH", info)
+	}
+	if _, err := os.Stat(runs); err == nil {
+		t.Error("Describe ran ssh")
+	}
+
+	_, err := remote.Detect(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "can't reach me@desktop") {
+		t.Errorf("Detect() error = %v, want the connection failure", err)
+	}
+}
+
+func TestRemoteDescribeRefusesOptionTarget(t *testing.T) {
+	fakeSSH(t)
+	if info := (&RemoteDetector{Target: "-oProxyCommand=touch pwned"}).Describe(); info.Available {
+		t.Error("a target starting with - should be unavailable")
+	}
+}
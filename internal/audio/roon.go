@@ -0,0 +1,148 @@
+package audio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RoonDetector reads a Roon zone's now playing through an HTTP bridge
+// extension such as roon-extension-http-api, which pairs with the Roon Core
+// once and serves its zones as JSON. Roon's own extension protocol needs a
+// long-lived, authorized connection, which a commit hook can't hold.
+type RoonDetector struct {
+	URL  string // The bridge, e.g. http://roon-core.local:3001
+	Zone string // Zone name or ID; empty picks the first playing zone
+}
+
+// roonZone is a zone as Roon's transport service describes it
+type roonZone struct {
+	ZoneID      string `json:"zone_id"`
+	DisplayName string `json:"display_name"`
+	State       string `json:"state"` // "playing", "paused", "loading" or "stopped"
+	NowPlaying  *struct {
+		SeekPosition float64 `json:"seek_position"` // Seconds
+		Length       float64 `json:"length"`        // Seconds
+		ThreeLine    struct {
+			Line1 string `json:"line1"` // Title
+			Line2 string `json:"line2"` // Artists, " / "-separated
+			Line3 string `json:"line3"` // Album
+		} `json:"three_line"`
+	} `json:"now_playing"`
+}
+
+func (r *RoonDetector) Name() string {
+	return "Roon"
+}
+
+func (r *RoonDetector) IsAvailable() bool {
+	return r.Describe().Available
+}
+
+func (r *RoonDetector) Describe() DetectorInfo {
+	info := DetectorInfo{Name: r.Name(), Requires: []string{"Roon with an HTTP bridge extension"}, Available: true}
+	if r.URL == "" {
+		return info.unavailable("roon.url isn't set")
+	}
+
+	// Only available when the bridge answers and knows the zone
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	zones, err := r.zones(ctx)
+	if err != nil {
+		return info.unavailable("%v", err)
+	}
+	if r.Zone != "" && r.findZone(zones) == nil {
+		return info.unavailable("Roon has no zone %q", r.Zone)
+	}
+	return info
+}
+
+func (r *RoonDetector) Detect(ctx context.Context) (*MediaInfo, error) {
+	zones, err := r.zones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	zone := r.findZone(zones)
+	if zone == nil {
+		if r.Zone != "" {
+			return nil, fmt.Errorf("Roon has no zone %q", r.Zone)
+		}
+		return nil, nil
+	}
+	if zone.NowPlaying == nil || zone.State == "stopped" || zone.NowPlaying.ThreeLine.Line1 == "" {
+		return nil, nil // Nothing playing
+	}
+
+	playing := zone.NowPlaying
+	return &MediaInfo{
+		Title:    playing.ThreeLine.Line1,
+		Artist:   strings.ReplaceAll(playing.ThreeLine.Line2, " / ", ", "),
+		Album:    playing.ThreeLine.Line3,
+		Source:   "Roon",
+		Type:     "song",
+		Duration: time.Duration(playing.Length * float64(time.Second)),
+		Position: time.Duration(playing.SeekPosition * float64(time.Second)),
+	}, nil
+}
+
+// findZone picks the configured zone, or without one the first that's playing
+func (r *RoonDetector) findZone(zones []roonZone) *roonZone {
+	for i, zone := range zones {
+		if r.Zone == "" && zone.State == "playing" {
+			return &zones[i]
+		}
+		if r.Zone != "" && (strings.EqualFold(zone.DisplayName, r.Zone) || zone.ZoneID == r.Zone) {
+			return &zones[i]
+		}
+	}
+	return nil
+}
+
+// zones fetches every zone from the bridge. Bridges serve them keyed by zone
+// ID or as a list, wrapped in a "zones" object or not.
+func (r *RoonDetector) zones(ctx context.Context) ([]roonZone, error) {
+	endpoint := strings.TrimRight(r.URL, "/") + "/roonAPI/listZones"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Roon bridge not reachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Roon bridge returned %s", resp.Status)
+	}
+
+	var body json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse Roon zones: %w", err)
+	}
+	var wrapped struct {
+		Zones json.RawMessage `json:"zones"`
+	}
+	if json.Unmarshal(body, &wrapped) == nil && len(wrapped.Zones) > 0 {
+		body = wrapped.Zones
+	}
+
+	var list []roonZone
+	if json.Unmarshal(body, &list) == nil {
+		return list, nil
+	}
+	var byID map[string]roonZone
+	if err := json.Unmarshal(body, &byID); err != nil {
+		return nil, fmt.Errorf("failed to parse Roon zones: %w", err)
+	}
+	for _, zone := range byID {
+		list = append(list, zone)
+	}
+	return list, nil
+}
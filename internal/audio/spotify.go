@@ -0,0 +1,135 @@
+package audio
+
+import (
+	"context"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/spotify"
+)
+
+// SpotifyAPIDetector asks the Spotify Web API what the user is playing on any device
+type SpotifyAPIDetector struct {
+	// AudioFeatures also fetches the track's tempo and derives a mood - one more API call
+	AudioFeatures bool
+}
+
+func (s *SpotifyAPIDetector) Name() string {
+	return "Spotify Web API"
+}
+
+func (s *SpotifyAPIDetector) IsAvailable() bool {
+	return s.Describe().Available
+}
+
+func (s *SpotifyAPIDetector) Describe() DetectorInfo {
+	info := DetectorInfo{Name: s.Name(), Requires: []string{"Spotify login"}, Available: true}
+	if _, err := spotify.LoadToken(); err != nil {
+		return info.unavailable("%v", err)
+	}
+	return info
+}
+
+func (s *SpotifyAPIDetector) Detect(ctx context.Context) (*MediaInfo, error) {
+	client, err := spotify.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	playback, err := client.Playback(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if playback == nil || !playback.IsPlaying || playback.Item == nil {
+		return nil, nil
+	}
+
+	item := playback.Item
+	media := &MediaInfo{
+		Title:    item.Name,
+		Source:   "Spotify",
+		Type:     "song",
+		Duration: time.Duration(item.DurationMS) * time.Millisecond,
+		Position: time.Duration(playback.ProgressMS) * time.Millisecond,
+		URL:      item.ExternalURLs.Spotify,
+		TrackURI: item.URI,
+		Explicit: item.Explicit,
+		Local:    item.IsLocal || isLocalURL(item.URI),
+	}
+
+	// Spotify Connect: the track may be playing on a speaker or phone rather than here
+	if device := playback.Device.Name; device != "" && !isThisComputer(device) {
+		media.OutputDevice = device
+	}
+
+	images := item.Album.Images
+	if playback.PlayingType == "episode" {
+		media.Type = "podcast"
+		media.Album = item.Show.Name
+		media.Artist = item.Show.Publisher
+		images = item.Images
+	} else {
+		var artists []string
+		for _, artist := range item.Artists {
+			artists = append(artists, artist.Name)
+		}
+		media.Artist = strings.Join(artists, ", ")
+		media.Album = item.Album.Name
+	}
+	if len(images) > 0 {
+		media.ArtworkURL = images[0].URL
+	}
+
+	// The context's name is optional too; it costs an API call the first time it's seen
+	if playing := playback.Context; playing != nil && playing.URI != "" {
+		if name, err := client.ContextName(ctx, playing); err == nil {
+			media.Context = name
+			media.ContextType = playing.Type
+			if playing.Type == "playlist" {
+				media.Playlist = name
+			}
+		}
+	}
+
+	// Audio features are a nice-to-have: the track is reported either way
+	if s.AudioFeatures && media.Type == "song" && item.ID != "" {
+		if features, err := client.AudioFeatures(ctx, item.ID); err == nil {
+			media.Tempo = int(math.Round(features.Tempo))
+			media.Mood = moodFor(features.Energy, features.Valence)
+		}
+	}
+
+	return media, nil
+}
+
+// isThisComputer reports whether a Spotify device name is this machine's.
+// The desktop app registers under the computer's host name.
+func isThisComputer(device string) bool {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return false
+	}
+	hostname = strings.TrimSuffix(hostname, ".local")
+	return strings.EqualFold(device, hostname)
+}
+
+// moodFor describes a track from Spotify's energy and valence (both 0-1)
+func moodFor(energy, valence float64) string {
+	switch {
+	case energy > 0.7 && valence >= 0.6:
+		return "upbeat"
+	case energy > 0.7:
+		return "high energy"
+	case energy < 0.4 && valence < 0.4:
+		return "melancholic"
+	case energy < 0.4:
+		return "chill"
+	case valence >= 0.6:
+		return "cheerful"
+	case valence < 0.4:
+		return "moody"
+	}
+	return "mellow"
+}
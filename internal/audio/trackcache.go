@@ -0,0 +1,93 @@
+package audio
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// TrackChangeDetector is a detector with a cheap way to tell which track is
+// playing. With a TrackStore its full metadata is only fetched again once the
+// track changes.
+type TrackChangeDetector interface {
+	Detector
+
+	// PlayingTrack identifies the playing track, "" when nothing is, along
+	// with how far into it playback is
+	PlayingTrack(ctx context.Context) (id string, position time.Duration, err error)
+}
+
+// TrackStore remembers the last media each detector reported, raw as the
+// detector gave it, with the track ID it had; detectors without a change
+// signal store an empty ID
+type TrackStore interface {
+	LoadTrack(detector string) (id string, media *MediaInfo, saved time.Time, ok bool)
+	SaveTrack(detector, id string, media *MediaInfo)
+}
+
+// UseTrackStore caches detections in store: a TrackChangeDetector's media is
+// reused for as long as the same track plays, and any other detector's for
+// ttl, since there's no telling when its track changes. Zero ttl caches only
+// detectors with a change signal.
+func (am *AudioManager) UseTrackStore(store TrackStore, ttl time.Duration) {
+	am.tracks = store
+	am.trackTTL = ttl
+}
+
+// cachedTrack returns the stored raw media for detector when it's still
+// playing, with its position brought up to date; Detect finishes it under the
+// current config like a fresh detection. id is what the detector's change
+// signal reported, for saving a fresh detection under; playing is false when
+// the signal says nothing is playing.
+func (am *AudioManager) cachedTrack(ctx context.Context, detector Detector) (media *MediaInfo, id string, playing bool) {
+	storedID, stored, saved, ok := am.tracks.LoadTrack(detector.Name())
+	if ok && stored != nil {
+		stored = stored.Clone()
+	}
+
+	changer, hasSignal := detector.(TrackChangeDetector)
+	if !hasSignal || am.forced {
+		if !ok || stored == nil || storedID != "" || am.trackTTL <= 0 || time.Since(saved) > am.trackTTL {
+			return nil, "", true
+		}
+
+		// Without a signal the track is assumed to have played on since it
+		// was saved; once it would have ended, detect again
+		stored.Position += time.Since(saved)
+		if stored.Duration > 0 && stored.Position > stored.Duration {
+			return nil, "", true
+		}
+		return stored, "", true
+	}
+
+	id, position, err := changer.PlayingTrack(ctx)
+	if err != nil {
+		return nil, "", true // Let the full detection report the problem
+	}
+	if id == "" {
+		return nil, "", false
+	}
+	if !ok || storedID != id || stored == nil {
+		return nil, id, true
+	}
+
+	stored.Position = position
+	return stored, id, true
+}
+
+// PlayingTrack reads just the player, track ID, title and position, which
+// together change whenever the track does, even on radio streams that keep
+// one track ID
+func (m *MPRISDetector) PlayingTrack(ctx context.Context) (string, time.Duration, error) {
+	fields := []string{"{{status}}", "{{playerName}}", "{{mpris:trackid}}", "{{title}}", "{{position}}"}
+	output, err := m.metadata(ctx, strings.Join(fields, playerctlSeparator))
+	if err != nil {
+		return "", 0, err
+	}
+
+	values := strings.Split(strings.TrimRight(output, "\r\n"), playerctlSeparator)
+	if len(values) != len(fields) || values[0] == "Stopped" || strings.TrimSpace(values[3]) == "" {
+		return "", 0, nil
+	}
+	return strings.Join(values[1:4], playerctlSeparator), parseMicroseconds(values[4]), nil
+}
@@ -0,0 +1,153 @@
+package audio
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/config"
+)
+
+// memoryStore is a TrackStore in memory, with a clock the tests can move
+type memoryStore struct {
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	id    string
+	media MediaInfo
+	saved time.Time
+}
+
+func (s *memoryStore) LoadTrack(detector string) (string, *MediaInfo, time.Time, bool) {
+	entry, ok := s.entries[detector]
+	if !ok {
+		return "", nil, time.Time{}, false
+	}
+	media := entry.media
+	return entry.id, &media, entry.saved, true
+}
+
+func (s *memoryStore) SaveTrack(detector, id string, media *MediaInfo) {
+	if s.entries == nil {
+		s.entries = map[string]memoryEntry{}
+	}
+	s.entries[detector] = memoryEntry{id: id, media: *media, saved: time.Now()}
+}
+
+// age makes every entry look saved d ago
+func (s *memoryStore) age(d time.Duration) {
+	for name, entry := range s.entries {
+		entry.saved = entry.saved.Add(-d)
+		s.entries[name] = entry
+	}
+}
+
+// changingDetector is a fakeDetector with a change signal
+type changingDetector struct {
+	fakeDetector
+	id       string
+	position time.Duration
+}
+
+func (c *changingDetector) PlayingTrack(ctx context.Context) (string, time.Duration, error) {
+	return c.id, c.position, nil
+}
+
+func TestTrackChangeInvalidatesCache(t *testing.T) {
+	detector := &changingDetector{fakeDetector: fakeDetector{name: "signal", media: song()}, id: "one"}
+	am := newTestManager(config.Default(), detector)
+	am.UseTrackStore(&memoryStore{}, 0)
+
+	if _, err := am.detect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	detector.position = 90 * time.Second
+	media, err := am.detect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls := detector.calls.Load(); calls != 1 {
+		t.Errorf("Detect ran %d times for the same track, want 1", calls)
+	}
+	if media.Position != 90*time.Second {
+		t.Errorf("Position = %v, want the signal's 1m30s", media.Position)
+	}
+
+	detector.id = "two"
+	detector.media = &MediaInfo{Title: "Genesis", Artist: "Justice", Source: "Test", Type: "song"}
+	media, err = am.detect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media.Title != "Genesis" || detector.calls.Load() != 2 {
+		t.Errorf("got %q after %d calls, want the new track detected again", media.Title, detector.calls.Load())
+	}
+}
+
+func TestTTLCacheAdvancesPosition(t *testing.T) {
+	playing := song()
+	playing.Position = 30 * time.Second
+	playing.Duration = 4 * time.Minute
+	detector := &fakeDetector{name: "plain", media: playing}
+	store := &memoryStore{}
+	am := newTestManager(config.Default(), detector)
+	am.UseTrackStore(store, 5*time.Minute)
+
+	if _, err := am.detect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	store.age(time.Minute)
+	media, err := am.detect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detector.calls.Load() != 1 {
+		t.Errorf("Detect ran %d times within the TTL, want 1", detector.calls.Load())
+	}
+	if media.Position < 90*time.Second || media.Position > 91*time.Second {
+		t.Errorf("Position = %v, want about 1m30s", media.Position)
+	}
+
+	// Past where the track would have ended, it's detected again
+	store.age(4 * time.Minute)
+	if _, err := am.detect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if detector.calls.Load() != 2 {
+		t.Errorf("Detect ran %d times after the track would have ended, want 2", detector.calls.Load())
+	}
+}
+
+func TestCachedTrackFinishedUnderCurrentConfig(t *testing.T) {
+	explicit := song()
+	explicit.Explicit = true
+	explicit.URL = "https://open.spotify.com/track/abc"
+	detector := &changingDetector{fakeDetector: fakeDetector{name: "signal", media: explicit}, id: "one"}
+	store := &memoryStore{}
+
+	first := newTestManager(config.Default(), detector)
+	first.UseTrackStore(store, 0)
+	media, err := first.Detect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media.Title != "Nightcall" {
+		t.Fatalf("Title = %q without redact_explicit", media.Title)
+	}
+
+	cfg := config.Default()
+	cfg.RedactExplicit = true
+	second := newTestManager(cfg, detector)
+	second.UseTrackStore(store, 0)
+	media, err = second.Detect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detector.calls.Load() != 1 {
+		t.Errorf("Detect ran %d times for the same track, want the cached one", detector.calls.Load())
+	}
+	if media.Title != ExplicitTitle || media.URL != "" {
+		t.Errorf("cached track = %q, %q; want it redacted under the second config", media.Title, media.URL)
+	}
+}
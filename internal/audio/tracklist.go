@@ -0,0 +1,168 @@
+package audio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TracklistEntry is one track of a DJ mix, starting at Offset into the video
+type TracklistEntry struct {
+	Offset time.Duration
+	Title  string
+	Artist string
+}
+
+// timestampLine matches description lines like "12:34 Artist - Title", "[1:02:03] Title" or "05:00 | Title"
+var timestampLine = regexp.MustCompile(`^\s*[\[(]?((?:\d{1,2}:)?\d{1,2}:\d{2})[\])]?\s*(?:[-–|.:]\s*)?(.+?)\s*$`)
+
+// ParseTracklist extracts timestamped tracks from a video description.
+// Lines without a leading timestamp are ignored; entries come back sorted by offset.
+func ParseTracklist(description string) []TracklistEntry {
+	var entries []TracklistEntry
+
+	for _, line := range strings.Split(description, "\n") {
+		match := timestampLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		offset, ok := parseTimestamp(match[1])
+		if !ok {
+			continue
+		}
+
+		entry := TracklistEntry{Offset: offset, Title: match[2]}
+		if parts := strings.SplitN(match[2], " - ", 2); len(parts) == 2 {
+			entry.Artist = strings.TrimSpace(parts[0])
+			entry.Title = strings.TrimSpace(parts[1])
+		}
+
+		// Descriptions occasionally repeat or go backwards - keep it monotonic
+		if len(entries) > 0 && offset <= entries[len(entries)-1].Offset {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// TrackAt returns the tracklist entry playing at the given position
+func TrackAt(entries []TracklistEntry, position time.Duration) *TracklistEntry {
+	var current *TracklistEntry
+	for i := range entries {
+		if entries[i].Offset > position {
+			break
+		}
+		current = &entries[i]
+	}
+	return current
+}
+
+func parseTimestamp(stamp string) (time.Duration, bool) {
+	var total time.Duration
+	for _, part := range strings.Split(stamp, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, false
+		}
+		total = total*60 + time.Duration(n)
+	}
+	return total * time.Second, true
+}
+
+// youtubeVideoID extracts the video ID from a youtube.com/watch or youtu.be URL
+func youtubeVideoID(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	switch host {
+	case "youtube.com", "m.youtube.com", "music.youtube.com":
+		return u.Query().Get("v")
+	case "youtu.be":
+		return strings.TrimPrefix(u.Path, "/")
+	}
+	return ""
+}
+
+// fetchYouTubeDescription looks up a video's description via the YouTube Data API
+func fetchYouTubeDescription(ctx context.Context, apiKey, videoID string) (string, error) {
+	endpoint := "https://www.googleapis.com/youtube/v3/videos?part=snippet&id=" +
+		url.QueryEscape(videoID) + "&key=" + url.QueryEscape(apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to query YouTube Data API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("YouTube Data API returned %s", resp.Status)
+	}
+
+	var result struct {
+		Items []struct {
+			Snippet struct {
+				Description string `json:"description"`
+			} `json:"snippet"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to parse YouTube Data API response: %w", err)
+	}
+
+	if len(result.Items) == 0 {
+		return "", fmt.Errorf("video %s not found", videoID)
+	}
+	return result.Items[0].Snippet.Description, nil
+}
+
+// resolveMixTrack swaps a YouTube mix's video title for the track currently
+// playing within it. The media is left untouched if anything is missing.
+func resolveMixTrack(ctx context.Context, apiKey string, media *MediaInfo) {
+	if apiKey == "" || media.Position <= 0 {
+		return
+	}
+
+	videoID := youtubeVideoID(media.URL)
+	if videoID == "" {
+		return
+	}
+
+	description, err := fetchYouTubeDescription(ctx, apiKey, videoID)
+	if err != nil {
+		return
+	}
+
+	entries := ParseTracklist(description)
+	if len(entries) < 2 {
+		return // Not a mix, or no tracklist
+	}
+
+	track := TrackAt(entries, media.Position)
+	if track == nil {
+		return
+	}
+
+	// Keep the mix itself around as the "album"
+	media.Album = media.Title
+	media.Title = track.Title
+	if track.Artist != "" {
+		media.Artist = track.Artist
+	}
+}
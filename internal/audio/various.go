@@ -0,0 +1,48 @@
+package audio
+
+import "strings"
+
+// DefaultVariousArtists are the names compilation albums are credited to,
+// compared without case
+var DefaultVariousArtists = []string{"Various Artists", "Various Artist", "Various", "VA", "V.A.", "V/A"}
+
+// CollapseVariousArtists drops a compilation credit like "Various Artists"
+// from the artist. MPRIS players and Spotify report each track's own artist
+// even on compilations, so when the credit comes alongside real names, e.g.
+// "Various Artists, Daft Punk", those are kept; when it's the only name the
+// artist is left empty rather than read "by Various Artists". names replaces
+// DefaultVariousArtists when set.
+func CollapseVariousArtists(media *MediaInfo, names []string) {
+	if media == nil || media.Artist == "" || media.Type == "video" || media.Type == "podcast" {
+		return
+	}
+	if len(names) == 0 {
+		names = DefaultVariousArtists
+	}
+
+	isVarious := func(artist string) bool {
+		for _, name := range names {
+			if strings.EqualFold(artist, strings.TrimSpace(name)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if isVarious(media.Artist) {
+		media.Artist = ""
+		return
+	}
+
+	// The credit is one name in a list, however the list is joined
+	artists := SplitArtists(media.Artist, true)
+	kept := artists[:0]
+	for _, artist := range artists {
+		if !isVarious(artist) {
+			kept = append(kept, artist)
+		}
+	}
+	if len(kept) < len(artists) {
+		media.Artist = strings.Join(kept, ", ")
+	}
+}
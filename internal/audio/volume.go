@@ -0,0 +1,85 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// VolumeState is the system's output volume
+type VolumeState struct {
+	Volume int // Percent
+	Muted  bool
+}
+
+// volumePercent finds the first channel's percentage in pactl output
+var volumePercent = regexp.MustCompile(`(\d+)%`)
+
+// SystemVolume reads the output volume via PulseAudio/PipeWire (pactl) on Linux
+// or AppleScript on macOS. It errors when the platform or tool isn't supported.
+func SystemVolume(ctx context.Context) (*VolumeState, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return pactlVolume(ctx)
+	case "darwin":
+		return macOSVolume(ctx)
+	}
+	return nil, fmt.Errorf("reading the volume is not supported on %s", runtime.GOOS)
+}
+
+func pactlVolume(ctx context.Context) (*VolumeState, error) {
+	muteOutput, err := commandOutput(ctx, "pactl", "get-sink-mute", "@DEFAULT_SINK@")
+	if err != nil {
+		return nil, fmt.Errorf("pactl not available: %w", err)
+	}
+	volumeOutput, err := commandOutput(ctx, "pactl", "get-sink-volume", "@DEFAULT_SINK@")
+	if err != nil {
+		return nil, fmt.Errorf("pactl not available: %w", err)
+	}
+
+	// "Mute: yes" and "Volume: front-left: 32768 /  50% / -18.06 dB, ..."
+	match := volumePercent.FindStringSubmatch(string(volumeOutput))
+	if match == nil {
+		return nil, fmt.Errorf("unexpected pactl output: %s", strings.TrimSpace(string(volumeOutput)))
+	}
+	volume, _ := strconv.Atoi(match[1])
+
+	return &VolumeState{
+		Volume: volume,
+		Muted:  strings.Contains(string(muteOutput), "yes"),
+	}, nil
+}
+
+func macOSVolume(ctx context.Context) (*VolumeState, error) {
+	// "output volume:50, input volume:75, alert volume:100, output muted:false"
+	output, err := commandOutput(ctx, "osascript", "-e", "get volume settings")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read volume settings: %w", err)
+	}
+
+	state := &VolumeState{}
+	found := false
+	for _, setting := range strings.Split(strings.TrimSpace(string(output)), ", ") {
+		key, value, ok := strings.Cut(setting, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "output volume":
+			// "missing value" when the output device has no volume control
+			if volume, err := strconv.Atoi(value); err == nil {
+				state.Volume = volume
+				found = true
+			}
+		case "output muted":
+			state.Muted = value == "true"
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("output volume unavailable")
+	}
+	return state, nil
+}
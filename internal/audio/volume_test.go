@@ -0,0 +1,98 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"github.com/pixare40/interactive-commit/internal/config"
+)
+
+// pactlRunner answers pactl the way PulseAudio and PipeWire do
+type pactlRunner struct {
+	mute, volume string
+}
+
+func (p pactlRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if name != "pactl" || len(args) == 0 {
+		return nil, exec.ErrNotFound
+	}
+	switch args[0] {
+	case "get-sink-mute":
+		return []byte(p.mute), nil
+	case "get-sink-volume":
+		return []byte(p.volume), nil
+	}
+	return nil, errors.New("unexpected pactl command")
+}
+
+func (p pactlRunner) LookPath(name string) (string, error) {
+	return "/usr/bin/" + name, nil
+}
+
+func useCommands(t *testing.T, runner CommandRunner) {
+	t.Helper()
+	saved := Commands
+	Commands = runner
+	t.Cleanup(func() { Commands = saved })
+}
+
+func TestSkipIfMutedReadsVolume(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("pactl is read on Linux")
+	}
+
+	tests := []struct {
+		name   string
+		runner pactlRunner
+		muted  bool
+		volume int
+	}{
+		{"muted", pactlRunner{"Mute: yes\n", "Volume: front-left: 32768 /  50% / -18.06 dB,   front-right: 32768 /  50% / -18.06 dB\n"}, true, 50},
+		{"volume at zero", pactlRunner{"Mute: no\n", "Volume: front-left: 0 /   0% / -inf dB,   front-right: 0 /   0% / -inf dB\n"}, true, 0},
+		{"audible", pactlRunner{"Mute: no\n", "Volume: front-left: 65536 / 100% / 0.00 dB,   front-right: 65536 / 100% / 0.00 dB\n"}, false, 100},
+	}
+
+	for _, tt := range tests {
+		useCommands(t, tt.runner)
+		cfg := config.Default()
+		cfg.SkipIfMuted = true
+		am := newTestManager(cfg, &fakeDetector{name: "player", media: song()})
+
+		media, err := am.Detect(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if media.Muted != tt.muted || media.Volume != tt.volume {
+			t.Errorf("%s: Muted, Volume = %v, %d; want %v, %d", tt.name, media.Muted, media.Volume, tt.muted, tt.volume)
+		}
+	}
+}
+
+func TestMutedUnknownWithoutPactl(t *testing.T) {
+	useCommands(t, failingRunner{})
+	cfg := config.Default()
+	cfg.SkipIfMuted = true
+	am := newTestManager(cfg, &fakeDetector{name: "player", media: song()})
+
+	media, err := am.Detect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media.Muted {
+		t.Error("a track was taken for muted without a way to read the volume")
+	}
+}
+
+// failingRunner has none of the tools
+type failingRunner struct{}
+
+func (failingRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return nil, exec.ErrNotFound
+}
+
+func (failingRunner) LookPath(name string) (string, error) {
+	return "", exec.ErrNotFound
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+)
+
+// ErrLocked is returned when another process held the lock for the whole wait
+var ErrLocked = errors.New("another detection is already running")
+
+// staleLock is how old a lockfile must be before it's assumed abandoned by a
+// crashed process. Detection never runs this long.
+const staleLock = 15 * time.Second
+
+// pollInterval is how often a waiting process retries the lock
+const pollInterval = 50 * time.Millisecond
+
+// Dir returns the cache directory, creating it if needed
+func Dir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(cacheDir, "interactive-commit")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// Lock takes the machine-wide detection lock, waiting until ctx is done.
+// The returned function releases it.
+func Lock(ctx context.Context) (func(), error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "detect.lock")
+
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintln(file, os.Getpid())
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock: %w", err)
+		}
+
+		// Clear a lock left behind by a process that died mid-detection
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(path)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ErrLocked
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// result is the on-disk form of the last detection
+type result struct {
+	Time  time.Time        `json:"time"`
+	Media *audio.MediaInfo `json:"media"`
+}
+
+func resultPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "last-detection.json"), nil
+}
+
+// SaveResult records what a detection found, nil meaning nothing was playing
+func SaveResult(media *audio.MediaInfo) error {
+	path, err := resultPath()
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(result{Time: time.Now(), Media: media})
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so a concurrent reader never sees half a file
+	tmp := path + "." + strconv.Itoa(os.Getpid())
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadResult returns the last detection if it was saved at or after since,
+// and when it was saved. ok is false when there's no such result; media may
+// be nil when the detection found nothing playing.
+func LoadResult(since time.Time) (media *audio.MediaInfo, saved time.Time, ok bool) {
+	path, err := resultPath()
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+
+	var last result
+	if err := json.Unmarshal(content, &last); err != nil || last.Time.Before(since) {
+		return nil, time.Time{}, false
+	}
+	return last.Media, last.Time, true
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// maxColors is how many cover colors are kept; the least recently found go first
+const maxColors = 500
+
+// Colors is the audio.ColorStore kept in the cache directory. A cover's color
+// never changes, so entries don't expire.
+type Colors struct{}
+
+// colorEntry is the color found for one cover
+type colorEntry struct {
+	Color string    `json:"color"`
+	Saved time.Time `json:"saved"`
+}
+
+func colorsPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cover-colors.json"), nil
+}
+
+// loadColors reads the stored colors by artwork URL; a missing or unreadable
+// file is empty
+func loadColors() map[string]colorEntry {
+	entries := map[string]colorEntry{}
+	path, err := colorsPath()
+	if err != nil {
+		return entries
+	}
+	if content, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(content, &entries)
+	}
+	return entries
+}
+
+// LoadColor returns the color stored for the cover at artworkURL
+func (Colors) LoadColor(artworkURL string) (string, bool) {
+	entry, ok := loadColors()[artworkURL]
+	return entry.Color, ok
+}
+
+// SaveColor stores the color of the cover at artworkURL, best effort
+func (Colors) SaveColor(artworkURL, color string) {
+	path, err := colorsPath()
+	if err != nil {
+		return
+	}
+
+	entries := loadColors()
+	entries[artworkURL] = colorEntry{Color: color, Saved: time.Now()}
+	if len(entries) > maxColors {
+		urls := make([]string, 0, len(entries))
+		for url := range entries {
+			urls = append(urls, url)
+		}
+		sort.Slice(urls, func(i, j int) bool { return entries[urls[i]].Saved.Before(entries[urls[j]].Saved) })
+		for _, url := range urls[:len(urls)-maxColors] {
+			delete(entries, url)
+		}
+	}
+
+	content, err := json.Marshal(entries)
+	if err != nil {
+		return
+	}
+
+	// Write then rename so a concurrent reader never sees half a file
+	tmp := path + "." + strconv.Itoa(os.Getpid())
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return
+	}
+	os.Rename(tmp, path)
+}
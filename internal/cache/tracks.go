@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+)
+
+// Tracks is the audio.TrackStore kept in the cache directory, so one commit
+// reuses what the last one found while the same track plays
+type Tracks struct{}
+
+// trackEntry is the last media a detector reported
+type trackEntry struct {
+	ID    string           `json:"id,omitempty"`
+	Saved time.Time        `json:"saved"`
+	Media *audio.MediaInfo `json:"media"`
+}
+
+func tracksPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tracks.json"), nil
+}
+
+// loadTracks reads the stored entries by detector; a missing or unreadable
+// file is empty
+func loadTracks() map[string]trackEntry {
+	entries := map[string]trackEntry{}
+	path, err := tracksPath()
+	if err != nil {
+		return entries
+	}
+	if content, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(content, &entries)
+	}
+	return entries
+}
+
+// LoadTrack returns the last media stored for detector
+func (Tracks) LoadTrack(detector string) (string, *audio.MediaInfo, time.Time, bool) {
+	entry, ok := loadTracks()[detector]
+	return entry.ID, entry.Media, entry.Saved, ok
+}
+
+// SaveTrack stores media for detector. Like the rest of the cache it's best
+// effort: a failed write only means the next commit detects in full.
+func (Tracks) SaveTrack(detector, id string, media *audio.MediaInfo) {
+	path, err := tracksPath()
+	if err != nil {
+		return
+	}
+
+	entries := loadTracks()
+	entries[detector] = trackEntry{ID: id, Saved: time.Now(), Media: media}
+	content, err := json.Marshal(entries)
+	if err != nil {
+		return
+	}
+
+	// Write then rename so a concurrent reader never sees half a file
+	tmp := path + "." + strconv.Itoa(os.Getpid())
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return
+	}
+	os.Rename(tmp, path)
+}
@@ -0,0 +1,368 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+	"github.com/pixare40/interactive-commit/internal/config"
+	"github.com/pixare40/interactive-commit/internal/format"
+	"github.com/spf13/cobra"
+)
+
+var backfillCmd = &cobra.Command{
+	Use:   "backfill <plays.csv|plays.json> [<revision-range>]",
+	Short: "Attach tracks from a listening history export to past commits (experimental)",
+	Long: `Match a listening history export against the commits made while it played,
+and attach each track to its commit as a git note under ` + notesRef + `.
+Commit messages and hashes are never changed.
+
+The file is a CSV with a header row or a JSON array, with a time, title and
+artist for each play (album is optional). Spotify's "Download your data"
+exports work as they are. Times without a time zone are read as UTC, which is
+how Spotify exports them.
+
+Each commit gets the track playing when it was authored, or else the last one
+started within --window before it. Commits that already have a note or a
+track in their message are left alone.
+
+Examples:
+  interactive-commit backfill plays.csv --dry-run
+  interactive-commit backfill StreamingHistory_music_0.json main~200..main`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runBackfill,
+}
+
+var (
+	backfillWindow time.Duration
+	backfillDryRun bool
+)
+
+func init() {
+	backfillCmd.Flags().DurationVar(&backfillWindow, "window", 10*time.Minute, "How long before a commit a track may have started")
+	backfillCmd.Flags().BoolVar(&backfillDryRun, "dry-run", false, "Show the matches without attaching notes")
+}
+
+// play is one track from a listening history export
+type play struct {
+	Start  time.Time
+	End    time.Time // Zero when the export only says when the track started
+	Title  string
+	Artist string
+	Album  string
+	Source string
+}
+
+// pastCommit is a commit considered for backfilling
+type pastCommit struct {
+	Hash     string
+	Authored time.Time
+	Tagged   bool // Already has a track in its message
+}
+
+func runBackfill(cmd *cobra.Command, args []string) error {
+	plays, err := loadPlays(args[0])
+	if err != nil {
+		return err
+	}
+	if len(plays) == 0 {
+		return fmt.Errorf("no plays with a time and title found in %s", args[0])
+	}
+	
+	revisions := "HEAD"
+	if len(args) > 1 {
+		revisions = args[1]
+	}
+	commits, err := pastCommits(revisions)
+	if err != nil {
+		return err
+	}
+	noted := notedCommits()
+	
+	cfg, _ := config.Load()
+	if resolved, err := cfg.Resolved(); err == nil {
+		cfg = resolved
+	}
+	// A past commit's session, track count and staged files aren't known
+	cfg.History = false
+	cfg.IncludeLanguage = false
+	defer func(now func() time.Time) { format.Now = now }(format.Now)
+	
+	var matched, unmatched, skipped, failed int
+	for _, commit := range commits {
+		if commit.Tagged || noted[commit.Hash] {
+			skipped++
+			continue
+		}
+	
+		p, ok := playAt(plays, commit.Authored, backfillWindow)
+		if !ok {
+			unmatched++
+			continue
+		}
+	
+		media := &audio.MediaInfo{Title: p.Title, Artist: p.Artist, Album: p.Album, Source: p.Source, Type: "song"}
+		format.Now = func() time.Time { return commit.Authored.Local() }
+		line := format.FormatCommitMessage(media, cfg)
+	
+		if backfillDryRun {
+			fmt.Printf("%s  %s\n", commit.Hash[:12], line)
+			matched++
+			continue
+		}
+	
+		if output, err := exec.Command("git", "notes", "--ref", notesRef, "add", "-m", line, commit.Hash).CombinedOutput(); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  failed to attach a note to %s: %s\n", commit.Hash[:12], strings.TrimSpace(string(output)))
+			failed++
+			continue
+		}
+		matched++
+	}
+	
+	verb := "Attached tracks to"
+	if backfillDryRun {
+		verb = "Would attach tracks to"
+	}
+	fmt.Printf("\n🎵 %s %d commits; %d had no track playing, %d already had one\n", verb, matched, unmatched, skipped)
+	if failed > 0 {
+		fmt.Printf("   %d could not be noted\n", failed)
+	}
+	if matched > 0 && !backfillDryRun {
+		fmt.Println("   See them with 'interactive-commit notes show <commit>'; share them with:")
+		fmt.Printf("   git push origin %s\n", notesRef)
+	}
+	return nil
+}
+
+// playAt finds the track playing at t: one whose play spans t, or else the
+// last one started within window before it. plays must be sorted by Start.
+func playAt(plays []play, t time.Time, window time.Duration) (play, bool) {
+	// The first play starting after t
+	i := sort.Search(len(plays), func(i int) bool { return plays[i].Start.After(t) })
+	if i == 0 {
+		return play{}, false
+	}
+	
+	last := plays[i-1]
+	if !last.End.IsZero() && !t.After(last.End) {
+		return last, true
+	}
+	if t.Sub(last.Start) <= window {
+		return last, true
+	}
+	return play{}, false
+}
+
+// pastCommits lists the commits in revisions with their author time
+func pastCommits(revisions string) ([]pastCommit, error) {
+	output, err := exec.Command("git", "log", "--format=%H%x00%at%x00%B%x1e", revisions, "--").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits in %s: %w", revisions, err)
+	}
+	
+	var commits []pastCommit
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		seconds, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+	
+		commit := pastCommit{Hash: fields[0], Authored: time.Unix(seconds, 0)}
+		for _, line := range strings.Split(fields[2], "\n") {
+			if format.IsCommitLine(line) {
+				commit.Tagged = true
+				break
+			}
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// notedCommits returns the commits that already have a track note
+func notedCommits() map[string]bool {
+	noted := map[string]bool{}
+	output, err := exec.Command("git", "notes", "--ref", notesRef, "list").Output()
+	if err != nil {
+		return noted
+	}
+	
+	// Each line is "<note object> <commit>"
+	for _, line := range strings.Split(string(output), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			noted[fields[1]] = true
+		}
+	}
+	return noted
+}
+
+// Field names each export uses, compared without case or underscores
+var (
+	startFields  = []string{"time", "timestamp", "playedat", "date", "start", "starttime", "uts"}
+	endFields    = []string{"endtime", "ts"}
+	titleFields  = []string{"title", "track", "trackname", "name", "mastermetadatatrackname"}
+	artistFields = []string{"artist", "artistname", "mastermetadataalbumartistname"}
+	albumFields  = []string{"album", "albumname", "mastermetadataalbumalbumname"}
+	playedFields = []string{"msplayed"}
+)
+
+// loadPlays reads a CSV or JSON listening history, sorted by start time.
+// Rows without a time or title, like podcast episodes in a Spotify export,
+// are dropped.
+func loadPlays(path string) ([]play, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	
+	var rows []map[string]string
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		rows, err = jsonRows(content)
+	} else {
+		rows, err = csvRows(content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	
+	var plays []play
+	for _, row := range rows {
+		if p, ok := rowPlay(row); ok {
+			plays = append(plays, p)
+		}
+	}
+	sort.Slice(plays, func(i, j int) bool { return plays[i].Start.Before(plays[j].Start) })
+	return plays, nil
+}
+
+// rowPlay turns an export row into a play
+func rowPlay(row map[string]string) (play, bool) {
+	p := play{
+		Title:  pick(row, titleFields),
+		Artist: pick(row, artistFields),
+		Album:  pick(row, albumFields),
+		Source: "Imported",
+	}
+	if _, ok := row["mastermetadatatrackname"]; ok {
+		p.Source = "Spotify"
+	} else if _, ok := row["msplayed"]; ok {
+		p.Source = "Spotify"
+	}
+	if p.Title == "" {
+		return play{}, false
+	}
+	
+	if start, ok := parsePlayTime(pick(row, startFields)); ok {
+		p.Start = start
+		return p, true
+	}
+	
+	// Spotify records when a play ended, and for how long it played
+	end, ok := parsePlayTime(pick(row, endFields))
+	if !ok {
+		return play{}, false
+	}
+	p.Start, p.End = end, end
+	if ms, err := strconv.ParseInt(pick(row, playedFields), 10, 64); err == nil {
+		p.Start = end.Add(-time.Duration(ms) * time.Millisecond)
+	}
+	return p, true
+}
+
+// pick returns the first non-empty field of a row among names
+func pick(row map[string]string, names []string) string {
+	for _, name := range names {
+		if value := strings.TrimSpace(row[name]); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// playTimeLayouts are the time formats seen in listening history exports
+var playTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"02 Jan 2006 15:04", // e.g. Last.fm exports
+}
+
+// parsePlayTime reads a time in any known layout, or as Unix seconds
+func parsePlayTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), true
+	}
+	for _, layout := range playTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// fieldKey normalizes a column or key name, so "trackName" matches "track_name"
+func fieldKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "_", ""))
+}
+
+// csvRows reads a CSV with a header row
+func csvRows(content []byte) ([]map[string]string, error) {
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(content), "\ufeff"))).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, nil
+	}
+	
+	var rows []map[string]string
+	for _, record := range records[1:] {
+		row := map[string]string{}
+		for i, name := range records[0] {
+			if i < len(record) {
+				row[fieldKey(name)] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// jsonRows reads a JSON array of objects
+func jsonRows(content []byte) ([]map[string]string, error) {
+	var objects []map[string]interface{}
+	if err := json.Unmarshal(content, &objects); err != nil {
+		return nil, err
+	}
+	
+	var rows []map[string]string
+	for _, object := range objects {
+		row := map[string]string{}
+		for name, value := range object {
+			switch value := value.(type) {
+			case string:
+				row[fieldKey(name)] = value
+			case float64:
+				row[fieldKey(name)] = strconv.FormatFloat(value, 'f', -1, 64)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/pixare40/interactive-commit/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Change interactive-commit settings",
+}
+
+var configUsePresetCmd = &cobra.Command{
+	Use:   "use-preset <name>",
+	Short: "Make a named preset the active one for this repository",
+	Long: `Set the preset used for commits in this repository. Presets are defined
+under "presets" in the global or repository config, e.g.
+  { "presets": { "minimal": { "template": "♪ {{.Title}}", "append_position": "bottom" } } }
+The choice is saved as "preset" in the repository's ` + config.FileName + `.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigUsePreset,
+}
+
+func init() {
+	configCmd.AddCommand(configUsePresetCmd)
+}
+
+func runConfigUsePreset(cmd *cobra.Command, args []string) error {
+	repoPath, err := config.RepoPath()
+	if err != nil {
+		return err
+	}
+	
+	if err := usePreset(repoPath, args[0]); err != nil {
+		return err
+	}
+	
+	fmt.Printf("✅ Using preset %q in %s\n", args[0], repoPath)
+	return nil
+}
+
+// usePreset checks that name is a defined preset and saves it as the active
+// one in the config file at path
+func usePreset(path, name string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	
+	if err := cfg.CheckPreset(name); err != nil {
+		return err
+	}
+	
+	if err := config.SetValue(path, "preset", name); err != nil {
+		return fmt.Errorf("failed to save preset: %w", err)
+	}
+	return nil
+}
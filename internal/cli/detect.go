@@ -2,10 +2,16 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/pixare40/interactive-commit/internal/audio"
+	"github.com/pixare40/interactive-commit/internal/config"
 	"github.com/pixare40/interactive-commit/internal/format"
 	"github.com/spf13/cobra"
 )
@@ -20,19 +26,110 @@ exactly what would be added to your commit messages.`,
 	RunE: runDetect,
 }
 
+var (
+	detectFormat         string
+	detectAll            bool
+	detectJSON           bool
+	detectLocal          bool
+	detectForceDetectors []string
+	detectRecord         string
+	detectReplay         string
+)
+
+func init() {
+	detectCmd.Flags().StringVar(&detectFormat, "format", "", "Preview a text/template commit line against the detected track")
+	detectCmd.Flags().BoolVar(&detectAll, "all", false, "Show what every detector reported, not just the selected one")
+	detectCmd.Flags().BoolVar(&detectJSON, "json", false, "Output the detected track as JSON")
+	
+	// Set by the remote detector, so two machines asking each other can't loop
+	detectCmd.Flags().BoolVar(&detectLocal, "local", false, "Only use this machine's detectors, not remote.ssh")
+	detectCmd.Flags().MarkHidden("local")
+	
+	// A support tool for reproducing bug reports, deliberately only on detect so it
+	// can never change what the hook does during a commit
+	detectCmd.Flags().StringSliceVar(&detectForceDetectors, "force-detector-order", nil, "Debug: run exactly these detectors in this order, even unavailable ones (e.g. mpris,wsl)")
+	detectCmd.Flags().MarkHidden("force-detector-order")
+	
+	// Recordings let a user's tool output be attached to a bug and parsed again
+	// anywhere. Detectors that talk HTTP (Spotify API, Roon, OBS) aren't recorded.
+	detectCmd.Flags().StringVar(&detectRecord, "record", "", "Debug: save the raw output of every command detection runs into this directory")
+	detectCmd.Flags().StringVar(&detectReplay, "replay", "", "Debug: detect from the command output recorded in this directory instead of running anything")
+	detectCmd.Flags().MarkHidden("record")
+	detectCmd.Flags().MarkHidden("replay")
+}
+
 func runDetect(cmd *cobra.Command, args []string) error {
+	if detectReplay != "" {
+		replay, err := audio.LoadReplay(detectReplay)
+		if err != nil {
+			return err
+		}
+		audio.Commands = replay
+		fmt.Fprintf(os.Stderr, "🐞 Debug: replaying commands recorded on %s at %s\n", replay.Platform, replay.Recorded.Format(time.RFC3339))
+		if replay.Platform != runtime.GOOS {
+			fmt.Fprintln(os.Stderr, "   Recorded on another OS - pick its detectors with --force-detector-order")
+		}
+	}
+	if detectRecord != "" {
+		recorder := audio.NewRecorder(audio.Commands)
+		audio.Commands = recorder
+		defer func() {
+			if err := recorder.Save(detectRecord); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  failed to save the recording: %v\n", err)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "📼 Recorded command output to %s\n", detectRecord)
+		}()
+	}
+	
+	if detectJSON {
+		return runDetectJSON()
+	}
 	fmt.Println("🎵 Detecting currently playing audio...")
 	
-	am := audio.NewAudioManager()
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("⚠️  %v (using defaults)\n", err)
+	}
+	if detectLocal {
+		cfg.Remote.SSH = ""
+	}
+	
+	// Compile up front so template mistakes are reported before a slow detection
+	var previewTemplate *template.Template
+	if detectFormat != "" {
+		previewTemplate, err = format.CompileTemplate(detectFormat)
+		if err != nil {
+			return err
+		}
+	}
+	
+	am := audio.NewAudioManager(cfg)
+	if len(detectForceDetectors) > 0 {
+		if err := am.ForceDetectorOrder(detectForceDetectors); err != nil {
+			return err
+		}
+		fmt.Printf("🐞 Debug: forcing detectors %s, skipping availability checks\n", strings.Join(detectForceDetectors, ", "))
+	}
 	
 	// Show available detectors
-	detectors := am.ListDetectors()
-	fmt.Printf("📡 Available detectors: %d\n", len(detectors))
-	for _, detector := range detectors {
-		fmt.Printf("  ✅ %s\n", detector.Name())
+	infos := am.DescribeDetectors()
+	available := 0
+	for _, info := range infos {
+		if info.Available {
+			available++
+		}
+	}
+	fmt.Printf("📡 Available detectors: %d\n", available)
+	for _, info := range infos {
+		if info.Available {
+			fmt.Printf("  ✅ %s\n", info.Name)
+		} else if !info.OtherPlatform {
+			fmt.Printf("  ❌ %s - %s\n", info.Name, info.Reason)
+		}
 	}
 	
-	if len(detectors) == 0 {
+	if available == 0 {
 		fmt.Println("❌ No audio detectors available on this platform")
 		return nil
 	}
@@ -41,28 +138,121 @@ func runDetect(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	
-	media, err := am.Detect(ctx)
+	// Ask every detector so we can explain which one won
+	media, results, err := am.DetectAll(ctx)
+	if detectAll {
+		printDetectorResults(results)
+	}
 	if err != nil {
 		fmt.Printf("❌ Detection failed: %v\n", err)
+		if errors.Is(err, audio.ErrAutomationDenied) {
+			fmt.Printf("\n%s\n", automationGuidance)
+		}
+		if errors.Is(err, audio.ErrExecutionPolicy) {
+			fmt.Printf("\n%s\n", executionPolicyGuidance)
+		}
 		return nil
 	}
 	
 	if media == nil {
 		fmt.Println("🔇 No audio currently playing")
+		if found := sourcesFound(results); found > 0 {
+			fmt.Printf("ℹ️  %d lower-priority source(s) had media, but stop_on_empty stopped at the first detector that answered\n", found)
+		}
 		return nil
 	}
 	
+	if found := sourcesFound(results); found > 1 {
+		fmt.Printf("\nℹ️  %d sources detected; selected %s (higher priority: %s)\n", found, media.Source, media.Detector)
+		if !detectAll {
+			fmt.Println("   Run with --all to see what each detector reported")
+		}
+	}
+	
 	// Display results
 	fmt.Println("\n🎵 Currently playing:")
 	fmt.Printf("   Title:  %s\n", media.Title)
 	fmt.Printf("   Artist: %s\n", media.Artist)
 	fmt.Printf("   Album:  %s\n", media.Album)
+	if media.Playlist != "" {
+		fmt.Printf("   From:   %s\n", media.Playlist)
+	} else if media.Context != "" {
+		fmt.Printf("   From:   %s (%s)\n", media.Context, media.ContextType)
+	}
 	fmt.Printf("   Source: %s\n", media.Source)
+	if media.Detector != "" {
+		fmt.Printf("   Via:    %s\n", media.Detector)
+	}
+	if media.OutputDevice != "" {
+		fmt.Printf("   Device: %s\n", media.OutputDevice)
+	}
 	fmt.Printf("   Type:   %s\n", media.Type)
 	
 	// Show what would be added to commit
-	commitText := format.FormatCommitMessage(media)
-	fmt.Printf("\n💬 Commit message addition:\n%s\n", commitText)
+	commitText := format.FormatCommitMessage(media, cfg)
+	if cfg.SkipIfMuted && media.Muted {
+		fmt.Println("\n🔇 Output is muted - the hook will skip this commit (skip_if_muted)")
+	} else {
+		fmt.Printf("\n💬 Commit message addition:\n%s\n", commitText)
+	}
+	
+	if previewTemplate != nil {
+		preview, err := format.Render(previewTemplate, media, cfg)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n🧪 Template preview:\n%s\n", preview)
+	}
 	
 	return nil
-} 
\ No newline at end of file
+} 
+
+// runDetectJSON prints what's playing as an audio.RemoteReport, which is
+// what the remote detector on another machine reads
+func runDetectJSON() error {
+	cfg, _ := config.Load()
+	if detectLocal {
+		cfg.Remote.SSH = ""
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	var report audio.RemoteReport
+	media, err := audio.NewAudioManager(cfg).Detect(ctx)
+	switch {
+	case errors.Is(err, audio.ErrNoAudio) && !errors.Is(err, audio.ErrAutomationDenied) && !errors.Is(err, audio.ErrExecutionPolicy):
+		// Nothing playing
+	case err != nil:
+		report.Error = err.Error()
+	default:
+		report.Media = media
+	}
+	printJSON(report)
+	return nil
+}
+
+// sourcesFound counts the detectors that reported media
+func sourcesFound(results []audio.DetectorResult) int {
+	found := 0
+	for _, result := range results {
+		if result.Media != nil {
+			found++
+		}
+	}
+	return found
+}
+
+func printDetectorResults(results []audio.DetectorResult) {
+	fmt.Println("\n🔎 Detector results (highest priority first):")
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			fmt.Printf("  ❌ %s: %v\n", result.Detector, result.Err)
+		case result.Media == nil:
+			fmt.Printf("  🔇 %s: nothing playing\n", result.Detector)
+		default:
+			fmt.Printf("  🎵 %s: \"%s\" from %s\n", result.Detector, result.Media.Title, result.Media.Source)
+		}
+	}
+}
\ No newline at end of file
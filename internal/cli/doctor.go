@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+	"github.com/pixare40/interactive-commit/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose common setup problems",
+	Long: `Check your environment for common problems that stop audio detection
+or the git hook from working, and explain how to fix them.`,
+	RunE: runDoctor,
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	fmt.Println("🩺 Checking your interactive-commit setup...")
+	
+	// Git is required for the hook to run at all
+	if version := gitVersion(); version == "" {
+		fmt.Println("❌ git not found on PATH")
+	} else {
+		fmt.Printf("✅ %s\n", version)
+	}
+	
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+	} else {
+		fmt.Println("✅ Configuration loaded")
+	}
+	if ignored := config.IgnoredRepoKeys(); len(ignored) > 0 {
+		fmt.Printf("⚠️  %s sets %s, which only your global config can set - ignored\n", config.FileName, strings.Join(ignored, ", "))
+	}
+	
+	am := audio.NewAudioManager(cfg)
+	available := 0
+	for _, info := range am.DescribeDetectors() {
+		switch {
+		case info.Available:
+			available++
+			fmt.Printf("✅ Detector available: %s\n", info.Name)
+		case !info.OtherPlatform:
+			fmt.Printf("⚠️  Detector unavailable: %s - %s\n", info.Name, info.Reason)
+		}
+	}
+	if available == 0 {
+		fmt.Println("❌ No audio detectors available on this platform")
+		return nil
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	media, err := am.Detect(ctx)
+	switch {
+	case errors.Is(err, audio.ErrAutomationDenied):
+		fmt.Printf("❌ %v\n\n%s\n", err, automationGuidance)
+	case errors.Is(err, audio.ErrExecutionPolicy):
+		fmt.Printf("❌ %v\n\n%s\n", err, executionPolicyGuidance)
+	case err != nil || media == nil:
+		fmt.Println("🔇 Detection ran but nothing is playing right now")
+	default:
+		fmt.Printf("✅ Detected \"%s\" from %s\n", media.Title, media.Source)
+	}
+	
+	if audio.FirefoxWithoutMPRIS(ctx) {
+		fmt.Printf("⚠️  %s\n", firefoxMPRISGuidance)
+	}
+	
+	return nil
+}
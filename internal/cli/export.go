@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/history"
+	"github.com/pixare40/interactive-commit/internal/spotify"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the tracks of your soundtracked commits",
+	Long: `Export the tracks recorded in your history, oldest first.
+
+Formats:
+  csv          time, title, artist, album, source and repo of every commit
+  spotify-uri  one spotify:track: URI per line, each track once - paste them
+               into a Spotify playlist to rebuild your coding soundtrack
+
+Tracks detected through the Spotify Web API carry their exact URI. Others are
+looked up by title and artist, which needs 'interactive-commit spotify login';
+tracks that can't be found are left out.
+
+Requires "history": true in your config so tagged commits are recorded.
+
+Examples:
+  interactive-commit export > soundtrack.csv
+  interactive-commit export --format spotify-uri --since 30d`,
+	Args: cobra.NoArgs,
+	RunE: runExport,
+}
+
+var (
+	exportFormat string
+	exportSince  string
+)
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", "csv", "Output format: csv or spotify-uri")
+	exportCmd.Flags().StringVar(&exportSince, "since", "", "Only export commits since a duration ago (30d, 12h) or a date (2025-01-01)")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	since, err := parseSince(exportSince)
+	if err != nil {
+		return err
+	}
+	
+	entries, err := history.Load(since)
+	if err != nil {
+		return err
+	}
+	
+	switch exportFormat {
+	case "csv":
+		return exportCSV(entries)
+	case "spotify-uri":
+		return exportSpotifyURIs(entries)
+	}
+	return fmt.Errorf("unknown export format %q (use csv or spotify-uri)", exportFormat)
+}
+
+// exportCSV writes every history entry as a CSV row
+func exportCSV(entries []history.Entry) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"time", "title", "artist", "album", "source", "repo"})
+	for _, entry := range entries {
+		w.Write([]string{entry.Time.Format(time.RFC3339), entry.Title, entry.Artist, entry.Album, entry.Source, entry.Repo})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// exportSpotifyURIs writes the Spotify URI of each track once, in the order
+// first committed to. Tracks without a recorded URI are searched for.
+func exportSpotifyURIs(entries []history.Entry) error {
+	var client *spotify.Client
+	var searchErr error
+	searched := map[string]string{}
+	written := map[string]bool{}
+	var missing int
+	
+	for _, entry := range entries {
+		if entry.Type == "podcast" || entry.Type == "video" {
+			continue
+		}
+	
+		uri := entry.TrackURI
+		if !strings.HasPrefix(uri, "spotify:track:") {
+			key := strings.ToLower(entry.Title + "\x00" + entry.Artist)
+			found, ok := searched[key]
+			if !ok && searchErr == nil {
+				if client == nil {
+					client, searchErr = spotify.NewClient()
+				}
+				if searchErr == nil {
+					ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+					found, searchErr = client.SearchTrack(ctx, entry.Title, entry.Artist)
+					cancel()
+				}
+				searched[key] = found
+			}
+			uri = found
+		}
+	
+		if uri == "" {
+			missing++
+			continue
+		}
+		if !written[uri] {
+			written[uri] = true
+			fmt.Println(uri)
+		}
+	}
+	
+	if missing > 0 {
+		fmt.Fprintf(os.Stderr, "⚠️  Left out %d commits whose track had no Spotify URI and wasn't found by search\n", missing)
+		if searchErr != nil {
+			fmt.Fprintf(os.Stderr, "   Search failed: %v\n", searchErr)
+		}
+	}
+	return nil
+}
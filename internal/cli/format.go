@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/pixare40/interactive-commit/internal/config"
+	"github.com/pixare40/interactive-commit/internal/format"
+	"github.com/spf13/cobra"
+)
+
+var formatCmd = &cobra.Command{
+	Use:   "format",
+	Short: "Work with commit line templates",
+}
+
+var formatSampleCmd = &cobra.Command{
+	Use:   "sample",
+	Short: "Render a template against a set of sample tracks",
+	Long: `Render your configured template (or one passed with --template) against
+built-in sample tracks - songs with and without artists, podcasts, livestreams,
+long and emoji titles - to catch edge cases before using it in commits.`,
+	RunE: runFormatSample,
+}
+
+var (
+	formatSampleTemplate string
+	formatSamplePreset   string
+)
+
+func init() {
+	formatSampleCmd.Flags().StringVar(&formatSampleTemplate, "template", "", "Template to render instead of the configured one")
+	formatSampleCmd.Flags().StringVar(&formatSamplePreset, "preset", "", "Preset to render instead of the active one")
+	formatCmd.AddCommand(formatSampleCmd)
+}
+
+func runFormatSample(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("⚠️  %v (using defaults)\n", err)
+	}
+	
+	if formatSamplePreset != "" {
+		if err := cfg.CheckPreset(formatSamplePreset); err != nil {
+			return err
+		}
+		cfg.ActivePreset = formatSamplePreset
+	}
+	if resolved, err := cfg.Resolved(); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	} else {
+		cfg = resolved
+	}
+	
+	if formatSampleTemplate != "" {
+		// Validate up front so a typo gives one clear error, not one per sample
+		if _, err := format.CompileTemplate(formatSampleTemplate); err != nil {
+			return err
+		}
+		cfg.Template = formatSampleTemplate
+	}
+	
+	if cfg.Template == "" {
+		fmt.Println("📝 Using the built-in format")
+	} else {
+		fmt.Printf("📝 Template: %s\n", cfg.Template)
+	}
+	
+	for _, sample := range format.Samples() {
+		fmt.Printf("\n%s:\n  %s\n", sample.Name, format.FormatCommitMessage(sample.Media, cfg))
+	}
+	
+	return nil
+}
@@ -2,79 +2,630 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
 	"github.com/pixare40/interactive-commit/internal/audio"
+	"github.com/pixare40/interactive-commit/internal/cache"
+	"github.com/pixare40/interactive-commit/internal/config"
 	"github.com/pixare40/interactive-commit/internal/format"
+	"github.com/pixare40/interactive-commit/internal/gitutil"
+	"github.com/pixare40/interactive-commit/internal/history"
+	"github.com/pixare40/interactive-commit/internal/message"
 	"github.com/spf13/cobra"
 )
 
+// inHookEnvVar is set while the hook runs, to stop it from re-entering itself
+const inHookEnvVar = "INTERACTIVE_COMMIT_IN_HOOK"
+
 var hookCmd = &cobra.Command{
 	Use:    "hook",
 	Short:  "Git hook handler (internal use)",
 	Long:   "This command is called by git hooks. You shouldn't run this manually.",
 	Hidden: true,
-	RunE:   runHook,
+	RunE:   runHookCommand,
+	
+	// A hook script from another version may pass flags this one doesn't know
+	FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
+}
+
+var (
+	hookNowPlaying     string
+	hookAppendPosition string
+)
+
+func init() {
+	hookCmd.Flags().StringVar(&hookNowPlaying, "now-playing", "", "Use this track (\"Title by Artist\") instead of detecting audio")
+	hookCmd.Flags().StringVar(&hookAppendPosition, "append-position", "", "Where to place the audio line: top or bottom (overrides config)")
+}
+
+// runHookCommand holds the hook to its exit-code contract. Git aborts the
+// commit when prepare-commit-msg exits non-zero, so a failure - even a crash -
+// is reported on stderr and the commit goes ahead without a track. With
+// "strict": true failures abort the commit instead.
+func runHookCommand(cmd *cobra.Command, args []string) (err error) {
+	cfg, _ := config.Load()
+	if cfg.Strict {
+		// main reports the error; usage would only bury it
+		cmd.SilenceUsage, cmd.SilenceErrors = true, true
+		return runHook(cmd, args)
+	}
+	
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  interactive-commit crashed, committing without a track: %v\n", r)
+			err = nil
+		}
+	}()
+	if err := runHook(cmd, args); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v; committing without a track\n", err)
+	}
+	return nil
 }
 
 func runHook(cmd *cobra.Command, args []string) error {
 	// This is called as a git hook
-	// args[0] should be the commit message file path
+	// args[0] should be the commit message file path, args[1] the message source
+	// and args[2] a commit SHA (see githooks(5) prepare-commit-msg)
 	
 	if len(args) < 1 {
 		return fmt.Errorf("missing commit message file argument")
 	}
 	
-	commitMsgFile := args[0]
+	// Anything we start inherits this, so a commit made from inside the hook
+	// (by a detector, a notifier or a misbehaving chained hook) can't loop back into it
+	if os.Getenv(inHookEnvVar) != "" {
+		return nil
+	}
+	os.Setenv(inHookEnvVar, "1")
+	
+	commitMsgFile := resolveMessagePath(args[0])
+	
+	// A broken config must never block a commit - fall back to defaults
+	cfg, _ := config.Load()
+	if resolved, err := cfg.Resolved(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+	} else {
+		cfg = resolved
+	}
+	
+	// A note from a commit abandoned in the editor must not reach this one
+	if cfg.StoreAs == config.StoreNote && !previewing {
+		clearPendingNote()
+	}
+	// Nor may its skip let this one past require_soundtrack
+	if cfg.RequireSoundtrack && !previewing {
+		clearSkipRecord()
+	}
+	
+	// args[1] is git's commit message source: empty when an editor will open,
+	// "message" for -m/-F, or "template", "merge", "squash", "commit"
+	commitSource := ""
+	if len(args) > 1 {
+		commitSource = args[1]
+	}
+	
+	if cfg.OnlyInteractive && commitSource != "" {
+		return skipCommit(cfg) // Not an editor commit, leave it alone
+	}
+	if skipsSource(cfg.SkipSources, commitSource) {
+		return skipCommit(cfg)
+	}
 	
 	// Read current commit message
-	content, err := os.ReadFile(commitMsgFile)
+	raw, err := os.ReadFile(commitMsgFile)
 	if err != nil {
 		return fmt.Errorf("failed to read commit message file: %w", err)
 	}
 	
-	// Detect currently playing audio
-	am := audio.NewAudioManager()
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	// Repositories with i18n.commitEncoding keep their messages in that encoding
+	charset := commitEncoding()
+	content, err := message.Decode(raw, charset)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		return nil // Rewriting a message we can't read would corrupt it
+	}
 	
-	media, err := am.Detect(ctx)
-	if err != nil || media == nil {
-		// No audio detected or error - just continue without adding anything
+	// At this point a commit.template message is usually still untouched. Git
+	// aborts commits whose message is left as the template, so adding our line
+	// would turn an abandoned commit into a commit of the boilerplate.
+	// A suggestion is only a comment, so it's still offered.
+	if commitSource == "template" && cfg.Mode != config.ModeSuggest && isUneditedTemplate(message.ToLF(content), charset) {
 		return nil
 	}
 	
-	// Check if there's actual commit content (non-comment, non-whitespace lines)
-	lines := strings.Split(string(content), "\n")
-	hasRealContent := false
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
-			hasRealContent = true
-			break
+	// The soundtrack belongs to the commit a fixup is squashed into, not in its
+	// message, where it would pile up under the original's line
+	if cfg.SkipFixup && message.IsAutosquash(message.ToLF(content)) {
+		return skipCommit(cfg)
+	}
+	
+	amendBehavior := cfg.AmendBehavior
+	switch amendBehavior {
+	case "", config.AmendAppend, config.AmendReplace, config.AmendSkip:
+	default:
+		fmt.Fprintf(os.Stderr, "⚠️  unknown amend_behavior %q (expected %q, %q or %q)\n", amendBehavior, config.AmendAppend, config.AmendReplace, config.AmendSkip)
+		amendBehavior = config.AmendAppend
+	}
+	
+	isSilence := func(line string) bool {
+		return isSilenceLine(line, cfg.SilenceText)
+	}
+	
+	// git passes "commit" as the source for --amend (and -c/-C); a message that
+	// already has our line or trailer has been through the hook before
+	if amendBehavior == config.AmendSkip {
+		isOurs := func(line string) bool { return isAudioLine(line) || isSilence(line) }
+		if commitSource == "commit" || message.FindLine(message.ToLF(content), isOurs) >= 0 {
+			return nil
 		}
 	}
 	
-	if !hasRealContent {
+	// A manual override skips detection entirely
+	override := hookNowPlaying
+	if override == "" {
+		override = os.Getenv(audio.OverrideEnvVar)
+	}
+	
+	var media *audio.MediaInfo
+	if override != "" {
+		media = audio.ParseOverride(override)
+	} else if cfg.AsyncDetect {
+		var ok bool
+		media, ok = asyncDetection(cfg)
+		if !ok {
+			return nil // Nothing detected yet; adding the silence text would be a guess
+		}
+	} else {
+		// Detect currently playing audio
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		
+		media, err = detectForHook(ctx, cfg)
+		if err != nil {
+			if errors.Is(err, audio.ErrAutomationDenied) {
+				warnOnce("macos-automation", automationGuidance+"\nRun 'interactive-commit doctor' for details.")
+			}
+			if errors.Is(err, audio.ErrExecutionPolicy) {
+				warnOnce("execution-policy", executionPolicyGuidance+"\nRun 'interactive-commit doctor' for details.")
+			}
+			// No audio detected or error - only the silence text, if any, is added
+			media = nil
+		}
+	}
+	
+	// A track skipped to a moment ago hasn't really been heard yet
+	if override == "" && media != nil && !heardLongEnough(media, cfg) {
+		return skipCommit(cfg)
+	}
+	
+	if media == nil && cfg.SilenceText == "" {
+		return nil
+	}
+	
+	if media != nil && inaudible(media, cfg) {
+		return skipCommit(cfg)
+	}
+	
+	if cfg.Mode == config.ModeSuggest {
+		return suggestTrack(commitMsgFile, content, charset, commitSource, media, cfg)
+	}
+	
+	// Work on LF internally and write back with the file's own convention (CRLF on Windows)
+	lineEnding := message.LineEnding(content)
+	text := message.ToLF(content)
+	
+	// Check if there's actual commit content (non-comment, non-whitespace lines)
+	if !message.HasContent(text) {
 		return nil // No actual commit content, don't add anything
 	}
 	
-	// Format the audio info using shared utility
-	audioLine := format.FormatCommitMessage(media)
+	// dedup_consecutive: the last commit here already names this track
+	repeated := media != nil && cfg.DedupConsecutive && sameAsLastCommit(cfg, media)
+	if repeated && cfg.StillPlayingText == "" {
+		return skipCommit(cfg)
+	}
+	isStillPlaying := func(line string) bool {
+		return isSilenceLine(line, cfg.StillPlayingText)
+	}
+	if repeated && (message.FindLine(text, isAudioLine) >= 0 || message.FindLine(text, isStillPlaying) >= 0) {
+		return nil // Amending the commit that has the line; it keeps it
+	}
+	
+	// Format the audio info using shared utility, or mark the silence
+	audioLine := cfg.SilenceText
+	if repeated {
+		audioLine = cfg.StillPlayingText
+	} else if media != nil {
+		audioLine = format.FormatCommitMessage(media, cfg)
+	} else if message.FindLine(text, isSilence) >= 0 || message.FindLine(text, isAudioLine) >= 0 {
+		// An amend that's already marked silent, or whose track was heard, keeps its line
+		return nil
+	}
+	
+	// Notes leave the message pristine; post-commit attaches the note once the commit exists
+	if cfg.StoreAs == config.StoreNote {
+		if !hasPostCommitHook() {
+			warnOnce("post-commit-hook", "store_as is \"note\" but the post-commit hook that attaches notes isn't installed.\nRun 'interactive-commit install' again to add it.")
+		}
+		if previewing {
+			previewNote = buildNote(media, audioLine, cfg)
+			return nil
+		}
+		if err := savePendingNote(buildNote(media, audioLine, cfg)); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  failed to save the track for its git note: %v\n", err)
+			return nil
+		}
+		recordTagged(cfg, media, text)
+		return nil
+	}
+	
+	if cfg.Marker {
+		audioLine += format.Marker
+	}
+	
+	position := cfg.AppendPosition
+	if hookAppendPosition != "" {
+		position = hookAppendPosition
+	}
 	
-	// Preserve original content structure, only trim trailing newlines
-	originalContent := strings.TrimRight(string(content), "\n")
+	// smart_placement keeps a written body on top and moves the line into the
+	// trailers; an explicit --append-position still wins
+	asTrailer := cfg.SmartPlacement && hookAppendPosition == "" && message.HasBody(text, func(line string) bool {
+		return isAudioLine(line) || isSilence(line)
+	})
 	
-	// Append audio info with proper spacing
-	newContent := originalContent + "\n\n" + audioLine + "\n"
+	// With replace, an amended message keeps one up-to-date line where the old one was
+	existing := -1
+	if amendBehavior == config.AmendReplace {
+		existing = message.FindLine(text, func(line string) bool {
+			return format.IsCommitLine(line) || line == audioLine || isSilence(line) || strings.HasPrefix(line, format.SoundtrackKey+": ")
+		})
+	}
+	
+	// With replace, trailers from an earlier run are updated rather than repeated
+	addTrailer := message.AppendTrailer
+	if amendBehavior == config.AmendReplace {
+		addTrailer = message.SetTrailer
+	}
+	
+	var newContent string
+	switch {
+	case existing >= 0 && strings.HasPrefix(strings.Split(text, "\n")[existing], format.SoundtrackKey+": "):
+		// Trailers stay on one line
+		newContent = message.ReplaceLine(text, existing, format.SoundtrackKey+": "+audioLine)
+	case existing < 0 && asTrailer:
+		newContent = addTrailer(text, format.SoundtrackKey, audioLine)
+	case existing >= 0 && cfg.WrapWidth > 0:
+		newContent = message.ReplaceParagraph(text, existing, format.Wrap(audioLine, cfg.WrapWidth))
+	case existing >= 0:
+		newContent = message.ReplaceLine(text, existing, audioLine)
+	default:
+		audioLine = format.Wrap(audioLine, cfg.WrapWidth)
+		newContent, err = message.Insert(text, audioLine, position)
+		if err != nil {
+			// A typo in the config shouldn't lose the soundtrack - use the default placement
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+			newContent, _ = message.Insert(text, audioLine, message.PositionBottom)
+		}
+	}
+	if cfg.MachineTrailer && media != nil {
+		if trailer, err := format.MachineTrailer(media); err == nil {
+			newContent = addTrailer(newContent, format.TrailerKey, trailer)
+		}
+	}
+	if cfg.PlayedOnTrailer && media != nil {
+		newContent = addTrailer(newContent, format.PlayedOnKey, playedOn(media))
+	}
+	newContent = message.FromLF(newContent, lineEnding)
+	
+	encoded, err := message.Encode(newContent, charset)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		return nil
+	}
 	
 	// Write back to file
-	if err := os.WriteFile(commitMsgFile, []byte(newContent), 0644); err != nil {
+	if err := os.WriteFile(commitMsgFile, encoded, 0644); err != nil {
 		return fmt.Errorf("failed to write commit message file: %w", err)
 	}
 	
+	recordTagged(cfg, media, text)
 	return nil
-} 
\ No newline at end of file
+}
+
+// commitSources are the message sources git names to prepare-commit-msg
+var commitSources = map[string]bool{"message": true, "template": true, "merge": true, "squash": true, "commit": true}
+
+// skipsSource reports whether skip_sources leaves commits from source alone
+func skipsSource(skipSources []string, source string) bool {
+	for _, skip := range skipSources {
+		if !commitSources[skip] {
+			fmt.Fprintf(os.Stderr, "⚠️  unknown commit source %q in skip_sources (expected message, template, merge, squash or commit)\n", skip)
+			continue
+		}
+		if skip == source {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestTrack offers the track as a commented-out line in the editor. Git
+// drops comments, so the commit only gets it if the user uncomments it.
+func suggestTrack(path, content, charset, commitSource string, media *audio.MediaInfo, cfg *config.Config) error {
+	// Comments are only stripped when an editor opens; with -m, -F or
+	// --no-edit they'd end up in the commit
+	if media == nil || (commitSource != "" && commitSource != "template") {
+		return nil
+	}
+	
+	line := format.FormatCommitMessage(media, cfg)
+	if cfg.Marker {
+		line += format.Marker
+	}
+	
+	lines := strings.Split(format.Wrap(line, cfg.WrapWidth), "\n")
+	intro := "Uncomment the next line to add what's playing:"
+	if len(lines) > 1 {
+		intro = "Uncomment the next lines to add what's playing:"
+	}
+	
+	lineEnding := message.LineEnding(content)
+	suggested := message.Suggest(message.ToLF(content), append([]string{intro}, lines...))
+	
+	encoded, err := message.Encode(message.FromLF(suggested, lineEnding), charset)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		return nil
+	}
+	if err := os.WriteFile(path, encoded, 0644); err != nil {
+		return fmt.Errorf("failed to write commit message file: %w", err)
+	}
+	return nil
+}
+
+// recordTagged does the follow-up for a tagged commit: feedback, history and
+// notifications. media is nil when the silence text was added.
+func recordTagged(cfg *config.Config, media *audio.MediaInfo, text string) {
+	if previewing {
+		return
+	}
+	
+	// stderr only: some git versions and GUIs fold hook stdout into the message
+	if cfg.HookFeedback {
+		fmt.Fprintf(os.Stderr, "%s\n", hookFeedbackLine(media, cfg.StoreAs == config.StoreNote))
+	}
+	
+	if media == nil {
+		return
+	}
+	
+	if cfg.History {
+		// History is a nice-to-have, never fail the commit over it
+		history.Append(history.NewEntry(media, repoRoot()))
+	}
+	
+	if cfg.Notify.WebhookURL != "" {
+		startNotify(media, message.Subject(text))
+	}
+}
+
+// sameAsLastCommit reports whether the history's latest commit in this
+// repository was tagged with media's track. Without history there's no
+// telling, and every commit gets its line.
+func sameAsLastCommit(cfg *config.Config, media *audio.MediaInfo) bool {
+	if !cfg.History {
+		warnOnce("dedup-history", "dedup_consecutive needs \"history\": true to know the previous commit's track.")
+		return false
+	}
+	root := repoRoot()
+	if root == "" {
+		return false
+	}
+	last, ok := history.Last(root)
+	return ok && last.IsTrack(media)
+}
+
+// playedOn describes where the track was heard, e.g. "macOS via Apple Music"
+func playedOn(media *audio.MediaInfo) string {
+	platform := runtime.GOOS
+	switch runtime.GOOS {
+	case "darwin":
+		platform = "macOS"
+	case "linux":
+		platform = "Linux"
+		if os.Getenv("WSL_DISTRO_NAME") != "" {
+			platform = "WSL"
+		}
+	case "windows":
+		platform = "Windows"
+	}
+	
+	if media.Source == "" {
+		return platform
+	}
+	return platform + " via " + media.Source
+}
+
+// isAudioLine recognizes a soundtrack line or trailer left by an earlier run of the hook
+func isAudioLine(line string) bool {
+	return format.IsCommitLine(line) || strings.HasPrefix(line, format.TrailerKey+": ") || strings.HasPrefix(line, format.SoundtrackKey+": ")
+}
+
+// resolveMessagePath finds the message file git passed. Git gives a path like
+// ".git/COMMIT_EDITMSG" relative to the repository root, which some GUI clients
+// don't run hooks from; then it's looked for under the top level and in the
+// git directory.
+func resolveMessagePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	
+	var candidates []string
+	if topLevel, err := gitutil.RevParse("--show-toplevel"); err == nil {
+		candidates = append(candidates, filepath.Join(topLevel, path))
+	}
+	if gitDir, err := gitutil.RevParse("--absolute-git-dir"); err == nil {
+		candidates = append(candidates, filepath.Join(gitDir, filepath.Base(path)))
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return path // Let the read fail with the path git gave
+}
+
+// hookFeedbackLine is the one-line confirmation shown with hook_feedback
+func hookFeedbackLine(media *audio.MediaInfo, asNote bool) string {
+	tagged := "tagged"
+	if asNote {
+		tagged = "noted"
+	}
+	
+	switch {
+	case media == nil:
+		return "🔇 " + tagged + ": nothing playing"
+	case media.Artist != "":
+		return fmt.Sprintf("🎵 %s: %s by %s", tagged, media.Title, media.Artist)
+	default:
+		return fmt.Sprintf("🎵 %s: %s", tagged, media.Title)
+	}
+}
+
+// heardLongEnough reports whether media has been playing for min_position_seconds.
+// Players that don't report a position pass unless skip_unknown_position is set.
+func heardLongEnough(media *audio.MediaInfo, cfg *config.Config) bool {
+	if cfg.MinPositionSeconds <= 0 {
+		return true
+	}
+	if media.Position <= 0 {
+		return !cfg.SkipUnknownPosition
+	}
+	return media.Position >= time.Duration(cfg.MinPositionSeconds)*time.Second
+}
+
+// inaudible reports whether playing media isn't really the soundtrack: with
+// skip_if_muted nobody is hearing it, and with skip_during_calls it's meeting audio
+func inaudible(media *audio.MediaInfo, cfg *config.Config) bool {
+	return cfg.SkipIfMuted && media.Muted || cfg.SkipDuringCalls && media.InCall != ""
+}
+
+// isSilenceLine recognizes the configured silence text, with or without the marker
+func isSilenceLine(line, silenceText string) bool {
+	return silenceText != "" && strings.TrimSuffix(line, format.Marker) == silenceText
+}
+
+// repoName returns the name of the current repository's top-level directory
+func repoName() string {
+	if topLevel := repoRoot(); topLevel != "" {
+		return filepath.Base(topLevel)
+	}
+	return ""
+}
+
+// repoRoot returns the current repository's top-level directory, or ""
+func repoRoot() string {
+	topLevel, err := gitutil.RevParse("--show-toplevel")
+	if err != nil {
+		return ""
+	}
+	return topLevel
+} 
+
+// detectForHook runs detection, taking turns with other commits on this machine
+// when detection_lock is set. A commit that had to wait reuses the result the
+// lock holder saved, and goes ahead without audio if the wait times out.
+func detectForHook(ctx context.Context, cfg *config.Config) (*audio.MediaInfo, error) {
+	am := hookManager(cfg)
+	if !cfg.DetectionLock {
+		return am.Detect(ctx)
+	}
+	
+	waitStart := time.Now()
+	lockCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.LockTimeoutMS)*time.Millisecond)
+	defer cancel()
+	
+	release, err := cache.Lock(lockCtx)
+	if errors.Is(err, cache.ErrLocked) {
+		if media, ok := finishedResult(ctx, am, waitStart); ok {
+			return media, nil
+		}
+		return nil, err
+	}
+	if err != nil {
+		return am.Detect(ctx) // No usable cache directory, so no lock either
+	}
+	defer release()
+	
+	// Another commit detected while we waited - its answer is still current
+	if media, ok := finishedResult(ctx, am, waitStart); ok {
+		return media, nil
+	}
+	
+	// The saved result is raw, for other commits to finish under their own repo's config
+	media, err := am.DetectRaw(ctx)
+	cache.SaveResult(media)
+	if media != nil {
+		am.Finish(ctx, media)
+	}
+	return media, err
+}
+
+// finishedResult loads the raw result another commit saved since since and
+// finishes it under am's config, which may be another repo's than the saver's
+func finishedResult(ctx context.Context, am *audio.AudioManager, since time.Time) (*audio.MediaInfo, bool) {
+	media, _, ok := cache.LoadResult(since)
+	if ok && media != nil {
+		am.Finish(ctx, media)
+	}
+	return media, ok
+}
+
+// hookManager returns an AudioManager for cfg with the caches the hook uses
+func hookManager(cfg *config.Config) *audio.AudioManager {
+	am := audio.NewAudioManager(cfg)
+	if cfg.DetectionCache {
+		am.UseTrackStore(cache.Tracks{}, time.Duration(cfg.DetectionCacheTTLSeconds)*time.Second)
+	}
+	if cfg.IncludeCoverColor {
+		am.UseColorStore(cache.Colors{})
+	}
+	return am
+}
+
+// isUneditedTemplate reports whether text is still the configured commit.template,
+// which is written in the commit encoding like the message
+func isUneditedTemplate(text, charset string) bool {
+	path, ok := gitutil.ConfigPath("commit.template")
+	if !ok {
+		return false // No commit.template (e.g. commit -t), nothing to compare against
+	}
+	
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	
+	template, err := message.Decode(raw, charset)
+	if err != nil {
+		return false
+	}
+	
+	return message.SameText(text, message.ToLF(template))
+}
+
+// commitEncoding returns the repository's i18n.commitEncoding, "" when unset
+func commitEncoding() string {
+	charset, _ := gitutil.Config("i18n.commitEncoding")
+	return charset
+}
@@ -0,0 +1,704 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+	"github.com/pixare40/interactive-commit/internal/cache"
+	"github.com/pixare40/interactive-commit/internal/config"
+	"github.com/pixare40/interactive-commit/internal/format"
+	"github.com/pixare40/interactive-commit/internal/gitutil"
+	"github.com/pixare40/interactive-commit/internal/history"
+)
+
+const testTrack = "Nightcall by Kavinsky"
+
+// hookEnv sets up a fresh repository to run the hook in, with configJSON as
+// the global config and the track given through the override, so nothing is
+// detected and no real config, history or cache is touched
+func hookEnv(t *testing.T, configJSON string) {
+	t.Helper()
+	dir := t.TempDir()
+	home := filepath.Join(dir, "home")
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, ".local", "share"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv(audio.OverrideEnvVar, testTrack)
+	t.Setenv(inHookEnvVar, "")
+	
+	configDir := filepath.Join(home, ".config", "interactive-commit")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(configJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	repo := filepath.Join(dir, "repo")
+	if output, err := exec.Command("git", "init", "-q", repo).CombinedOutput(); err != nil {
+		t.Skipf("git init: %v: %s", err, output)
+	}
+	chdir(t, repo)
+}
+
+// chdir moves the test to dir, forgetting what git said about the last one
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	t.Chdir(dir)
+	gitutil.Forget()
+}
+
+// runTestHook writes content as the commit message, runs the hook on it with
+// git's extra arguments (source, SHA) and returns the message it leaves
+func runTestHook(t *testing.T, content string, args ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	// The hook marks the environment for everything it starts; each run here is a new commit
+	os.Setenv(inHookEnvVar, "")
+	if err := runHook(hookCmd, append([]string{path}, args...)); err != nil {
+		t.Fatal(err)
+	}
+	
+	result, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(result)
+}
+
+// tagged reports whether the hook added the test track to message
+func tagged(message string) bool {
+	return strings.Contains(message, `"Nightcall" by Kavinsky`)
+}
+
+func TestHookPerSource(t *testing.T) {
+	// git's second argument: empty when an editor opens, "message" for -m/-F,
+	// "template" for commit.template, "merge", "squash", or "commit" for --amend/-c/-C
+	tests := []struct {
+		source          string
+		tagged          bool
+		interactiveOnly bool
+	}{
+		{"", true, true},
+		{"message", true, false},
+		{"template", true, false},
+		{"merge", true, false},
+		{"squash", true, false},
+		{"commit", true, false},
+	}
+	
+	for _, tt := range tests {
+		name := tt.source
+		if name == "" {
+			name = "editor"
+		}
+		t.Run(name, func(t *testing.T) {
+			hookEnv(t, `{}`)
+			if got := runTestHook(t, "Fix the parser\n", tt.source); tagged(got) != tt.tagged {
+				t.Errorf("tagged = %v, want %v:\n%s", tagged(got), tt.tagged, got)
+			}
+			
+			hookEnv(t, `{"only_interactive": true}`)
+			if got := runTestHook(t, "Fix the parser\n", tt.source); tagged(got) != tt.interactiveOnly {
+				t.Errorf("only_interactive: tagged = %v, want %v:\n%s", tagged(got), tt.interactiveOnly, got)
+			}
+			
+			if tt.source == "" {
+				return // An editor commit has no source to skip
+			}
+			hookEnv(t, `{"skip_sources": ["`+tt.source+`"]}`)
+			if got := runTestHook(t, "Fix the parser\n", tt.source); tagged(got) {
+				t.Errorf("skip_sources: tagged:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestHookAmendBehavior(t *testing.T) {
+	tests := []struct {
+		behavior string
+		want     []string // Tracks named in the amended message, in order
+	}{
+		{"append", []string{"Nightcall", "Genesis"}},
+		{"replace", []string{"Genesis"}},
+		{"skip", []string{"Nightcall"}},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.behavior, func(t *testing.T) {
+			hookEnv(t, `{"amend_behavior": "`+tt.behavior+`"}`)
+			first := runTestHook(t, "Fix the parser\n")
+			
+			// git commit --amend passes the source "commit" and the amended commit
+			t.Setenv(audio.OverrideEnvVar, "Genesis by Justice")
+			amended := runTestHook(t, first, "commit", "HEAD")
+			
+			var got []string
+			for _, line := range strings.Split(amended, "\n") {
+				for _, title := range []string{"Nightcall", "Genesis"} {
+					if strings.Contains(line, `"`+title+`"`) {
+						got = append(got, title)
+					}
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("tracks = %v, want %v:\n%s", got, tt.want, amended)
+			}
+		})
+	}
+}
+
+func TestHookAmendSkipFindsOwnLine(t *testing.T) {
+	hookEnv(t, `{"amend_behavior": "skip"}`)
+	first := runTestHook(t, "Fix the parser\n")
+	
+	// Without the "commit" source, the line the hook added still marks an amend
+	t.Setenv(audio.OverrideEnvVar, "Genesis by Justice")
+	if again := runTestHook(t, first); again != first {
+		t.Errorf("message changed:\n%s", again)
+	}
+}
+
+func TestHookMarkerFindsEditedLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   []string // Lines naming a track after the amend
+	}{
+		{"marker", `{"amend_behavior": "replace", "template": "♪ {{.Title}}"}`, []string{"♪ Genesis"}},
+		{"no marker", `{"amend_behavior": "replace", "template": "♪ {{.Title}}", "marker": false}`, []string{"♪ Nightcall, on repeat", "♪ Genesis"}},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hookEnv(t, tt.config)
+			first := runTestHook(t, "Fix the parser\n")
+			
+			// Between commits the body grows and the line itself is reworded;
+			// the marker at its end survives
+			edited := strings.Replace(first, "Fix the parser\n", "Fix the parser\n\nIt choked on tabs.\n", 1)
+			edited = strings.Replace(edited, "♪ Nightcall", "♪ Nightcall, on repeat", 1)
+			edited += "\nReviewed-by: Someone <someone@example.com>\n"
+			
+			t.Setenv(audio.OverrideEnvVar, "Genesis by Justice")
+			amended := runTestHook(t, edited, "commit", "HEAD")
+			
+			var got []string
+			for _, line := range strings.Split(amended, "\n") {
+				if strings.HasPrefix(line, "♪ ") {
+					got = append(got, strings.TrimSuffix(line, format.Marker))
+				}
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("track lines = %q, want %q:\n%s", got, tt.want, amended)
+			}
+			if !strings.Contains(amended, "It choked on tabs.") {
+				t.Errorf("the edited body was lost:\n%s", amended)
+			}
+		})
+	}
+}
+
+func TestInaudible(t *testing.T) {
+	tests := []struct {
+		name           string
+		media          audio.MediaInfo
+		skipIfMuted    bool
+		skipDuringCall bool
+		want           bool
+	}{
+		{"muted", audio.MediaInfo{Muted: true}, true, false, true},
+		{"muted, option off", audio.MediaInfo{Muted: true}, false, false, false},
+		{"audible", audio.MediaInfo{Volume: 60}, true, false, false},
+		{"in a call", audio.MediaInfo{InCall: "Zoom"}, false, true, true},
+		{"in a call, option off", audio.MediaInfo{InCall: "Zoom"}, true, false, false},
+	}
+	
+	for _, tt := range tests {
+		cfg := config.Default()
+		cfg.SkipIfMuted, cfg.SkipDuringCalls = tt.skipIfMuted, tt.skipDuringCall
+		media := tt.media
+		if got := inaudible(&media, cfg); got != tt.want {
+			t.Errorf("%s: inaudible = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHeardLongEnough(t *testing.T) {
+	tests := []struct {
+		name        string
+		position    time.Duration
+		minSeconds  int
+		skipUnknown bool
+		want        bool
+	}{
+		{"option off", 5 * time.Second, 0, false, true},
+		{"below", 29 * time.Second, 30, false, false},
+		{"equal", 30 * time.Second, 30, false, true},
+		{"above", 3 * time.Minute, 30, false, true},
+		{"unknown position", 0, 30, false, true},
+		{"unknown position, skip_unknown_position", 0, 30, true, false},
+		{"known position, skip_unknown_position", time.Minute, 30, true, true},
+	}
+	
+	for _, tt := range tests {
+		cfg := config.Default()
+		cfg.MinPositionSeconds, cfg.SkipUnknownPosition = tt.minSeconds, tt.skipUnknown
+		media := &audio.MediaInfo{Title: "Nightcall", Position: tt.position}
+		if got := heardLongEnough(media, cfg); got != tt.want {
+			t.Errorf("%s: heardLongEnough = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHookIgnoresReentrantCall(t *testing.T) {
+	hookEnv(t, `{}`)
+	path := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	if err := os.WriteFile(path, []byte("Fix the parser\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	// The outer run marks the environment; a commit made by anything it starts
+	// runs the hook again with the mark inherited
+	os.Setenv(inHookEnvVar, "")
+	if err := runHook(hookCmd, []string{path}); err != nil {
+		t.Fatal(err)
+	}
+	if os.Getenv(inHookEnvVar) == "" {
+		t.Fatalf("%s wasn't set for processes the hook starts", inHookEnvVar)
+	}
+	
+	inner := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	if err := os.WriteFile(inner, []byte("Commit from a chained hook\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runHook(hookCmd, []string{inner}); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(inner); string(content) != "Commit from a chained hook\n" {
+		t.Errorf("the re-entrant run changed the message:\n%s", content)
+	}
+	
+	// Even a message file it can't read is left alone
+	if err := runHook(hookCmd, []string{filepath.Join(t.TempDir(), "missing")}); err != nil {
+		t.Errorf("re-entrant run with a missing file: %v", err)
+	}
+}
+
+// TestHookHelperProcess is the hook in a process of its own, started by
+// TestConcurrentHooksShareDetection; on its own it does nothing
+func TestHookHelperProcess(t *testing.T) {
+	path := os.Getenv("INTERACTIVE_COMMIT_TEST_MESSAGE")
+	if path == "" {
+		return
+	}
+	if err := runHook(hookCmd, []string{path}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// slowMPD is an MPD server that takes a while to answer status and counts how
+// often it's asked, standing in for a slow detection
+func slowMPD(t *testing.T, delay time.Duration) (string, *atomic.Int32) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("can't listen:", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	
+	var detections atomic.Int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.Write([]byte("OK MPD 0.23.5\n"))
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					switch scanner.Text() {
+					case "status":
+						detections.Add(1)
+						time.Sleep(delay)
+						conn.Write([]byte("state: play\nelapsed: 90.0\nduration: 245.0\nOK\n"))
+					case "currentsong":
+						conn.Write([]byte("file: Kavinsky/Nightcall.flac\nTitle: Nightcall\nArtist: Kavinsky\nOK\n"))
+					default:
+						conn.Write([]byte("ACK [5@0] {} unknown command\n"))
+					}
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String(), &detections
+}
+
+func TestConcurrentHooksShareDetection(t *testing.T) {
+	host, detections := slowMPD(t, 500*time.Millisecond)
+	hookEnv(t, `{"detection_lock": true, "lock_timeout_ms": 5000, "process_scan": [], "mpd_host": "`+host+`"}`)
+	t.Setenv(audio.OverrideEnvVar, "") // Detect this time
+	
+	// Two terminals committing at once: each hook is its own process
+	var commits []*exec.Cmd
+	var paths []string
+	for range 2 {
+		path := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+		if err := os.WriteFile(path, []byte("Fix the parser\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		commit := exec.Command(os.Args[0], "-test.run=^TestHookHelperProcess$")
+		commit.Env = append(os.Environ(), "INTERACTIVE_COMMIT_TEST_MESSAGE="+path)
+		if err := commit.Start(); err != nil {
+			t.Fatal(err)
+		}
+		commits = append(commits, commit)
+		paths = append(paths, path)
+	}
+	for _, commit := range commits {
+		if err := commit.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	
+	if n := detections.Load(); n != 1 {
+		t.Errorf("detected %d times for two overlapping commits, want 1", n)
+	}
+	for _, path := range paths {
+		if content, _ := os.ReadFile(path); !tagged(string(content)) {
+			t.Errorf("a commit went without the track:\n%s", content)
+		}
+	}
+}
+
+// noTools is an audio.CommandRunner on a machine without any player tools
+type noTools struct{}
+
+func (noTools) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return nil, exec.ErrNotFound
+}
+
+func (noTools) LookPath(name string) (string, error) {
+	return "", exec.ErrNotFound
+}
+
+func TestHookSilenceText(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"empty default", `{"process_scan": []}`, "Fix the parser\n"},
+		{"configured", `{"process_scan": [], "silence_text": "🔇 Committed in silence"}`, "Fix the parser\n\n🔇 Committed in silence" + format.Marker + "\n"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hookEnv(t, tt.config)
+			// No player tools, so nothing is playing whatever runs on this machine
+			t.Setenv(audio.OverrideEnvVar, "")
+			saved := audio.Commands
+			audio.Commands = noTools{}
+			t.Cleanup(func() { audio.Commands = saved })
+			
+			got := runTestHook(t, "Fix the parser\n")
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			
+			// Amending doesn't stack a second silence line
+			if amended := runTestHook(t, got, "commit", "HEAD"); amended != tt.want {
+				t.Errorf("amended: got %q, want %q", amended, tt.want)
+			}
+			
+			// Nor is silence added to an empty message, which git would abort
+			if empty := runTestHook(t, "\n# Please enter the commit message\n"); strings.Contains(empty, "silence") {
+				t.Errorf("silence added to an empty message: %q", empty)
+			}
+		})
+	}
+}
+
+func TestAsyncDetectionFinishesUnderRepoConfig(t *testing.T) {
+	hookEnv(t, `{"process_scan": []}`)
+	previewing = true // Don't start a background refresh from the test binary
+	t.Cleanup(func() { previewing = false })
+	
+	// Saved raw by a commit in a repo without redact_explicit
+	raw := &audio.MediaInfo{Title: "Nightcall", Artist: "Kavinsky", Source: "Spotify", Type: "song", Explicit: true}
+	if err := cache.SaveResult(raw); err != nil {
+		t.Fatal(err)
+	}
+	
+	cfg := config.Default()
+	media, ok := asyncDetection(cfg)
+	if !ok || media == nil || media.Title != "Nightcall" {
+		t.Fatalf("asyncDetection() = %+v, %v; want the saved track", media, ok)
+	}
+	
+	cfg.RedactExplicit = true
+	media, ok = asyncDetection(cfg)
+	if !ok || media == nil || media.Title != audio.ExplicitTitle {
+		t.Errorf("asyncDetection() = %+v, %v; want it redacted under this repo's config", media, ok)
+	}
+}
+
+func TestLockWaiterFinishesSharedResult(t *testing.T) {
+	hookEnv(t, `{"process_scan": []}`)
+	saved := audio.Commands
+	audio.Commands = noTools{}
+	t.Cleanup(func() { audio.Commands = saved })
+	
+	// Another repo's commit holds the lock, then saves what it detected
+	release, err := cache.Lock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cache.SaveResult(&audio.MediaInfo{Title: "Nightcall", Artist: "Kavinsky", Source: "Spotify", Type: "song", Explicit: true})
+		release()
+	}()
+	
+	cfg := config.Default()
+	cfg.DetectionLock = true
+	cfg.LockTimeoutMS = 5000
+	cfg.ProcessScan = []string{}
+	cfg.RedactExplicit = true
+	media, err := detectForHook(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media == nil || media.Title != audio.ExplicitTitle {
+		t.Errorf("detectForHook() = %+v; want the shared track redacted under this repo's config", media)
+	}
+}
+
+// git runs git in the current directory and fails the test if it fails
+func git(t *testing.T, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, output)
+	}
+}
+
+func TestResolveMessagePath(t *testing.T) {
+	hookEnv(t, `{}`)
+	repo, _ := os.Getwd()
+	message := filepath.Join(repo, ".git", "COMMIT_EDITMSG")
+	if err := os.WriteFile(message, []byte("Fix the parser\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	// Run from the top level, git's own relative path works as is
+	if got := resolveMessagePath(".git/COMMIT_EDITMSG"); got != ".git/COMMIT_EDITMSG" {
+		t.Errorf("from the top level: %q", got)
+	}
+	if got := resolveMessagePath(message); got != message {
+		t.Errorf("absolute path: %q", got)
+	}
+	
+	// A core.hooksPath directory inside the repository, as some clients run hooks from
+	hooks := filepath.Join(repo, "tools", "hooks")
+	if err := os.MkdirAll(hooks, 0755); err != nil {
+		t.Fatal(err)
+	}
+	git(t, "config", "core.hooksPath", "tools/hooks")
+	chdir(t, hooks)
+	if got := resolveMessagePath(".git/COMMIT_EDITMSG"); !samePath(got, message) {
+		t.Errorf("from core.hooksPath: %q, want %q", got, message)
+	}
+	if got := resolveMessagePath(".git/MISSING"); got != ".git/MISSING" {
+		t.Errorf("missing file: %q, want the path git gave", got)
+	}
+	
+	// A linked worktree keeps its message in its own git directory
+	chdir(t, repo)
+	git(t, "commit", "-q", "--allow-empty", "-m", "Initial commit")
+	worktree := filepath.Join(t.TempDir(), "worktree")
+	git(t, "worktree", "add", "-q", worktree)
+	chdir(t, worktree)
+	output, err := exec.Command("git", "rev-parse", "--absolute-git-dir").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktreeMessage := filepath.Join(strings.TrimSpace(string(output)), "COMMIT_EDITMSG")
+	if err := os.WriteFile(worktreeMessage, []byte("Fix the parser\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(worktree, "sub")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, sub)
+	if got := resolveMessagePath(".git/COMMIT_EDITMSG"); !samePath(got, worktreeMessage) {
+		t.Errorf("from a worktree: %q, want %q", got, worktreeMessage)
+	}
+}
+
+func TestHookSuggestMode(t *testing.T) {
+	hookEnv(t, `{"mode": "suggest"}`)
+	
+	got := runTestHook(t, "\n# Please enter the commit message\n")
+	if !tagged(got) {
+		t.Fatalf("no suggestion:\n%s", got)
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if strings.Contains(line, "Nightcall") && !strings.HasPrefix(line, "# ") {
+			t.Errorf("the suggestion isn't a comment: %q", line)
+		}
+	}
+	
+	// Git keeps comments with -m, and an amended commit that has its line needs no suggestion
+	if got := runTestHook(t, "Fix the parser\n", "message"); got != "Fix the parser\n" {
+		t.Errorf("-m commit: %q", got)
+	}
+	amended := "Fix the parser\n\n🎵 Currently playing: \"Nightcall\" by Kavinsky (Spotify)\n"
+	if got := runTestHook(t, amended, "commit", "HEAD"); got != amended {
+		t.Errorf("amended tagged commit: %q", got)
+	}
+}
+
+func TestHookSmartPlacement(t *testing.T) {
+	hookEnv(t, `{"smart_placement": true}`)
+	
+	// A subject alone gets the plain line at the bottom
+	got := runTestHook(t, "Fix the parser\n")
+	if !tagged(got) || strings.Contains(got, format.SoundtrackKey+": ") {
+		t.Errorf("subject only:\n%s", got)
+	}
+	
+	// A body ending in trailers gets the line joined to them
+	got = runTestHook(t, "Fix the parser\n\nIt choked on tabs.\n\nSigned-off-by: Ada <ada@example.com>\n")
+	if !strings.Contains(got, "Signed-off-by: Ada <ada@example.com>\n"+format.SoundtrackKey+": ") {
+		t.Errorf("not joined to the trailers:\n%s", got)
+	}
+	
+	// commit --verbose: the line stays above git's comments and the diff, which git drops
+	diff := "# ------------------------ >8 ------------------------\ndiff --git a/x b/x\n+Signed-off-by: nobody\n"
+	got = runTestHook(t, "Fix the parser\n\nIt choked on tabs.\n\n# Please enter the commit message\n"+diff)
+	trailer := strings.Index(got, format.SoundtrackKey+": ")
+	if trailer < 0 || trailer > strings.Index(got, "# Please enter") {
+		t.Errorf("trailer not above git's comments:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "\n# Please enter the commit message\n"+diff) {
+		t.Errorf("comments or diff changed:\n%s", got)
+	}
+}
+
+func TestHookDedupConsecutive(t *testing.T) {
+	hookEnv(t, `{"history": true, "dedup_consecutive": true, "still_playing_text": "🎵 (still playing)"}`)
+	still := "Fix the parser\n\n🎵 (still playing)" + format.Marker + "\n"
+	
+	// Without history for this repository there's nothing to repeat
+	if got := runTestHook(t, "Fix the parser\n", "message"); !tagged(got) {
+		t.Fatalf("first commit not tagged:\n%s", got)
+	}
+	if got := runTestHook(t, "Fix the parser\n", "message"); got != still {
+		t.Errorf("same track: got %q, want %q", got, still)
+	}
+	
+	// Nor is the compact line added to an empty message, which git would abort
+	if got := runTestHook(t, "\n# Please enter the commit message\n"); strings.Contains(got, "still playing") || tagged(got) {
+		t.Errorf("empty message: %q", got)
+	}
+	
+	t.Setenv(audio.OverrideEnvVar, "Genesis by Justice")
+	if got := runTestHook(t, "Fix the parser\n", "message"); !strings.Contains(got, `"Genesis" by Justice`) {
+		t.Errorf("different track not tagged:\n%s", got)
+	}
+	
+	// Unreadable history can't tell, so the commit gets its line
+	path, err := history.Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("not json\n{\"title\": \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := runTestHook(t, "Fix the parser\n", "message"); !strings.Contains(got, `"Genesis" by Justice`) {
+		t.Errorf("corrupt history: not tagged:\n%s", got)
+	}
+	
+	// Without still_playing_text a repeat is left out
+	hookEnv(t, `{"history": true, "dedup_consecutive": true}`)
+	runTestHook(t, "Fix the parser\n", "message")
+	if got := runTestHook(t, "Fix the parser\n", "message"); got != "Fix the parser\n" {
+		t.Errorf("same track without still_playing_text: %q", got)
+	}
+	
+	// Nor does dedup work without history to compare against
+	hookEnv(t, `{"dedup_consecutive": true, "still_playing_text": "🎵 (still playing)"}`)
+	runTestHook(t, "Fix the parser\n", "message")
+	if got := runTestHook(t, "Fix the parser\n", "message"); !tagged(got) {
+		t.Errorf("history off: second commit not tagged:\n%s", got)
+	}
+}
+
+func TestHookCommandExitContract(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		name := "default"
+		if strict {
+			name = "strict"
+		}
+		t.Run(name, func(t *testing.T) {
+			if strict {
+				hookEnv(t, `{"strict": true}`)
+			} else {
+				hookEnv(t, `{}`)
+			}
+			
+			// A message file that can't be read
+			os.Setenv(inHookEnvVar, "")
+			err := runHookCommand(hookCmd, []string{filepath.Join(t.TempDir(), "missing")})
+			if (err != nil) != strict {
+				t.Errorf("unreadable message: err = %v, want an error only when strict", err)
+			}
+			
+			// A crash while formatting the line
+			saved := format.Now
+			format.Now = func() time.Time { panic("clock stopped") }
+			t.Cleanup(func() { format.Now = saved })
+			path := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+			if err := os.WriteFile(path, []byte("Fix the parser\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			
+			os.Setenv(inHookEnvVar, "")
+			panicked := true
+			func() {
+				defer func() {
+					if recover() == nil {
+						panicked = false
+					}
+				}()
+				err = runHookCommand(hookCmd, []string{path})
+			}()
+			if strict && !panicked {
+				t.Error("strict: the crash was swallowed, want it to fail the commit")
+			}
+			if !strict && (panicked || err != nil) {
+				t.Errorf("the crash escaped the hook (panicked %v, err %v)", panicked, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pixare40/interactive-commit/internal/gitutil"
+	"github.com/spf13/cobra"
+)
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Inspect the places Interactive-Commit hooks are installed",
+}
+
+var hooksListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every prepare-commit-msg hook that could apply here, and which one fires",
+	Long: `List the prepare-commit-msg hooks that could apply to this repository:
+
+  global       the directory in your global core.hooksPath
+  hooksPath    a core.hooksPath set in this repository's own config
+  repository   the repository's .git/hooks directory
+  .githooks    a .githooks directory committed to the repository
+
+Each shows whether it is an Interactive-Commit hook, the executable and
+release it was installed with, and whether git will run it. git runs hooks
+from core.hooksPath when it is set (the repository's value wins over the
+global one) and from .git/hooks otherwise - only one of them fires.`,
+	Args: cobra.NoArgs,
+	RunE: runHooksList,
+}
+
+var hooksListJSON bool
+
+func init() {
+	hooksCmd.AddCommand(hooksListCmd)
+	hooksListCmd.Flags().BoolVar(&hooksListJSON, "json", false, "Output as JSON")
+}
+
+// hookLocation is one place a prepare-commit-msg hook may live
+type hookLocation struct {
+	Location string `json:"location"` // "global", "hooksPath", "repository" or ".githooks"
+	hookStatus
+	Fires bool   `json:"fires"`          // git runs this hook for the current repository
+	Note  string `json:"note,omitempty"` // Why it doesn't fire, or how it still runs
+}
+
+func runHooksList(cmd *cobra.Command, args []string) error {
+	execPath, _ := os.Executable()
+	locations := hookLocations()
+	
+	active := activeHookPath()
+	var activeScript string
+	if content, err := os.ReadFile(active); err == nil {
+		activeScript = string(content)
+	}
+	
+	var list []*hookLocation
+	seen := map[string]bool{}
+	for _, location := range locations {
+		path := filepath.Join(location.dir, "prepare-commit-msg")
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+	
+		hook := &hookLocation{Location: location.name, hookStatus: *inspectHook(path, execPath)}
+		hook.Fires = path == active
+		if !hook.Fires {
+			hook.Note = skippedNote(hook, active, activeScript)
+		}
+		list = append(list, hook)
+	}
+	
+	// A core.hooksPath from somewhere else, such as the system config
+	if active != "" && !seen[active] {
+		hook := &hookLocation{Location: "core.hooksPath", hookStatus: *inspectHook(active, execPath), Fires: true}
+		list = append(list, hook)
+	}
+	
+	if hooksListJSON {
+		printJSON(list)
+		return nil
+	}
+	
+	printHookLocations(list)
+	return nil
+}
+
+// hookDir is a hooks directory worth looking in
+type hookDir struct {
+	name string
+	dir  string
+}
+
+// hookLocations lists the hooks directories that could apply here: the
+// global core.hooksPath, and inside a repository its own core.hooksPath,
+// .git/hooks and .githooks
+func hookLocations() []hookDir {
+	var dirs []hookDir
+	if globalDir, err := configuredGlobalHooksDir(); err == nil && globalDir != "" {
+		dirs = append(dirs, hookDir{"global", globalDir})
+	}
+	
+	if !gitutil.InsideWorkTree() {
+		return dirs
+	}
+	topLevel, _ := gitutil.RevParse("--show-toplevel")
+	
+	// git reads a relative core.hooksPath from the top of the working tree
+	if hooksPath, err := gitutil.Output("config", "--local", "--path", "core.hooksPath"); err == nil && hooksPath != "" {
+		if !filepath.IsAbs(hooksPath) {
+			hooksPath = filepath.Join(topLevel, hooksPath)
+		}
+		dirs = append(dirs, hookDir{"hooksPath", hooksPath})
+	}
+	
+	if commonDir, err := gitutil.RevParse("--git-common-dir"); err == nil {
+		hooksDir, _ := filepath.Abs(filepath.Join(commonDir, "hooks"))
+		dirs = append(dirs, hookDir{"repository", hooksDir})
+	}
+	
+	if topLevel != "" {
+		githooks := filepath.Join(topLevel, ".githooks")
+		if info, err := os.Stat(githooks); err == nil && info.IsDir() {
+			dirs = append(dirs, hookDir{".githooks", githooks})
+		}
+	}
+	return dirs
+}
+
+// activeHookPath is the prepare-commit-msg git runs here, or "" when none
+// applies. Outside a repository only the global core.hooksPath can.
+func activeHookPath() string {
+	if gitutil.InsideWorkTree() {
+		if dir, err := activeHooksDir(); err == nil {
+			return filepath.Join(dir, "prepare-commit-msg")
+		}
+		return ""
+	}
+	if globalDir, err := configuredGlobalHooksDir(); err == nil && globalDir != "" {
+		return filepath.Join(globalDir, "prepare-commit-msg")
+	}
+	return ""
+}
+
+// skippedNote explains why git won't run hook, or how it runs anyway
+func skippedNote(hook *hookLocation, active, activeScript string) string {
+	activeDir := filepath.Dir(active)
+	switch {
+	case hook.Location == "repository" && hook.Foreign && strings.Contains(activeScript, localChainComment):
+		return "git skips it while core.hooksPath is set, but the active hook runs it first"
+	case hook.Location == ".githooks":
+		return "not used unless this repository sets it with 'git config core.hooksPath .githooks'"
+	case active == "":
+		return "git isn't looking for hooks here"
+	case hook.Location == "global":
+		return fmt.Sprintf("this repository's core.hooksPath overrides it; git runs hooks from %s", activeDir)
+	}
+	return fmt.Sprintf("git runs hooks from %s instead", activeDir)
+}
+
+func printHookLocations(list []*hookLocation) {
+	if len(list) == 0 {
+		fmt.Println("❌ No hooks directories to look in - run this inside a repository")
+		return
+	}
+	
+	var firing *hookLocation
+	for _, hook := range list {
+		marker := "  "
+		if hook.Fires {
+			marker = "▶ "
+			firing = hook
+		}
+		fmt.Printf("%s%-10s  %s\n", marker, hook.Location, hook.Path)
+	
+		switch {
+		case hook.Installed:
+			installed := "Interactive-Commit " + hook.Kind
+			if hook.Version != "" {
+				installed += ", version " + hook.Version
+			}
+			fmt.Printf("   %s\n", installed)
+			fmt.Printf("   Runs: %s\n", hook.ExecPath)
+			if hook.Chained {
+				fmt.Println("   Runs the preserved hook first")
+			}
+			if hook.Stale {
+				fmt.Println("   ⚠️  Points at a different executable - run 'interactive-commit update'")
+			}
+		case hook.Foreign:
+			fmt.Println("   Another tool's hook")
+		default:
+			fmt.Println("   No hook")
+		}
+		if hook.Note != "" {
+			fmt.Printf("   ↳ %s\n", hook.Note)
+		}
+	}
+	
+	fmt.Println()
+	switch {
+	case firing == nil:
+		fmt.Println("❌ git runs no prepare-commit-msg hook here - run 'interactive-commit install'")
+	case firing.Installed:
+		fmt.Printf("✅ git runs the %s Interactive-Commit hook here\n", firing.Location)
+	case firing.Foreign:
+		fmt.Printf("⚠️  git runs another tool's %s hook here, not Interactive-Commit\n", firing.Location)
+	default:
+		fmt.Printf("❌ git looks in %s but finds no prepare-commit-msg hook - run 'interactive-commit install'\n", filepath.Dir(firing.Path))
+	}
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pixare40/interactive-commit/internal/gitutil"
+)
+
+// hookMarker appears in every hook script we write, so we can recognize our own hooks
+const hookMarker = "# Interactive-Commit"
+
+// hookVersionLine records which release wrote a hook script
+var hookVersionLine = regexp.MustCompile(`(?m)^# Installed by interactive-commit (\S+)$`)
+
+// hookKindLine is the marker line, naming the kind of hook
+var hookKindLine = regexp.MustCompile(`(?m)^` + hookMarker + ` (.+)$`)
+
+// chainedHookSuffix names the backup of a pre-existing hook that our hook runs first
+const chainedHookSuffix = ".pre-interactive-commit"
+
+// isOurHook reports whether the hook at path was written by interactive-commit
+func isOurHook(path string) bool {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(content), hookMarker)
+}
+
+// localChainComment introduces the part of a global hook that runs the repository's own hook
+const localChainComment = "# Run the repository's own hook, which git skips while core.hooksPath is set"
+
+// buildHookScript renders the prepare-commit-msg script. When chained, the
+// previously installed hook runs first and a failure from it aborts the commit
+// exactly as it would have without us. With chainLocal (global hooks only) the
+// repository's own prepare-commit-msg runs first too, unless it is ours.
+func buildHookScript(execPath, kind string, chained, chainLocal bool) string {
+	var sb strings.Builder
+	
+	fmt.Fprintf(&sb, "#!/bin/sh\n%s %s\n# Automatically appends currently playing audio to commit messages\n# Installed by interactive-commit %s\n\n", hookMarker, kind, version)
+	
+	if chained {
+		sb.WriteString("# Run the hook that was installed before Interactive-Commit\n")
+		fmt.Fprintf(&sb, "\"$(dirname \"$0\")/prepare-commit-msg%s\" \"$@\" || exit $?\n\n", chainedHookSuffix)
+	}
+	
+	if chainLocal {
+		sb.WriteString(localChainComment + "\n")
+		sb.WriteString("localHook=\"$(git rev-parse --git-common-dir)/hooks/prepare-commit-msg\"\n")
+		fmt.Fprintf(&sb, "if [ -x \"$localHook\" ] && ! grep -q \"%s\" \"$localHook\"; then\n", hookMarker)
+		sb.WriteString("\t\"$localHook\" \"$@\" || exit $?\nfi\n\n")
+	}
+	
+	fmt.Fprintf(&sb, "\"%s\" hook \"$1\" \"$2\" \"$3\"\n", hookExecPath(execPath))
+	return sb.String()
+}
+
+// repoHooks lists the hooks in the current repository's own hooks directory,
+// which git ignores once a global core.hooksPath is set. Sample hooks don't count.
+func repoHooks() []string {
+	commonDir, err := gitutil.RevParse("--git-common-dir")
+	if err != nil {
+		return nil
+	}
+	
+	hooksDir := filepath.Join(commonDir, "hooks")
+	entries, err := os.ReadDir(hooksDir)
+	if err != nil {
+		return nil
+	}
+	
+	var hooks []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasSuffix(name, ".sample") || isOurHook(filepath.Join(hooksDir, name)) {
+			continue
+		}
+		hooks = append(hooks, name)
+	}
+	return hooks
+}
+
+// prepareHookPath decides what to do about an existing hook at hookPath.
+// Our own hooks are updated in place (keeping any chain). Foreign hooks are
+// either preserved and chained (with --prepend-existing) or, after confirmation,
+// overwritten. It returns whether the new script should chain to a backup and
+// whether installation should proceed at all.
+func prepareHookPath(hookPath, label string) (chained bool, proceed bool, err error) {
+	backupPath := hookPath + chainedHookSuffix
+	_, backupErr := os.Stat(backupPath)
+	hasBackup := backupErr == nil
+	
+	if _, err := os.Stat(hookPath); os.IsNotExist(err) {
+		return hasBackup, true, nil
+	}
+	
+	if isOurHook(hookPath) {
+		fmt.Fprintf(humanOut, "🔄 Updating existing Interactive-Commit %s at %s\n", label, hookPath)
+		return hasBackup, true, nil
+	}
+	
+	if installPrependExisting {
+		if hasBackup {
+			return false, false, fmt.Errorf("cannot preserve %s: %s already exists", hookPath, backupPath)
+		}
+		if err := os.Rename(hookPath, backupPath); err != nil {
+			return false, false, fmt.Errorf("failed to preserve existing hook: %w", err)
+		}
+		fmt.Fprintf(humanOut, "🔗 Preserved existing %s as %s - it will run before ours\n", label, backupPath)
+		return true, true, nil
+	}
+	
+	if installForce {
+		fmt.Fprintf(humanOut, "⚠️  Overwriting the existing %s at %s\n", label, hookPath)
+		return hasBackup, true, nil
+	}
+	
+	// --json is for scripts, which can't see or answer the prompt
+	if installJSON {
+		return false, false, fmt.Errorf("%s already exists at %s, pass --force to overwrite it or --prepend-existing to keep it and run it before ours", label, hookPath)
+	}
+	
+	fmt.Fprintf(humanOut, "⚠️  %s already exists at %s\n", strings.ToUpper(label[:1])+label[1:], hookPath)
+	fmt.Fprintln(humanOut, "   (use --prepend-existing to keep it and run it before ours, or --force to overwrite it)")
+	fmt.Fprint(humanOut, "Do you want to overwrite it? (y/N): ")
+	var response string
+	fmt.Scanln(&response)
+	if response != "y" && response != "Y" {
+		fmt.Fprintln(humanOut, "Installation cancelled.")
+		return false, false, nil
+	}
+	return hasBackup, true, nil
+}
@@ -1,13 +1,17 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
 
+	"github.com/pixare40/interactive-commit/internal/config"
+	"github.com/pixare40/interactive-commit/internal/gitutil"
 	"github.com/spf13/cobra"
 )
 
@@ -19,170 +23,374 @@ var installCmd = &cobra.Command{
 Supports multiple installation modes:
   --local  : Install for current repository only (default)
   --global : Install for all repositories globally
-  --team   : Install with team configuration`,
+  --team   : Install with team configuration
+
+An existing prepare-commit-msg hook from another tool can be kept with
+--prepend-existing: it is renamed to prepare-commit-msg.pre-interactive-commit
+and run before ours on every commit.
+
+A global install sets core.hooksPath, which makes git ignore every repository's
+own .git/hooks. Only prepare-commit-msg is written to the hooks directory;
+anything else there, including .sample hooks, is left alone. With --chain-local
+the global hook also runs the repository's own prepare-commit-msg.
+
+--with-validation adds a commit-msg hook that rejects a commit whose
+soundtrack line was edited into something the configured format can't
+produce. With "require_soundtrack": true it also rejects commits without one.`,
 	RunE: runInstall,
 }
 
 var (
-	installLocal  bool
-	installGlobal bool
-	installTeam   bool
+	installLocal           bool
+	installGlobal          bool
+	installTeam            bool
+	installPrependExisting bool
+	installForce           bool
+	installChainLocal      bool
+	installJSON            bool
+	installPreset          string
+	installWithValidation  bool
 )
 
+// humanOut receives install progress messages; --json silences them
+var humanOut io.Writer = os.Stdout
+
+// installResult describes what install did, for --json
+type installResult struct {
+	Mode       string `json:"mode"`      // "local" or "global"
+	HookPath   string `json:"hook_path"` // The prepare-commit-msg hook written
+	Action     string `json:"action"`    // "created", "updated" or "skipped"
+	Chained    bool   `json:"chained"`   // Runs a preserved hook first
+	Preset     string `json:"preset,omitempty"` // The preset made active
+	GitVersion string `json:"git_version,omitempty"`
+}
+
 func init() {
 	installCmd.Flags().BoolVar(&installLocal, "local", true, "Install for current repository")
 	installCmd.Flags().BoolVar(&installGlobal, "global", false, "Install globally for all repositories")
 	installCmd.Flags().BoolVar(&installTeam, "team", false, "Install with team configuration")
+	installCmd.Flags().BoolVar(&installPrependExisting, "prepend-existing", false, "Keep an existing prepare-commit-msg hook and run it before ours")
+	installCmd.Flags().BoolVar(&installForce, "force", false, "Overwrite an existing prepare-commit-msg hook without asking")
+	installCmd.Flags().BoolVar(&installChainLocal, "chain-local", false, "With --global, also run each repository's own prepare-commit-msg hook")
+	installCmd.Flags().BoolVar(&installJSON, "json", false, "Output the result as JSON")
+	installCmd.Flags().BoolVar(&installWithValidation, "with-validation", false, "Also install a commit-msg hook that rejects malformed soundtrack lines")
+	installCmd.Flags().StringVar(&installPreset, "preset", "", "Make a preset from your config the active one (for this repository, or globally with --global)")
 }
 
 func runInstall(cmd *cobra.Command, args []string) error {
+	if installJSON {
+		// Errors are reported in the JSON object instead of as text
+		humanOut = io.Discard
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+	}
+	
+	var result *installResult
+	var err error
+	
+	// Select the preset first, so an unknown name fails before any hook is touched
+	if installPreset != "" {
+		err = installSelectPreset()
+	}
+	
+	switch {
+	case err != nil:
+		// Leave the hooks alone
+	case installGlobal:
+		fmt.Fprintln(humanOut, "🌍 Installing globally...")
+		result, err = installGlobalHook()
+	case installTeam:
+		fmt.Fprintln(humanOut, "👥 Installing for team...")
+		err = fmt.Errorf("team installation not yet implemented")
+	default:
+		fmt.Fprintln(humanOut, "📁 Installing locally...")
+		result, err = installLocalHook()
+	}
+	
+	if installJSON {
+		if err != nil {
+			printJSON(map[string]string{"error": err.Error()})
+			return err
+		}
+		result.Preset = installPreset
+		result.GitVersion = gitVersion()
+		printJSON(result)
+	}
+	return err
+}
+
+// installSelectPreset saves --preset to the config the install applies to
+func installSelectPreset() error {
+	pathFunc := config.RepoPath
 	if installGlobal {
-		fmt.Println("🌍 Installing globally...")
-		return installGlobalHook()
+		pathFunc = config.GlobalPath
 	}
 	
-	if installTeam {
-		fmt.Println("👥 Installing for team...")
-		return fmt.Errorf("team installation not yet implemented")
+	path, err := pathFunc()
+	if err != nil {
+		return err
 	}
 	
-	fmt.Println("📁 Installing locally...")
-	return installLocalHook()
+	if err := usePreset(path, installPreset); err != nil {
+		return err
+	}
+	fmt.Fprintf(humanOut, "🎨 Using preset %q\n", installPreset)
+	return nil
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+// hookAction names what writing a hook at hookPath will do
+func hookAction(hookPath string) string {
+	if _, err := os.Stat(hookPath); err == nil {
+		return "updated"
+	}
+	return "created"
 }
 
-func installLocalHook() error {
-	// Check if we're in a git repository
-	if _, err := os.Stat(".git"); os.IsNotExist(err) {
-		return fmt.Errorf("not in a git repository - please run this command from the root of a git repository")
+func installLocalHook() (*installResult, error) {
+	// Check if we're in a git working tree (worktrees and submodules included)
+	if !gitutil.InsideWorkTree() {
+		return nil, fmt.Errorf("not in a git repository - please run this command from inside a git working tree")
+	}
+	
+	// Let git resolve the hooks directory rather than assuming .git/hooks
+	hooksDir, err := getLocalHooksDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine hooks directory: %w", err)
+	}
+	
+	// With a global core.hooksPath git skips the repository's hooks, and
+	// installing into the shared directory would change every repository
+	if activeDir, err := activeHooksDir(); err == nil && !samePath(activeDir, hooksDir) {
+		return nil, fmt.Errorf("core.hooksPath is set globally to %s, so git runs every repository's hooks from there and would skip one installed in %s.\nRun 'interactive-commit install --global' to install into the shared directory", activeDir, hooksDir)
 	}
 	
 	// Create hooks directory if it doesn't exist
-	hooksDir := ".git/hooks"
 	if err := os.MkdirAll(hooksDir, 0755); err != nil {
-		return fmt.Errorf("failed to create hooks directory: %w", err)
+		return nil, fmt.Errorf("failed to create hooks directory: %w", err)
 	}
 	
 	// Get the path to the current executable
 	execPath, err := os.Executable()
 	if err != nil {
-		return fmt.Errorf("failed to get executable path: %w", err)
+		return nil, fmt.Errorf("failed to get executable path: %w", err)
 	}
 	
 	// Create the prepare-commit-msg hook
 	hookPath := filepath.Join(hooksDir, "prepare-commit-msg")
 	
-	// Check if hook already exists
-	if _, err := os.Stat(hookPath); err == nil {
-		fmt.Printf("⚠️  Hook already exists at %s\n", hookPath)
-		fmt.Print("Do you want to overwrite it? (y/N): ")
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
-			fmt.Println("Installation cancelled.")
-			return nil
-		}
+	// Deal with any hook that's already there
+	action := hookAction(hookPath)
+	chained, proceed, err := prepareHookPath(hookPath, "hook")
+	if err != nil {
+		return nil, err
+	}
+	if !proceed {
+		return &installResult{Mode: "local", HookPath: hookPath, Action: "skipped"}, nil
 	}
 	
+	// Make sure git will actually be able to run the script
+	checkHookShell()
+	
 	// Create hook script
-	hookScript := fmt.Sprintf(`#!/bin/sh
-# Interactive-Commit git hook
-# Automatically appends currently playing audio to commit messages
-
-"%s" hook "$1" "$2" "$3"
-`, execPath)
+	hookScript := buildHookScript(execPath, "git hook", chained, false)
 	
 	// Write hook file
 	if err := os.WriteFile(hookPath, []byte(hookScript), 0755); err != nil {
-		return fmt.Errorf("failed to write hook file: %w", err)
+		return nil, fmt.Errorf("failed to write hook file: %w", err)
 	}
 	
-	fmt.Printf("✅ Successfully installed Interactive-Commit hook at %s\n", hookPath)
-	fmt.Println("🎵 Your commits will now include currently playing audio!")
-	fmt.Println("\nTo test it, try making a commit while playing music:")
-	fmt.Println("  git add . && git commit -m \"feat: add awesome feature\"")
+	installPostCommitHook(hooksDir, execPath, "git hook")
+	if installWithValidation {
+		installCommitMsgHook(hooksDir, execPath, "git hook")
+	}
 	
-	return nil
+	fmt.Fprintf(humanOut, "✅ Successfully installed Interactive-Commit hook at %s\n", hookPath)
+	fmt.Fprintln(humanOut, "🎵 Your commits will now include currently playing audio!")
+	fmt.Fprintln(humanOut, "\nTo test it, try making a commit while playing music:")
+	fmt.Fprintln(humanOut, "  git add . && git commit -m \"feat: add awesome feature\"")
+	
+	return &installResult{Mode: "local", HookPath: hookPath, Action: action, Chained: chained}, nil
 }
 
-func installGlobalHook() error {
+func installGlobalHook() (*installResult, error) {
 	// Get global hooks directory
 	hooksDir, err := getGlobalHooksDir()
 	if err != nil {
-		return fmt.Errorf("failed to determine global hooks directory: %w", err)
+		return nil, fmt.Errorf("failed to determine global hooks directory: %w", err)
 	}
 	
 	// Create global hooks directory if it doesn't exist
 	if err := os.MkdirAll(hooksDir, 0755); err != nil {
-		return fmt.Errorf("failed to create global hooks directory: %w", err)
+		return nil, fmt.Errorf("failed to create global hooks directory: %w", err)
 	}
 	
 	// Get the path to the current executable
 	execPath, err := os.Executable()
 	if err != nil {
-		return fmt.Errorf("failed to get executable path: %w", err)
+		return nil, fmt.Errorf("failed to get executable path: %w", err)
 	}
 	
 	// Create the prepare-commit-msg hook
 	hookPath := filepath.Join(hooksDir, "prepare-commit-msg")
 	
-	// Check if hook already exists
-	if _, err := os.Stat(hookPath); err == nil {
-		fmt.Printf("⚠️  Global hook already exists at %s\n", hookPath)
-		fmt.Print("Do you want to overwrite it? (y/N): ")
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
-			fmt.Println("Installation cancelled.")
-			return nil
-		}
+	// Global hooks are shared by every repository - never clobber one silently
+	action := hookAction(hookPath)
+	chained, proceed, err := prepareHookPath(hookPath, "global hook")
+	if err != nil {
+		return nil, err
+	}
+	if !proceed {
+		return &installResult{Mode: "global", HookPath: hookPath, Action: "skipped"}, nil
 	}
 	
+	// Make sure git will actually be able to run the script
+	checkHookShell()
+	
 	// Create hook script
-	hookScript := fmt.Sprintf(`#!/bin/sh
-# Interactive-Commit global git hook
-# Automatically appends currently playing audio to commit messages
-
-"%s" hook "$1" "$2" "$3"
-`, execPath)
+	hookScript := buildHookScript(execPath, "global git hook", chained, installChainLocal)
 	
 	// Write hook file
 	if err := os.WriteFile(hookPath, []byte(hookScript), 0755); err != nil {
-		return fmt.Errorf("failed to write global hook file: %w", err)
+		return nil, fmt.Errorf("failed to write global hook file: %w", err)
+	}
+	
+	installPostCommitHook(hooksDir, execPath, "global git hook")
+	if installWithValidation {
+		installCommitMsgHook(hooksDir, execPath, "global git hook")
+	}
+	
+	// A global core.hooksPath silently disables the repository's own hooks
+	if hooks := repoHooks(); len(hooks) > 0 {
+		fmt.Fprintf(humanOut, "⚠️  This repository has its own hooks that git will ignore once core.hooksPath is set: %s\n", strings.Join(hooks, ", "))
+		if !installChainLocal {
+			fmt.Fprintln(humanOut, "   Re-run with --chain-local to keep running the repository's prepare-commit-msg,")
+			fmt.Fprintln(humanOut, "   or, in repositories that rely on their own hooks, run 'git config core.hooksPath .git/hooks'")
+			fmt.Fprintln(humanOut, "   and then 'interactive-commit install --local' there.")
+		} else {
+			fmt.Fprintln(humanOut, "   Its prepare-commit-msg will still run (--chain-local); other hooks will not.")
+		}
 	}
 	
 	// Configure Git to use the global hooks directory
 	if err := configureGlobalHooksPath(hooksDir); err != nil {
-		return fmt.Errorf("failed to configure global hooks path: %w", err)
+		return nil, fmt.Errorf("failed to configure global hooks path: %w", err)
 	}
 	
-	fmt.Printf("✅ Successfully installed Interactive-Commit global hook at %s\n", hookPath)
-	fmt.Printf("🔧 Configured Git to use global hooks directory: %s\n", hooksDir)
-	fmt.Println("🎵 All your repositories will now include currently playing audio in commits!")
-	fmt.Println("\nTo test it, try making a commit in any repository while playing music:")
-	fmt.Println("  cd /path/to/any/git/repo && git add . && git commit -m \"feat: add awesome feature\"")
-	fmt.Println("\nTo disable global hooks, run:")
-	fmt.Println("  git config --global --unset core.hooksPath")
+	fmt.Fprintf(humanOut, "✅ Successfully installed Interactive-Commit global hook at %s\n", hookPath)
+	fmt.Fprintf(humanOut, "🔧 Configured Git to use global hooks directory: %s\n", hooksDir)
+	fmt.Fprintln(humanOut, "🎵 All your repositories will now include currently playing audio in commits!")
+	fmt.Fprintln(humanOut, "\nTo test it, try making a commit in any repository while playing music:")
+	fmt.Fprintln(humanOut, "  cd /path/to/any/git/repo && git add . && git commit -m \"feat: add awesome feature\"")
+	fmt.Fprintln(humanOut, "\nTo disable global hooks, run:")
+	fmt.Fprintln(humanOut, "  git config --global --unset core.hooksPath")
 	
-	return nil
+	return &installResult{Mode: "global", HookPath: hookPath, Action: action, Chained: chained}, nil
 }
 
-func getGlobalHooksDir() (string, error) {
-	// Check if user already has a global hooks path configured
-	cmd := exec.Command("git", "config", "--global", "core.hooksPath")
-	if output, err := cmd.Output(); err == nil {
-		existingPath := strings.TrimSpace(string(output))
-		if existingPath != "" {
-			// Expand ~ to home directory if needed
-			if strings.HasPrefix(existingPath, "~/") {
-				homeDir, err := os.UserHomeDir()
-				if err != nil {
-					return "", err
-				}
-				existingPath = filepath.Join(homeDir, existingPath[2:])
+// hookExecPath converts the executable path into a form sh understands.
+// On Windows, backslashes would be treated as escapes inside the hook script.
+func hookExecPath(execPath string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.ToSlash(execPath)
+	}
+	return execPath
+}
+
+// checkHookShell warns when git on native Windows has no sh to run hooks with.
+// Git for Windows runs hooks through its bundled sh; without it the hook never fires.
+func checkHookShell() {
+	if runtime.GOOS != "windows" {
+		return
+	}
+	
+	if _, err := exec.LookPath("sh"); err == nil {
+		return
+	}
+	
+	// Git for Windows ships sh next to git itself, even when it isn't on PATH
+	if execPath, err := gitutil.Output("--exec-path"); err == nil {
+		gitRoot := filepath.Join(execPath, "..", "..", "..")
+		for _, candidate := range []string{
+			filepath.Join(gitRoot, "bin", "sh.exe"),
+			filepath.Join(gitRoot, "usr", "bin", "sh.exe"),
+		} {
+			if _, err := os.Stat(candidate); err == nil {
+				return
+			}
+		}
+	}
+	
+	fmt.Fprintln(humanOut, "⚠️  WARNING: no 'sh' found for git to run hooks with.")
+	fmt.Fprintln(humanOut, "   The hook will be installed but git will silently skip it.")
+	fmt.Fprintln(humanOut, "   Install Git for Windows (https://gitforwindows.org), which bundles the")
+	fmt.Fprintln(humanOut, "   bash/sh that git uses to run hooks, then re-run this install.")
+}
+
+// getLocalHooksDir returns the repository's own hooks directory: a
+// core.hooksPath set in its config, or else the hooks directory in its git
+// directory. A global core.hooksPath is shared by every repository, so it
+// never counts as local.
+func getLocalHooksDir() (string, error) {
+	// git reads a relative core.hooksPath from the top of the working tree
+	if hooksPath, err := gitutil.Output("config", "--local", "--path", "core.hooksPath"); err == nil && hooksPath != "" {
+		if !filepath.IsAbs(hooksPath) {
+			topLevel, err := gitutil.RevParse("--show-toplevel")
+			if err != nil {
+				return "", err
 			}
-			fmt.Printf("📁 Using existing global hooks directory: %s\n", existingPath)
-			return existingPath, nil
+			hooksPath = filepath.Join(topLevel, hooksPath)
 		}
+		return hooksPath, nil
+	}
+	
+	// --git-common-dir follows .git files, so worktrees and submodules resolve
+	// to the repository they belong to
+	commonDir, err := gitutil.RevParse("--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(filepath.Join(commonDir, "hooks"))
+}
+
+// activeHooksDir returns the directory git runs this repository's hooks
+// from, which is the global core.hooksPath when only that is set
+func activeHooksDir() (string, error) {
+	hooksDir, err := gitutil.RevParse("--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	
+	return filepath.Abs(hooksDir)
+}
+
+// samePath reports whether a and b name the same directory, following symlinks
+// where they exist
+func samePath(a, b string) bool {
+	if resolved, err := filepath.EvalSymlinks(a); err == nil {
+		a = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(b); err == nil {
+		b = resolved
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
+
+func getGlobalHooksDir() (string, error) {
+	// Check if user already has a global hooks path configured
+	existingPath, err := configuredGlobalHooksDir()
+	if err != nil {
+		return "", err
+	}
+	if existingPath != "" {
+		fmt.Fprintf(humanOut, "📁 Using existing global hooks directory: %s\n", existingPath)
+		return existingPath, nil
 	}
 	
 	// Create our own global hooks directory
@@ -206,8 +414,26 @@ func getGlobalHooksDir() (string, error) {
 	return filepath.Join(configDir, "git", "hooks"), nil
 }
 
+// configuredGlobalHooksDir returns the global core.hooksPath with ~ expanded,
+// or "" when none is configured
+func configuredGlobalHooksDir() (string, error) {
+	existingPath, ok := gitutil.GlobalConfig("core.hooksPath")
+	if !ok {
+		return "", nil
+	}
+	
+	// Expand ~ to home directory if needed
+	if strings.HasPrefix(existingPath, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		existingPath = filepath.Join(homeDir, existingPath[2:])
+	}
+	return existingPath, nil
+}
+
 func configureGlobalHooksPath(hooksDir string) error {
 	// Always use absolute path - Git doesn't always expand ~ correctly
-	cmd := exec.Command("git", "config", "--global", "core.hooksPath", hooksDir)
-	return cmd.Run()
+	return gitutil.Run("config", "--global", "core.hooksPath", hooksDir)
 } 
\ No newline at end of file
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+	"github.com/pixare40/interactive-commit/internal/config"
+	"github.com/pixare40/interactive-commit/internal/format"
+	"github.com/pixare40/interactive-commit/internal/gitutil"
+	"github.com/pixare40/interactive-commit/internal/message"
+	"github.com/spf13/cobra"
+)
+
+// notesRef holds the tracks attached to commits with store_as "note"
+const notesRef = "refs/notes/now-playing"
+
+// pendingNoteFile is where prepare-commit-msg leaves the note for post-commit,
+// inside the worktree's git directory
+const pendingNoteFile = "interactive-commit-note"
+
+// stalePendingNote is how long a pending note waits for its commit. Older ones
+// belong to a commit that was abandoned in the editor.
+const stalePendingNote = time.Hour
+
+var notesCmd = &cobra.Command{
+	Use:   "notes",
+	Short: "Read tracks stored as git notes",
+	Long: `With "store_as": "note" the track is attached to each commit as a git note
+under ` + notesRef + ` instead of being added to the message.
+
+Show notes in git log with:
+  git config notes.displayRef ` + notesRef + `
+Notes aren't pushed by default; share them with:
+  git push origin ` + notesRef,
+}
+
+var notesShowCmd = &cobra.Command{
+	Use:   "show [<commit>]",
+	Short: "Show the track attached to a commit (HEAD by default)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runNotesShow,
+}
+
+var postCommitCmd = &cobra.Command{
+	Use:    "post-commit",
+	Short:  "Git post-commit hook handler (internal use)",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE:   runPostCommit,
+}
+
+func init() {
+	notesCmd.AddCommand(notesShowCmd)
+}
+
+func runNotesShow(cmd *cobra.Command, args []string) error {
+	commit := "HEAD"
+	if len(args) > 0 {
+		commit = args[0]
+	}
+	
+	output, err := exec.Command("git", "notes", "--ref", notesRef, "show", commit).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("no track noted for %s: %s", commit, strings.TrimSpace(string(output)))
+	}
+	
+	fmt.Print(string(output))
+	return nil
+}
+
+// runPostCommit attaches the note prepared for this commit. Like the hook,
+// it never complains loudly: the commit has already been made.
+func runPostCommit(cmd *cobra.Command, args []string) error {
+	path, err := pendingNotePath()
+	if err != nil {
+		return nil
+	}
+	
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil // Nothing was playing, or the track went into the message
+	}
+	content, err := os.ReadFile(path)
+	os.Remove(path)
+	if err != nil || time.Since(info.ModTime()) > stalePendingNote {
+		return nil
+	}
+	
+	if output, err := exec.Command("git", "notes", "--ref", notesRef, "add", "-f", "-m", string(content), "HEAD").CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  failed to attach the track as a git note: %s\n", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// pendingNotePath locates the note waiting for the commit in progress
+func pendingNotePath() (string, error) {
+	path, err := gitutil.RevParse("--git-path", pendingNoteFile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+// savePendingNote leaves the note for post-commit to attach once the commit exists
+func savePendingNote(note string) error {
+	path, err := pendingNotePath()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(note), 0644)
+}
+
+// clearPendingNote drops a note left by a commit that never happened
+func clearPendingNote() {
+	if path, err := pendingNotePath(); err == nil {
+		os.Remove(path)
+	}
+}
+
+// buildNote renders the git note: the commit line, then any enabled trailers.
+// media is nil when the line is the silence text.
+func buildNote(media *audio.MediaInfo, line string, cfg *config.Config) string {
+	note := line + "\n"
+	if media == nil {
+		return note // The silence text
+	}
+	if cfg.MachineTrailer {
+		if trailer, err := format.MachineTrailer(media); err == nil {
+			note = message.AppendTrailer(note, format.TrailerKey, trailer)
+		}
+	}
+	if cfg.PlayedOnTrailer {
+		note = message.AppendTrailer(note, format.PlayedOnKey, playedOn(media))
+	}
+	return note
+}
+
+// hasPostCommitHook reports whether git will run our post-commit hook
+func hasPostCommitHook() bool {
+	path, err := gitutil.RevParse("--git-path", "hooks/post-commit")
+	if err != nil {
+		return false
+	}
+	return isOurHook(path)
+}
+
+// buildPostCommitScript renders the post-commit script that attaches notes
+func buildPostCommitScript(execPath, kind string) string {
+	return fmt.Sprintf("#!/bin/sh\n%s %s\n# Attaches the track to the new commit as a git note (store_as: note)\n\n\"%s\" post-commit\n",
+		hookMarker, kind, hookExecPath(execPath))
+}
+
+// installPostCommitHook writes the post-commit hook when the config stores
+// tracks as notes. A hook from another tool is left alone.
+func installPostCommitHook(hooksDir, execPath, kind string) {
+	cfg, _ := config.Load()
+	if cfg.StoreAs != config.StoreNote {
+		return
+	}
+	
+	hookPath := filepath.Join(hooksDir, "post-commit")
+	if _, err := os.Stat(hookPath); err == nil && !isOurHook(hookPath) {
+		fmt.Fprintf(humanOut, "⚠️  %s already exists; add this line to it so tracks are stored as notes:\n", hookPath)
+		fmt.Fprintf(humanOut, "   \"%s\" post-commit\n", hookExecPath(execPath))
+		return
+	}
+	
+	if err := os.WriteFile(hookPath, []byte(buildPostCommitScript(execPath, kind)), 0755); err != nil {
+		fmt.Fprintf(humanOut, "⚠️  failed to write post-commit hook: %v\n", err)
+		return
+	}
+	fmt.Fprintf(humanOut, "📝 Installed post-commit hook at %s to store tracks as git notes\n", hookPath)
+}
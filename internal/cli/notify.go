@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+	"github.com/pixare40/interactive-commit/internal/config"
+	"github.com/pixare40/interactive-commit/internal/notify"
+	"github.com/spf13/cobra"
+)
+
+var notifyCmd = &cobra.Command{
+	Use:    "notify <event-json>",
+	Short:  "Post a tagged commit to the configured webhook (internal use)",
+	Long:   "Started in the background by the hook. You shouldn't run this manually.",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE:   runNotify,
+}
+
+func runNotify(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if cfg.Notify.WebhookURL == "" {
+		return nil
+	}
+	
+	var event notify.Event
+	if err := json.Unmarshal([]byte(args[0]), &event); err != nil {
+		return fmt.Errorf("invalid event: %w", err)
+	}
+	if event.Media == nil {
+		return nil
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	var notifier notify.Notifier = &notify.Webhook{URL: cfg.Notify.WebhookURL}
+	return notifier.Notify(ctx, event)
+}
+
+// startNotify hands the event to a background notify process and returns at once,
+// so a slow or unreachable webhook can never delay or fail the commit
+func startNotify(media *audio.MediaInfo, subject string) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return
+	}
+	
+	event, err := json.Marshal(notify.Event{Media: media, Subject: subject, Repo: repoName()})
+	if err != nil {
+		return
+	}
+	
+	// No stdio: git waits for the hook's output pipes to close, so the child mustn't hold them
+	child := exec.Command(execPath, "notify", string(event))
+	if err := child.Start(); err != nil {
+		return
+	}
+	child.Process.Release()
+}
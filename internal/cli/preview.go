@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pixare40/interactive-commit/internal/message"
+	"github.com/spf13/cobra"
+)
+
+var previewCmd = &cobra.Command{
+	Use:   "preview <commit-msg-file> [<source>]",
+	Short: "Show what the hook would make of a commit message, without changing it",
+	Long: `Run the hook on a scratch of a commit message file and print the result: the
+detected track (or --now-playing) placed, deduplicated and formatted exactly as
+in a real commit, with your config. The file itself is left untouched, and
+nothing is recorded to history, notified or attached as a note.
+
+<source> is what git would pass as the message source: message (-m/-F),
+template, merge, squash or commit (--amend). Leave it out for an editor commit.
+
+Examples:
+  interactive-commit preview .git/COMMIT_EDITMSG
+  interactive-commit preview msg.txt message --now-playing "Nightcall by Kavinsky"`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runPreview,
+}
+
+var (
+	// previewing is set while preview runs the hook: nothing is recorded,
+	// notified or noted
+	previewing bool
+
+	// previewNote is the git note the hook would have attached
+	previewNote string
+)
+
+func init() {
+	previewCmd.Flags().StringVar(&hookNowPlaying, "now-playing", "", "Use this track (\"Title by Artist\") instead of detecting audio")
+	previewCmd.Flags().StringVar(&hookAppendPosition, "append-position", "", "Where to place the audio line: top or bottom (overrides config)")
+}
+
+func runPreview(cmd *cobra.Command, args []string) error {
+	original, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	
+	scratch, err := os.CreateTemp("", "interactive-commit-preview-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(scratch.Name())
+	_, err = scratch.Write(original)
+	if closeErr := scratch.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	
+	previewing = true
+	hookArgs := append([]string{scratch.Name()}, args[1:]...)
+	if err := runHook(cmd, hookArgs); err != nil {
+		return err
+	}
+	
+	result, err := os.ReadFile(scratch.Name())
+	if err != nil {
+		return err
+	}
+	content, err := message.Decode(result, commitEncoding())
+	if err != nil {
+		return err
+	}
+	
+	fmt.Print(content)
+	if previewNote != "" {
+		fmt.Printf("\n--- attached as a git note under %s ---\n%s", notesRef, previewNote)
+	}
+	return nil
+}
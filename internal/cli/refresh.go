@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+	"github.com/pixare40/interactive-commit/internal/cache"
+	"github.com/pixare40/interactive-commit/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// asyncResultMaxAge is the oldest background result async_detect still uses.
+// Older ones most likely name a track that finished long ago.
+const asyncResultMaxAge = 10 * time.Minute
+
+var refreshDetectionCmd = &cobra.Command{
+	Use:    "refresh-detection",
+	Short:  "Detect in the background for async_detect (internal use)",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE:   runRefreshDetection,
+}
+
+// runRefreshDetection detects and saves the result for the next commit.
+// Nobody waits for it, so it's silent; a refresh already running wins.
+func runRefreshDetection(cmd *cobra.Command, args []string) error {
+	cfg, _ := config.Load()
+	if resolved, err := cfg.Resolved(); err == nil {
+		cfg = resolved
+	}
+	cfg.DetectionLock = true
+	cfg.LockTimeoutMS = 0
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	detectForHook(ctx, cfg)
+	return nil
+}
+
+// asyncDetection returns the last saved detection without detecting, finished
+// under cfg, and starts a background detection so the next commit's answer is
+// fresher. ok is false when there's no recent result, e.g. on the first commit, or
+// when its track would have ended since.
+func asyncDetection(cfg *config.Config) (*audio.MediaInfo, bool) {
+	if !previewing {
+		startRefresh()
+	}
+	media, saved, ok := cache.LoadResult(time.Now().Add(-asyncResultMaxAge))
+	if !ok || media == nil {
+		return media, ok
+	}
+	
+	// The track has played on since it was detected
+	if media.Position > 0 {
+		media.Position += time.Since(saved)
+		if media.Duration > 0 && media.Position > media.Duration {
+			return nil, false
+		}
+	}
+	
+	// The result is saved raw: this repo's config decides how it reads, and
+	// volume and calls are read afresh, which is quick next to a detection
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	hookManager(cfg).Finish(ctx, media)
+	return media, true
+}
+
+// startRefresh runs refresh-detection without waiting for it. Its output goes
+// nowhere, so git doesn't wait for it either.
+func startRefresh() {
+	execPath, err := os.Executable()
+	if err != nil {
+		return
+	}
+	
+	refresh := exec.Command(execPath, refreshDetectionCmd.Name())
+	if err := refresh.Start(); err != nil {
+		return
+	}
+	refresh.Process.Release()
+}
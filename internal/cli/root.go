@@ -4,6 +4,9 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// version is the release, also written into installed hooks
+const version = "0.1.0"
+
 var rootCmd = &cobra.Command{
 	Use:   "interactive-commit",
 	Short: "Transform your git commits with the soundtrack of your code",
@@ -11,7 +14,7 @@ var rootCmd = &cobra.Command{
 playing audio to commit messages, creating a rich narrative of your development journey.
 
 Ready to soundtrack your code? 🎵`,
-	Version: "0.1.0",
+	Version: version,
 }
 
 func Execute() error {
@@ -22,4 +25,20 @@ func init() {
 	rootCmd.AddCommand(installCmd)
 	rootCmd.AddCommand(detectCmd)
 	rootCmd.AddCommand(hookCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(formatCmd)
+	rootCmd.AddCommand(updateCmd)
+	rootCmd.AddCommand(spotifyCmd)
+	rootCmd.AddCommand(notifyCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(notesCmd)
+	rootCmd.AddCommand(postCommitCmd)
+	rootCmd.AddCommand(backfillCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(previewCmd)
+	rootCmd.AddCommand(hooksCmd)
+	rootCmd.AddCommand(refreshDetectionCmd)
+	rootCmd.AddCommand(commitMsgCmd)
 } 
\ No newline at end of file
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/config"
+	"github.com/pixare40/interactive-commit/internal/spotify"
+	"github.com/spf13/cobra"
+)
+
+var spotifyCmd = &cobra.Command{
+	Use:   "spotify",
+	Short: "Connect the Spotify Web API detector",
+}
+
+var spotifyLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Authorize interactive-commit to read your Spotify playback",
+	Long: `Log in to Spotify so the Web API detector can see what's playing on any of
+your devices.
+
+Create an app at https://developer.spotify.com/dashboard, add the redirect URI
+` + spotify.RedirectURI + ` and put its client ID in your config:
+  { "spotify": { "client_id": "..." } }
+The token is saved next to your global config and refreshed automatically.`,
+	RunE: runSpotifyLogin,
+}
+
+var spotifyLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Forget the saved Spotify token",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := spotify.Logout(); err != nil {
+			return fmt.Errorf("failed to remove Spotify token: %w", err)
+		}
+		fmt.Println("👋 Logged out of Spotify")
+		return nil
+	},
+}
+
+var spotifyClientID string
+
+func init() {
+	spotifyLoginCmd.Flags().StringVar(&spotifyClientID, "client-id", "", "Spotify app client ID (defaults to spotify.client_id from config)")
+	spotifyCmd.AddCommand(spotifyLoginCmd)
+	spotifyCmd.AddCommand(spotifyLogoutCmd)
+}
+
+func runSpotifyLogin(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("⚠️  %v (using defaults)\n", err)
+	}
+	
+	clientID := spotifyClientID
+	if clientID == "" {
+		clientID = cfg.Spotify.ClientID
+	}
+	if clientID == "" {
+		return fmt.Errorf("no Spotify client ID - set spotify.client_id in your config or pass --client-id (see 'interactive-commit spotify login --help')")
+	}
+	
+	verifier, challenge, err := spotify.NewVerifier()
+	if err != nil {
+		return err
+	}
+	state, _, err := spotify.NewVerifier()
+	if err != nil {
+		return err
+	}
+	
+	// Spotify redirects the browser back to us with the authorization code
+	listener, err := net.Listen("tcp", "127.0.0.1:8888")
+	if err != nil {
+		return fmt.Errorf("failed to listen for the Spotify redirect: %w", err)
+	}
+	
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		if query.Get("state") != state {
+			http.Error(w, "Unexpected login attempt", http.StatusBadRequest)
+			return
+		}
+		if reason := query.Get("error"); reason != "" {
+			fmt.Fprintln(w, "Spotify login failed - you can close this tab.")
+			errs <- fmt.Errorf("Spotify login failed: %s", reason)
+			return
+		}
+		fmt.Fprintln(w, "Logged in to Spotify - you can close this tab.")
+		codes <- query.Get("code")
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+	
+	fmt.Println("🔐 Open this URL in your browser to log in to Spotify:")
+	fmt.Println()
+	fmt.Println("  " + spotify.AuthorizeURL(clientID, challenge, state))
+	fmt.Println()
+	fmt.Println("⏳ Waiting for Spotify...")
+	
+	var code string
+	select {
+	case code = <-codes:
+	case err := <-errs:
+		return err
+	case <-time.After(5 * time.Minute):
+		return fmt.Errorf("timed out waiting for the Spotify login")
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	
+	token, err := spotify.Exchange(ctx, clientID, code, verifier)
+	if err != nil {
+		return err
+	}
+	if err := token.Save(); err != nil {
+		return fmt.Errorf("failed to save Spotify token: %w", err)
+	}
+	
+	fmt.Println("✅ Logged in to Spotify")
+	if cfg.Spotify.ClientID == "" {
+		fmt.Println("💡 Set spotify.client_id in your config to turn on the Spotify Web API detector")
+	}
+	
+	return nil
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/history"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show your most-committed-to artists, tracks and sources",
+	Long: `Summarize the soundtrack history of your commits - like a mini
+Wrapped for your code.
+
+Requires "history": true in your config so tagged commits are recorded.
+
+Examples:
+  interactive-commit stats
+  interactive-commit stats --since 30d
+  interactive-commit stats --since 2025-01-01 --json`,
+	RunE: runStats,
+}
+
+var (
+	statsSince string
+	statsJSON  bool
+	statsLimit int
+)
+
+func init() {
+	statsCmd.Flags().StringVar(&statsSince, "since", "", "Only count commits since a duration ago (30d, 12h) or a date (2025-01-01)")
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "Output as JSON")
+	statsCmd.Flags().IntVar(&statsLimit, "limit", 10, "Number of entries to show per table")
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	since, err := parseSince(statsSince)
+	if err != nil {
+		return err
+	}
+	
+	entries, err := history.Load(since)
+	if err != nil {
+		return err
+	}
+	
+	stats := history.Summarize(entries, statsLimit)
+	
+	if statsJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+	
+	if stats.Commits == 0 {
+		fmt.Println("📭 No soundtracked commits in your history yet.")
+		fmt.Println("Enable recording with \"history\": true in your config.")
+		return nil
+	}
+	
+	fmt.Printf("📊 %d soundtracked commits\n", stats.Commits)
+	printCounts("🎤 Top artists", stats.Artists)
+	printCounts("🎵 Top tracks", stats.Tracks)
+	printCounts("📡 Top sources", stats.Sources)
+	
+	return nil
+}
+
+func printCounts(heading string, counts []history.Count) {
+	if len(counts) == 0 {
+		return
+	}
+	
+	fmt.Printf("\n%s\n", heading)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, count := range counts {
+		fmt.Fprintf(w, "  %d.\t%s\t%d\n", i+1, count.Name, count.Count)
+	}
+	w.Flush()
+}
+
+// parseSince accepts a Go duration, a day/week count like "30d" or "2w", or a date
+func parseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, err := strconv.Atoi(strings.TrimSuffix(value, suffix)); err == nil && strings.HasSuffix(value, suffix) {
+			return time.Now().Add(-time.Duration(n) * unit), nil
+		}
+	}
+	
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	
+	return time.Time{}, fmt.Errorf("invalid --since value %q (use e.g. 30d, 12h or 2025-01-01)", value)
+}
@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+	"github.com/pixare40/interactive-commit/internal/config"
+	"github.com/pixare40/interactive-commit/internal/gitutil"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show where hooks are installed and which one git runs",
+	Long: `Show the installed Interactive-Commit hooks (local and global), which one
+git will actually run in this repository, whether they point at this executable,
+and which config files are in use. Use --json for provisioning scripts.`,
+	RunE: runStatus,
+}
+
+var statusJSON bool
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Output as JSON")
+}
+
+// hookStatus describes one prepare-commit-msg hook location
+type hookStatus struct {
+	Path      string `json:"path"`
+	Installed bool   `json:"installed"`           // An Interactive-Commit hook is there
+	Kind      string `json:"kind,omitempty"`      // "git hook" or "global git hook"
+	Version   string `json:"version,omitempty"`   // Release that wrote the hook, if recorded
+	Foreign   bool   `json:"foreign,omitempty"`   // Some other tool's hook is there
+	Chained   bool   `json:"chained,omitempty"`   // Runs a preserved hook first
+	ExecPath  string `json:"exec_path,omitempty"` // Binary the hook runs
+	Stale     bool   `json:"stale,omitempty"`     // ExecPath isn't this executable
+}
+
+// configFileStatus is one config file Load consults
+type configFileStatus struct {
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+}
+
+// statusReport is everything status shows
+type statusReport struct {
+	GitVersion  string             `json:"git_version"`
+	Mode        string             `json:"mode"` // Hook git runs here: "local", "global" or "none"
+	Local       *hookStatus        `json:"local,omitempty"`
+	Global      *hookStatus        `json:"global,omitempty"`
+	ConfigFiles []configFileStatus `json:"config_files"`
+	Detectors   []audio.DetectorInfo `json:"detectors"`
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	report := &statusReport{GitVersion: gitVersion(), Mode: "none"}
+	
+	execPath, _ := os.Executable()
+	
+	globalDir, err := configuredGlobalHooksDir()
+	if err == nil && globalDir != "" {
+		report.Global = inspectHook(filepath.Join(globalDir, "prepare-commit-msg"), execPath)
+		if report.Global.Installed {
+			report.Mode = "global"
+		}
+	}
+	
+	if gitutil.InsideWorkTree() {
+		// The repository's own hooks directory, whatever core.hooksPath says
+		if commonDir, err := gitutil.RevParse("--git-common-dir"); err == nil {
+			hooksDir, _ := filepath.Abs(filepath.Join(commonDir, "hooks"))
+			report.Local = inspectHook(filepath.Join(hooksDir, "prepare-commit-msg"), execPath)
+		}
+		
+		// git runs hooks from wherever --git-path resolves, honouring core.hooksPath
+		if activeDir, err := activeHooksDir(); err == nil {
+			active := filepath.Join(activeDir, "prepare-commit-msg")
+			switch {
+			case report.Global != nil && active == report.Global.Path:
+				// Already counted as global
+			case report.Local != nil && active == report.Local.Path && report.Local.Installed:
+				report.Mode = "local"
+			case isOurHook(active):
+				report.Mode = "local" // A repository-level core.hooksPath
+			default:
+				report.Mode = "none"
+			}
+		}
+	}
+	
+	for _, path := range config.Paths() {
+		_, err := os.Stat(path)
+		report.ConfigFiles = append(report.ConfigFiles, configFileStatus{Path: path, Exists: err == nil})
+	}
+	
+	cfg, _ := config.Load()
+	report.Detectors = audio.NewAudioManager(cfg).DescribeDetectors()
+	
+	if statusJSON {
+		printJSON(report)
+		return nil
+	}
+	
+	printStatus(report)
+	return nil
+}
+
+// inspectHook reads the hook at path
+func inspectHook(path, execPath string) *hookStatus {
+	status := &hookStatus{Path: path}
+	
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return status
+	}
+	
+	script := string(content)
+	if !strings.Contains(script, hookMarker) {
+		status.Foreign = true
+		return status
+	}
+	
+	status.Installed = true
+	if match := hookKindLine.FindStringSubmatch(script); match != nil {
+		status.Kind = match[1]
+	}
+	if match := hookVersionLine.FindStringSubmatch(script); match != nil {
+		status.Version = match[1]
+	}
+	status.Chained = strings.Contains(script, chainedHookSuffix)
+	if match := hookCommandLine.FindStringSubmatch(script); match != nil {
+		status.ExecPath = match[1]
+		status.Stale = execPath != "" && match[1] != hookExecPath(execPath)
+	}
+	return status
+}
+
+func printStatus(report *statusReport) {
+	fmt.Printf("🔧 %s\n", report.GitVersion)
+	
+	switch report.Mode {
+	case "global":
+		fmt.Println("✅ Active: global hook")
+	case "local":
+		fmt.Println("✅ Active: this repository's hook")
+	default:
+		fmt.Println("❌ No Interactive-Commit hook runs here - run 'interactive-commit install'")
+	}
+	
+	printHookStatus("Global", report.Global)
+	printHookStatus("Local", report.Local)
+	
+	fmt.Println("\n📄 Config files:")
+	for _, file := range report.ConfigFiles {
+		state := "not found"
+		if file.Exists {
+			state = "in use"
+		}
+		fmt.Printf("   %s (%s)\n", file.Path, state)
+	}
+	
+	fmt.Println("\n📡 Detectors:")
+	for _, info := range report.Detectors {
+		switch {
+		case info.Available:
+			fmt.Printf("   ✅ %s\n", info.Name)
+		case !info.OtherPlatform:
+			fmt.Printf("   ❌ %s - %s\n", info.Name, info.Reason)
+		}
+	}
+}
+
+func printHookStatus(label string, status *hookStatus) {
+	if status == nil {
+		return
+	}
+	
+	fmt.Printf("\n%s hook: %s\n", label, status.Path)
+	switch {
+	case status.Installed:
+		fmt.Printf("   Runs: %s\n", status.ExecPath)
+		if status.Chained {
+			fmt.Println("   Runs the preserved hook first")
+		}
+		if status.Stale {
+			fmt.Println("   ⚠️  Points at a different executable - run 'interactive-commit update'")
+		}
+	case status.Foreign:
+		fmt.Println("   Another tool's hook")
+	default:
+		fmt.Println("   Not installed")
+	}
+}
+
+// gitVersion returns `git --version` output, or "" when git isn't on PATH
+func gitVersion() string {
+	version, err := gitutil.Output("--version")
+	if err != nil {
+		return ""
+	}
+	return version
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pixare40/interactive-commit/internal/gitutil"
+	"github.com/spf13/cobra"
+)
+
+var updateCmd = &cobra.Command{
+	Use:     "update",
+	Aliases: []string{"reinstall"},
+	Short:   "Rebuild installed hooks after upgrading the binary",
+	Long: `Rebuild every Interactive-Commit hook that is currently installed.
+
+Run this after upgrading (e.g. go install ...@latest). It finds the active
+installs - the global hooks directory from core.hooksPath and this repository's
+hooks directory - points them at the current executable and rewrites scripts
+from older versions in the current format, keeping any chained hook.`,
+	RunE: runUpdate,
+}
+
+// hookCommandLine matches the line of a hook script that runs our binary
+var hookCommandLine = regexp.MustCompile(`(?m)^"(.+)" hook "\$1"`)
+
+// installedHook is one of our hooks found by update
+type installedHook struct {
+	path string
+	kind string // "git hook" or "global git hook", as written in the script
+}
+
+func runUpdate(cmd *cobra.Command, args []string) error {
+	execPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+	
+	hooks, err := findInstalledHooks()
+	if err != nil {
+		return err
+	}
+	
+	if len(hooks) == 0 {
+		fmt.Println("❌ No Interactive-Commit hooks found")
+		fmt.Println("   Install one with: interactive-commit install --local (or --global)")
+		return nil
+	}
+	
+	checkHookShell()
+	
+	for _, hook := range hooks {
+		if err := updateHook(hook, execPath); err != nil {
+			return err
+		}
+		updatePostCommitHook(hook, execPath)
+		updateCommitMsgHook(hook, execPath)
+	}
+	
+	return nil
+}
+
+// findInstalledHooks detects the current install topology: the global hooks
+// directory configured in git, and the hooks directory of the current repository
+func findInstalledHooks() ([]installedHook, error) {
+	var hooks []installedHook
+	
+	globalDir, err := configuredGlobalHooksDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine global hooks directory: %w", err)
+	}
+	
+	globalPath := ""
+	if globalDir != "" {
+		globalPath = filepath.Join(globalDir, "prepare-commit-msg")
+		if isOurHook(globalPath) {
+			hooks = append(hooks, installedHook{path: globalPath, kind: "global git hook"})
+		}
+	}
+	
+	// Outside a repository there is only the global install to look at
+	if !gitutil.InsideWorkTree() {
+		return hooks, nil
+	}
+	
+	localDir, err := getLocalHooksDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine hooks directory: %w", err)
+	}
+	
+	// A repository's core.hooksPath may point at the global directory too
+	localPath := filepath.Join(localDir, "prepare-commit-msg")
+	if localPath != globalPath && isOurHook(localPath) {
+		hooks = append(hooks, installedHook{path: localPath, kind: "git hook"})
+	}
+	
+	return hooks, nil
+}
+
+// updateHook rewrites one hook for execPath and prints what changed
+func updateHook(hook installedHook, execPath string) error {
+	content, err := os.ReadFile(hook.path)
+	if err != nil {
+		return fmt.Errorf("failed to read hook %s: %w", hook.path, err)
+	}
+	oldScript := string(content)
+	
+	_, backupErr := os.Stat(hook.path + chainedHookSuffix)
+	chained := backupErr == nil
+	
+	chainLocal := strings.Contains(oldScript, localChainComment)
+	
+	newScript := buildHookScript(execPath, hook.kind, chained, chainLocal)
+	if newScript == oldScript {
+		fmt.Printf("✅ %s at %s is up to date\n", hook.kind, hook.path)
+		return nil
+	}
+	
+	if err := os.WriteFile(hook.path, []byte(newScript), 0755); err != nil {
+		return fmt.Errorf("failed to write hook file: %w", err)
+	}
+	
+	fmt.Printf("🔄 Updated %s at %s\n", hook.kind, hook.path)
+	
+	newExec := hookExecPath(execPath)
+	oldExec := ""
+	if match := hookCommandLine.FindStringSubmatch(oldScript); match != nil {
+		oldExec = match[1]
+	}
+	if oldExec != newExec {
+		fmt.Printf("   executable: %s → %s\n", oldExec, newExec)
+	}
+	
+	wasChained := strings.Contains(oldScript, chainedHookSuffix)
+	if chained && !wasChained {
+		fmt.Printf("   now runs the preserved hook prepare-commit-msg%s first\n", chainedHookSuffix)
+	}
+	
+	// Anything else that differs is an older script layout
+	if oldExec == newExec && chained == wasChained {
+		fmt.Println("   migrated script to the current format")
+	}
+	
+	return nil
+}
+
+// updatePostCommitHook points the notes post-commit hook next to hook, if we
+// installed one, at execPath too
+func updatePostCommitHook(hook installedHook, execPath string) {
+	path := filepath.Join(filepath.Dir(hook.path), "post-commit")
+	if !isOurHook(path) {
+		return
+	}
+	
+	script := buildPostCommitScript(execPath, hook.kind)
+	if content, err := os.ReadFile(path); err == nil && string(content) == script {
+		return
+	}
+	
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		fmt.Printf("⚠️  failed to update post-commit hook %s: %v\n", path, err)
+		return
+	}
+	fmt.Printf("🔄 Updated post-commit hook at %s\n", path)
+}
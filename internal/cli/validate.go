@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pixare40/interactive-commit/internal/config"
+	"github.com/pixare40/interactive-commit/internal/format"
+	"github.com/pixare40/interactive-commit/internal/gitutil"
+	"github.com/pixare40/interactive-commit/internal/message"
+	"github.com/spf13/cobra"
+)
+
+// errNoSoundtrack is returned with require_soundtrack when the message has no soundtrack line
+var errNoSoundtrack = errors.New("the commit message has no soundtrack line (require_soundtrack)")
+
+var commitMsgCmd = &cobra.Command{
+	Use:    "commit-msg <file>",
+	Short:  "Git commit-msg hook handler that validates the soundtrack line (internal use)",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE:   runCommitMsg,
+}
+
+// runCommitMsg rejects a commit whose soundtrack line no configured format
+// could have written, and with require_soundtrack one without any. Unlike
+// the prepare-commit-msg hook it does fail commits: that's what it's for.
+func runCommitMsg(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	
+	cfg, _ := config.Load()
+	if resolved, err := cfg.Resolved(); err == nil {
+		cfg = resolved
+	}
+	
+	raw, err := os.ReadFile(resolveMessagePath(args[0]))
+	if err != nil {
+		return fmt.Errorf("failed to read commit message file: %w", err)
+	}
+	content, err := message.Decode(raw, commitEncoding())
+	if err != nil {
+		return nil // Not ours to judge a message we can't read
+	}
+	text := message.ToLF(content)
+	
+	err = validateSoundtrack(text, cfg)
+	switch {
+	case errors.Is(err, format.ErrMalformedLine):
+		fmt.Fprintln(os.Stderr, "❌ The soundtrack line was edited into something the configured format can't produce.")
+		fmt.Fprintln(os.Stderr, "   Fix or remove it, then commit again (the message is kept in .git/COMMIT_EDITMSG).")
+	case errors.Is(err, errNoSoundtrack):
+		fmt.Fprintln(os.Stderr, "❌ This repository requires a soundtrack line in every commit.")
+		fmt.Fprintln(os.Stderr, "   Set silence_text so commits made in silence are marked too.")
+	}
+	return err
+}
+
+// validateSoundtrack checks the soundtrack line in text. A wrapped line is
+// checked as the paragraph it fills.
+func validateSoundtrack(text string, cfg *config.Config) error {
+	index := message.FindLine(text, func(line string) bool {
+		return format.IsCommitLine(line) || isSilenceLine(line, cfg.SilenceText) || isSilenceLine(line, cfg.StillPlayingText) ||
+			strings.HasPrefix(line, format.SoundtrackKey+": ")
+	})
+	if index >= 0 {
+		line := strings.Split(text, "\n")[index]
+		if value, ok := strings.CutPrefix(line, format.SoundtrackKey+": "); ok {
+			return format.ValidateLine(value, cfg) // Trailers are never wrapped
+		}
+		if cfg.WrapWidth > 0 {
+			line = message.Paragraph(text, index)
+		}
+		return format.ValidateLine(line, cfg)
+	}
+	
+	// Notes leave the message without a line
+	if !cfg.RequireSoundtrack || cfg.StoreAs == config.StoreNote {
+		return nil
+	}
+	
+	// Nor is a commit the hook skipped on purpose held to it. Git passes no
+	// source here, so a merge or squash is told from what's in progress, and
+	// the rest (only_interactive, min_position_seconds, a muted or repeated
+	// track...) from what the hook recorded
+	if cfg.SkipFixup && message.IsAutosquash(text) || skipsSource(cfg.SkipSources, inProgressSource()) || hookSkipped() {
+		return nil
+	}
+	
+	// A template line without the marker can't be told from any other line
+	// except by matching it
+	if message.FindLine(text, func(line string) bool { return format.ValidateLine(line, cfg) == nil }) >= 0 {
+		return nil
+	}
+	return errNoSoundtrack
+}
+
+// skipRecordFile is where prepare-commit-msg notes that it left the commit
+// without a line on purpose, inside the worktree's git directory
+const skipRecordFile = "interactive-commit-skipped"
+
+func skipRecordPath() (string, error) {
+	path, err := gitutil.RevParse("--git-path", skipRecordFile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+// skipCommit records for require_soundtrack that the hook leaves this commit
+// without a line on purpose, and returns runHook's nil
+func skipCommit(cfg *config.Config) error {
+	if !cfg.RequireSoundtrack || previewing {
+		return nil
+	}
+	if path, err := skipRecordPath(); err == nil {
+		os.WriteFile(path, nil, 0644)
+	}
+	return nil
+}
+
+// clearSkipRecord drops the record of a skipped commit that never happened
+func clearSkipRecord() {
+	if path, err := skipRecordPath(); err == nil {
+		os.Remove(path)
+	}
+}
+
+// hookSkipped reports whether the hook left this commit without a line on purpose
+func hookSkipped() bool {
+	path, err := skipRecordPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// inProgressSource is the commit source git would have passed the hook for
+// the commit being made: "merge" while a merge is concluded, "squash" after
+// merge --squash, and "" otherwise
+func inProgressSource() string {
+	for _, state := range []struct{ file, source string }{{"MERGE_HEAD", "merge"}, {"SQUASH_MSG", "squash"}} {
+		path, err := gitutil.RevParse("--git-path", state.file)
+		if err != nil {
+			return ""
+		}
+		if _, err := os.Stat(path); err == nil {
+			return state.source
+		}
+	}
+	return ""
+}
+
+// buildCommitMsgScript renders the commit-msg script that validates the soundtrack line
+func buildCommitMsgScript(execPath, kind string) string {
+	return fmt.Sprintf("#!/bin/sh\n%s %s\n# Checks the soundtrack line before the commit is made (install --with-validation)\n\n\"%s\" commit-msg \"$1\"\n",
+		hookMarker, kind, hookExecPath(execPath))
+}
+
+// installCommitMsgHook writes the validating commit-msg hook for --with-validation.
+// A hook from another tool is left alone.
+func installCommitMsgHook(hooksDir, execPath, kind string) {
+	hookPath := filepath.Join(hooksDir, "commit-msg")
+	if _, err := os.Stat(hookPath); err == nil && !isOurHook(hookPath) {
+		fmt.Fprintf(humanOut, "⚠️  %s already exists; add this line to it to validate soundtrack lines:\n", hookPath)
+		fmt.Fprintf(humanOut, "   \"%s\" commit-msg \"$1\" || exit $?\n", hookExecPath(execPath))
+		return
+	}
+	
+	if err := os.WriteFile(hookPath, []byte(buildCommitMsgScript(execPath, kind)), 0755); err != nil {
+		fmt.Fprintf(humanOut, "⚠️  failed to write commit-msg hook: %v\n", err)
+		return
+	}
+	fmt.Fprintf(humanOut, "🔎 Installed commit-msg hook at %s to validate soundtrack lines\n", hookPath)
+}
+
+// updateCommitMsgHook points the validating commit-msg hook next to hook, if
+// we installed one, at execPath too
+func updateCommitMsgHook(hook installedHook, execPath string) {
+	path := filepath.Join(filepath.Dir(hook.path), "commit-msg")
+	if !isOurHook(path) {
+		return
+	}
+	
+	script := buildCommitMsgScript(execPath, hook.kind)
+	if content, err := os.ReadFile(path); err == nil && string(content) == script {
+		return
+	}
+	
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		fmt.Printf("⚠️  failed to update commit-msg hook %s: %v\n", path, err)
+		return
+	}
+	fmt.Printf("🔄 Updated commit-msg hook at %s\n", path)
+}
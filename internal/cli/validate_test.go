@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	
+	"github.com/pixare40/interactive-commit/internal/config"
+)
+
+func TestRequireSoundtrackExemptsFixups(t *testing.T) {
+	hookEnv(t, `{}`)
+	cfg := config.Default()
+	cfg.RequireSoundtrack = true
+	cfg.SkipFixup = false
+	
+	fixup := "fixup! Fix the parser\n"
+	if err := validateSoundtrack(fixup, cfg); !errors.Is(err, errNoSoundtrack) {
+		t.Errorf("without skip_fixup: %v, want errNoSoundtrack", err)
+	}
+	cfg.SkipFixup = true
+	if err := validateSoundtrack(fixup, cfg); err != nil {
+		t.Errorf("with skip_fixup: %v", err)
+	}
+	if err := validateSoundtrack("Fix the parser\n", cfg); !errors.Is(err, errNoSoundtrack) {
+		t.Errorf("plain commit with skip_fixup: %v, want errNoSoundtrack", err)
+	}
+}
+
+func TestRequireSoundtrackExemptsSkippedMerges(t *testing.T) {
+	hookEnv(t, `{}`)
+	cfg := config.Default()
+	cfg.RequireSoundtrack = true
+	cfg.SkipSources = []string{"merge"}
+	
+	text := "Merge branch 'feature'\n"
+	if err := validateSoundtrack(text, cfg); !errors.Is(err, errNoSoundtrack) {
+		t.Errorf("no merge in progress: %v, want errNoSoundtrack", err)
+	}
+	if err := os.WriteFile(filepath.Join(".git", "MERGE_HEAD"), []byte("0000000000000000000000000000000000000000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateSoundtrack(text, cfg); err != nil {
+		t.Errorf("merge with skip_sources merge: %v", err)
+	}
+}
+
+func TestRequireSoundtrackFollowsHookSkip(t *testing.T) {
+	hookEnv(t, `{"require_soundtrack": true, "only_interactive": true}`)
+	cfg, _ := config.Load()
+	cfg, _ = cfg.Resolved()
+	
+	// -m commits are skipped by the hook, which commit-msg can't tell on its own
+	skipped := runTestHook(t, "Fix the parser\n", "message")
+	if tagged(skipped) {
+		t.Fatalf("only_interactive tagged a -m commit:\n%s", skipped)
+	}
+	if err := validateSoundtrack(skipped, cfg); err != nil {
+		t.Errorf("skipped commit: %v", err)
+	}
+	
+	// The next commit starts afresh: one the hook tagged has to keep its line
+	if !tagged(runTestHook(t, "Fix the parser\n")) {
+		t.Fatal("editor commit wasn't tagged")
+	}
+	if err := validateSoundtrack("Fix the parser\n", cfg); !errors.Is(err, errNoSoundtrack) {
+		t.Errorf("line removed after the hook tagged it: %v, want errNoSoundtrack", err)
+	}
+}
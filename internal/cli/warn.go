@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// automationGuidance explains how to recover from a denied macOS Automation prompt
+const automationGuidance = `macOS blocked interactive-commit from controlling your music apps.
+Grant access in System Settings → Privacy & Security → Automation:
+enable your terminal (Terminal, iTerm, VS Code, ...) for Spotify, Music and System Events.`
+
+// executionPolicyGuidance explains how to recover when PowerShell refuses to run our script
+const executionPolicyGuidance = `PowerShell's execution policy blocked audio detection.
+Set "powershell_bypass": true in your config (the default) so interactive-commit
+passes -ExecutionPolicy Bypass, or ask your administrator to allow it with:
+  Set-ExecutionPolicy -Scope CurrentUser RemoteSigned`
+
+// firefoxMPRISGuidance explains how to let Firefox share its media over MPRIS
+const firefoxMPRISGuidance = `Firefox is running but isn't sharing any media over MPRIS, so its tabs can't be detected.
+If something is playing in it, open about:config and set media.hardwaremediakeys.enabled
+to true, then restart Firefox.`
+
+// warnOnce prints a warning to stderr the first time it is seen on this machine.
+// A marker file in the user cache directory remembers that we've already warned,
+// so commits aren't spammed with the same message.
+func warnOnce(key, message string) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return
+	}
+
+	markerPath := filepath.Join(cacheDir, "interactive-commit", "warned-"+key)
+	if _, err := os.Stat(markerPath); err == nil {
+		return // Already warned
+	}
+
+	fmt.Fprintf(os.Stderr, "⚠️  %s\n", message)
+
+	if err := os.MkdirAll(filepath.Dir(markerPath), 0755); err != nil {
+		return
+	}
+	os.WriteFile(markerPath, nil, 0644)
+}
@@ -0,0 +1,595 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/pixare40/interactive-commit/internal/gitutil"
+)
+
+// FileName is the per-repository configuration file, looked up at the repository root
+const FileName = ".interactive-commit.json"
+
+// Values for AmendBehavior
+const (
+	AmendAppend  = "append"  // Always add the audio line (default)
+	AmendReplace = "replace" // Update an existing audio line instead of adding another
+	AmendSkip    = "skip"    // Leave amends and already-tagged messages untouched
+)
+
+// Values for StoreAs
+const (
+	StoreMessage = "message" // Add the track to the commit message (default)
+	StoreNote    = "note"    // Attach the track as a git note, leaving the message untouched
+)
+
+// Values for Mode
+const (
+	ModeAppend  = "append"  // Add the track to the message (default)
+	ModeSuggest = "suggest" // Offer the track as a comment in the editor, to uncomment if wanted
+)
+
+// Config holds user preferences for detection and formatting
+type Config struct {
+	// SourceEmoji maps a media source (e.g. "Spotify") to the emoji that prefixes its commit line
+	SourceEmoji map[string]string `json:"source_emoji,omitempty"`
+
+	// TypeEmoji maps a media type (e.g. "podcast") to its emoji, replacing the
+	// built-in one. A source emoji still wins.
+	TypeEmoji map[string]string `json:"type_emoji,omitempty"`
+
+	// DefaultEmoji is used when neither the source nor the media type has an emoji
+	DefaultEmoji string `json:"default_emoji,omitempty"`
+
+	// ArtistSeparator goes between title and artist, e.g. "by", "—" or "·"
+	ArtistSeparator string `json:"artist_separator,omitempty"`
+
+	// Template is a text/template for the commit line, e.g. `{{.Emoji}} {{.Title}} - {{.Artist}}`.
+	// Empty uses the built-in "Currently playing" format.
+	Template string `json:"template,omitempty"`
+
+	// TimeOfDayBuckets names parts of the day for {{.TimeOfDay}} by the hour (0-23) they
+	// start at. Empty uses morning 5, afternoon 12, evening 17 and late-night 22.
+	TimeOfDayBuckets map[string]int `json:"time_of_day_buckets,omitempty"`
+
+	// Presets are named bundles of formatting options, e.g. "funky" or "minimal"
+	Presets map[string]Preset `json:"presets,omitempty"`
+
+	// ActivePreset names the preset applied on top of these settings
+	ActivePreset string `json:"preset,omitempty"`
+
+	// Mode "suggest" offers the track as a commented-out line in the editor
+	// instead of adding it
+	Mode string `json:"mode,omitempty"`
+
+	// StoreAs is where the track is recorded: in the commit "message", or as a git
+	// "note" under refs/notes/now-playing attached by the post-commit hook
+	StoreAs string `json:"store_as,omitempty"`
+
+	// MachineTrailer also appends an X-Now-Playing trailer holding the full media info
+	// as URL-safe base64 of compact JSON, for tools that parse commits
+	MachineTrailer bool `json:"machine_trailer,omitempty"`
+
+	// PlayedOnTrailer appends a "Played-On: macOS via Apple Music" trailer naming the OS and source
+	PlayedOnTrailer bool `json:"played_on_trailer,omitempty"`
+
+	// ArtistsSeparator joins multiple artists ("A, B", "A & B", "A feat. B" all become "A, B")
+	ArtistsSeparator string `json:"artists_separator,omitempty"`
+
+	// PrimaryArtistOnly keeps just the first of several artists
+	PrimaryArtistOnly bool `json:"primary_artist_only,omitempty"`
+
+	// SplitArtistJoiners also treats ",", "&" and " x " as separating artists.
+	// Off, only featuring credits ("feat.", "ft.", "featuring") and ";" do,
+	// since the others are often part of one name.
+	SplitArtistJoiners bool `json:"split_artist_joiners,omitempty"`
+
+	// SilenceText is added instead when nothing is playing, e.g. "🔇 Committed in silence".
+	// Empty adds nothing.
+	SilenceText string `json:"silence_text,omitempty"`
+
+	// DedupConsecutive leaves out the line when the last commit in the repository,
+	// as the history recorded it, was made to the same track
+	DedupConsecutive bool `json:"dedup_consecutive,omitempty"`
+
+	// StillPlayingText is added instead of a repeated track with DedupConsecutive,
+	// e.g. "🎵 (still playing)". Empty adds nothing.
+	StillPlayingText string `json:"still_playing_text,omitempty"`
+
+	// RequireSoundtrack makes the commit-msg hook (install --with-validation)
+	// reject commits without a soundtrack line
+	RequireSoundtrack bool `json:"require_soundtrack,omitempty"`
+
+	// ShowPlaylist adds the playlist or station a track plays from to the built-in line
+	ShowPlaylist bool `json:"show_playlist,omitempty"`
+
+	// AppendPosition places the audio line after the body ("bottom") or right after the subject ("top")
+	AppendPosition string `json:"append_position,omitempty"`
+
+	// SmartPlacement writes the line as the body of a subject-only message, and
+	// as a Soundtrack trailer below a message that already has a body
+	SmartPlacement bool `json:"smart_placement,omitempty"`
+
+	// Badge prefixes the commit line with a markdown service badge instead of an emoji,
+	// for commits pasted into rendered changelogs. Sources without a badge keep plain text.
+	Badge bool `json:"badge,omitempty"`
+
+	// BadgeTemplates maps a media source to a markdown badge template, overriding the built-in
+	// shields.io badges. Templates see the same fields as Template, e.g. {{.ArtworkURL}}.
+	BadgeTemplates map[string]string `json:"badge_templates,omitempty"`
+
+	// AmendBehavior decides what happens when a message is amended or already carries
+	// an audio line: "append", "replace" or "skip"
+	AmendBehavior string `json:"amend_behavior,omitempty"`
+
+	// WrapWidth word-wraps the audio line to this many columns; zero keeps it on one line
+	WrapWidth int `json:"wrap_width,omitempty"`
+
+	// Marker appends an invisible zero-width sentinel to the audio line so amend_behavior
+	// finds it even with a custom template or after edits to the message
+	Marker bool `json:"marker"`
+
+	// Strict lets the hook abort the commit when it fails, e.g. when the message
+	// file can't be written. By default failures are reported and the commit
+	// goes ahead without a track.
+	Strict bool `json:"strict,omitempty"`
+
+	// HookFeedback prints a one-line confirmation to stderr when a commit is tagged,
+	// for git GUIs that show hook output
+	HookFeedback bool `json:"hook_feedback,omitempty"`
+
+	// OnlyInteractive only tags commits whose message is written in an editor,
+	// skipping -m/-F, merge, squash, template and amend-with-message commits
+	OnlyInteractive bool `json:"only_interactive,omitempty"`
+
+	// SkipSources leaves commits alone whose message comes from one of these of
+	// git's sources: "message" (-m/-F), "template", "merge", "squash" or "commit"
+	SkipSources []string `json:"skip_sources,omitempty"`
+
+	// History records each tagged commit's track to a local JSONL file, used by the stats command
+	History bool `json:"history,omitempty"`
+
+	// SessionGapMinutes is the break between commits that starts a new listening session
+	SessionGapMinutes int `json:"session_gap_minutes,omitempty"`
+
+	// TitleCase rewrites titles, artists and albums written in ALL CAPS in title case
+	TitleCase bool `json:"title_case,omitempty"`
+
+	// RedactExplicit replaces the title of tracks flagged explicit with a
+	// generic "an explicit track". Only the Spotify Web API flags tracks.
+	RedactExplicit bool `json:"redact_explicit,omitempty"`
+
+	// CollapseVariousArtists drops compilation credits like "Various Artists"
+	// from the artist, keeping any real artists credited alongside.
+	// VariousArtists replaces the built-in list of credits when set.
+	CollapseVariousArtists bool     `json:"collapse_various_artists,omitempty"`
+	VariousArtists         []string `json:"various_artists,omitempty"`
+
+	// InferArtistFromTitle splits "Artist - Song" video titles that come without an artist
+	InferArtistFromTitle bool `json:"infer_artist_from_title,omitempty"`
+
+	// StopOnEmpty trusts a detector that cleanly reports nothing playing instead of
+	// falling through to lower-priority detectors. Detector errors always fall through.
+	StopOnEmpty bool `json:"stop_on_empty,omitempty"`
+
+	// RaiseDetectorPanics lets a crashing detector abort with its stack trace
+	// instead of being treated as a failed detector. For debugging detectors.
+	RaiseDetectorPanics bool `json:"raise_detector_panics,omitempty"`
+
+	// PerDetectorTimeoutMS caps how much of the detection budget each detector
+	// may use, so a hung one leaves time for the rest. Zero lets every detector
+	// use whatever budget is left.
+	PerDetectorTimeoutMS int `json:"per_detector_timeout_ms,omitempty"`
+
+	// MaxDetectors caps how many available detectors are tried, in priority
+	// order, before giving up. Zero tries them all.
+	MaxDetectors int `json:"max_detectors,omitempty"`
+
+	// DetectionCache reuses the last commit's detection while the same track
+	// plays, for players that cheaply say which track that is (MPRIS). Other
+	// detectors' results are reused for DetectionCacheTTLSeconds, zero for never.
+	DetectionCache           bool `json:"detection_cache,omitempty"`
+	DetectionCacheTTLSeconds int  `json:"detection_cache_ttl_seconds,omitempty"`
+
+	// DetectionLock lets only one commit on this machine detect at a time; others wait
+	// up to LockTimeoutMS, reuse its result, or go ahead without audio
+	DetectionLock bool `json:"detection_lock,omitempty"`
+
+	// LockTimeoutMS is the longest a commit waits for another commit's detection
+	LockTimeoutMS int `json:"lock_timeout_ms,omitempty"`
+
+	// AsyncDetect never waits for detection: the hook uses the last result a
+	// background detection saved, and starts another for the next commit
+	AsyncDetect bool `json:"async_detect,omitempty"`
+
+	// MinPositionSeconds skips tagging tracks that have played for less than this,
+	// e.g. ones you just skipped to. Zero tags every track.
+	MinPositionSeconds int `json:"min_position_seconds,omitempty"`
+
+	// SkipUnknownPosition also skips tracks whose player doesn't report a position
+	// while MinPositionSeconds is set, instead of tagging them
+	SkipUnknownPosition bool `json:"skip_unknown_position,omitempty"`
+
+	// SkipIfMuted leaves the message alone when the system output is muted or at zero volume
+	SkipIfMuted bool `json:"skip_if_muted,omitempty"`
+
+	// SkipDuringCalls leaves the message alone while a video call is going on,
+	// since whatever is playing is the call or incidental to it
+	SkipDuringCalls bool `json:"skip_during_calls,omitempty"`
+
+	// PowerShellBypass runs the WSL/Windows detector with -ExecutionPolicy Bypass
+	PowerShellBypass bool `json:"powershell_bypass"`
+
+	// SkipFixup leaves "fixup!" and "squash!" commits alone: rebase --autosquash
+	// folds them into the commit they name, which has its own line already
+	SkipFixup bool `json:"skip_fixup"`
+
+	// IncludeLyrics adds the first line of a song's lyrics to the commit line,
+	// looked up with the Lyrics provider
+	IncludeLyrics bool         `json:"include_lyrics,omitempty"`
+	Lyrics        LyricsConfig `json:"lyrics,omitempty"`
+
+	// IncludeLanguage adds the main language of the staged files to the commit
+	// line, e.g. "while coding Go"
+	IncludeLanguage bool `json:"include_language,omitempty"`
+
+	// IncludeCoverColor samples the dominant color of the album art, for
+	// templates ({{.CoverColor}}). Covers are downloaded once and cached.
+	IncludeCoverColor bool `json:"include_cover_color,omitempty"`
+
+	// MixTracklist resolves the current track inside YouTube DJ mixes from the video's timestamped tracklist
+	MixTracklist bool `json:"mix_tracklist,omitempty"`
+
+	// YouTubeAPIKey is a YouTube Data API key, used to fetch video descriptions
+	YouTubeAPIKey string `json:"youtube_api_key,omitempty"`
+
+	// PreferredPlayers are MPRIS players reported first, in order, when several
+	// have media on Linux, e.g. ["spotify"] over a browser tab
+	PreferredPlayers []string `json:"preferred_players,omitempty"`
+
+	// FifoPath enables reading now-playing lines written by your own daemon to a FIFO or file
+	FifoPath string `json:"fifo_path,omitempty"`
+
+	// MPVSocket is the path mpv was started with via --input-ipc-server
+	MPVSocket string `json:"mpv_socket,omitempty"`
+
+	// MPDHost is MPD's "host:port" or socket path, optionally "password@"-prefixed
+	MPDHost string `json:"mpd_host,omitempty"`
+
+	// ProcessScan lists terminal player commands (e.g. "mpv") the last-resort process scan
+	// looks for; the track is taken from the player's command line. Empty disables the scan.
+	ProcessScan []string `json:"process_scan"`
+
+	// Spotify enables the Spotify Web API detector once a client ID is set
+	Spotify SpotifyConfig `json:"spotify,omitempty"`
+
+	// Notify posts each tagged commit's track to a chat webhook
+	Notify NotifyConfig `json:"notify,omitempty"`
+
+	// OBS enables the OBS Studio detector when a websocket URL is set
+	OBS OBSConfig `json:"obs,omitempty"`
+
+	// Roon enables the Roon detector when a bridge URL is set
+	Roon RoonConfig `json:"roon,omitempty"`
+
+	// Remote enables detecting what plays on another machine over SSH
+	Remote RemoteConfig `json:"remote,omitempty"`
+}
+
+// Preset overrides the formatting options it sets; empty fields keep the base value
+type Preset struct {
+	Template        string            `json:"template,omitempty"`
+	DefaultEmoji    string            `json:"default_emoji,omitempty"`
+	SourceEmoji     map[string]string `json:"source_emoji,omitempty"`
+	TypeEmoji       map[string]string `json:"type_emoji,omitempty"`
+	ArtistSeparator string            `json:"artist_separator,omitempty"`
+	AppendPosition  string            `json:"append_position,omitempty"`
+}
+
+// SpotifyConfig holds Spotify Web API settings
+type SpotifyConfig struct {
+	ClientID string `json:"client_id,omitempty"` // From your app at developer.spotify.com
+
+	// AudioFeatures fetches tempo and mood for each track, an extra API call per commit
+	AudioFeatures bool `json:"audio_features,omitempty"`
+}
+
+// NotifyConfig holds where tagged commits are announced
+type NotifyConfig struct {
+	WebhookURL string `json:"webhook_url,omitempty"` // Slack or Discord incoming webhook
+}
+
+// LyricsConfig picks where include_lyrics looks lyrics up
+type LyricsConfig struct {
+	Provider string `json:"provider,omitempty"` // "lrclib" (the default, needs no key) or "musixmatch"
+	APIKey   string `json:"api_key,omitempty"`
+}
+
+// RoonConfig holds where the Roon detector finds an HTTP bridge extension
+type RoonConfig struct {
+	URL  string `json:"url,omitempty"`  // e.g. http://roon-core.local:3001
+	Zone string `json:"zone,omitempty"` // Zone name or ID; empty uses the first playing zone
+}
+
+// RemoteConfig holds the machine the remote detector asks over SSH
+type RemoteConfig struct {
+	SSH     string `json:"ssh,omitempty"`     // ssh destination, e.g. "me@desktop"
+	Command string `json:"command,omitempty"` // Path to interactive-commit there, if not on its PATH
+}
+
+// OBSConfig holds obs-websocket v5 connection settings
+type OBSConfig struct {
+	URL      string `json:"url,omitempty"` // e.g. ws://localhost:4455
+	Password string `json:"password,omitempty"`
+}
+
+// Default returns the configuration used when no config file exists
+func Default() *Config {
+	return &Config{
+		SourceEmoji:       map[string]string{},
+		DefaultEmoji:      "🎵",
+		ArtistSeparator:   "by",
+		ArtistsSeparator:  ", ",
+		AppendPosition:    "bottom",
+		AmendBehavior:     AmendAppend,
+		StoreAs:           StoreMessage,
+		Mode:              ModeAppend,
+		SessionGapMinutes: 30,
+		LockTimeoutMS:     2000,
+		Marker:            true,
+		PowerShellBypass:  true,
+		SkipFixup:         true,
+		ProcessScan:       []string{"mpv", "mplayer", "ffplay", "mpg123", "cvlc", "mocp"},
+	}
+}
+
+// Resolved returns a copy of the config with the active preset's options applied.
+// An unknown preset is an error, and the config is returned unchanged.
+func (c *Config) Resolved() (*Config, error) {
+	if c.ActivePreset == "" {
+		return c, nil
+	}
+
+	preset, ok := c.Presets[c.ActivePreset]
+	if !ok {
+		return c, c.unknownPreset(c.ActivePreset)
+	}
+
+	// The copy is flattened, so later overrides of its fields stick
+	resolved := *c
+	resolved.ActivePreset = ""
+	if preset.Template != "" {
+		resolved.Template = preset.Template
+	}
+	if preset.DefaultEmoji != "" {
+		resolved.DefaultEmoji = preset.DefaultEmoji
+	}
+	if len(preset.SourceEmoji) > 0 {
+		resolved.SourceEmoji = mergeEmoji(c.SourceEmoji, preset.SourceEmoji)
+	}
+	if len(preset.TypeEmoji) > 0 {
+		resolved.TypeEmoji = mergeEmoji(c.TypeEmoji, preset.TypeEmoji)
+	}
+	if preset.ArtistSeparator != "" {
+		resolved.ArtistSeparator = preset.ArtistSeparator
+	}
+	if preset.AppendPosition != "" {
+		resolved.AppendPosition = preset.AppendPosition
+	}
+	return &resolved, nil
+}
+
+// mergeEmoji returns a new map of base with overlay's entries on top
+func mergeEmoji(base, overlay map[string]string) map[string]string {
+	merged := map[string]string{}
+	for key, emoji := range base {
+		merged[key] = emoji
+	}
+	for key, emoji := range overlay {
+		merged[key] = emoji
+	}
+	return merged
+}
+
+// CheckPreset returns an error naming the defined presets when name isn't one of them
+func (c *Config) CheckPreset(name string) error {
+	if _, ok := c.Presets[name]; !ok {
+		return c.unknownPreset(name)
+	}
+	return nil
+}
+
+func (c *Config) unknownPreset(name string) error {
+	if len(c.Presets) == 0 {
+		return fmt.Errorf("unknown preset %q: no presets are defined in the config", name)
+	}
+
+	names := make([]string, 0, len(c.Presets))
+	for presetName := range c.Presets {
+		names = append(names, presetName)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// Load reads the global config file, then overlays the repository config file on top.
+// Missing files are not an error - defaults are used instead.
+func Load() (*Config, error) {
+	cfg := Default()
+
+	if globalPath, err := GlobalPath(); err == nil {
+		if err := cfg.mergeFile(globalPath, false); err != nil {
+			return Default(), err
+		}
+	}
+
+	if repoPath, err := RepoPath(); err == nil {
+		if err := cfg.mergeFile(repoPath, true); err != nil {
+			return Default(), err
+		}
+	}
+
+	return cfg, nil
+}
+
+// Paths returns the config files consulted by Load, lowest precedence first
+func Paths() []string {
+	var paths []string
+
+	if globalPath, err := GlobalPath(); err == nil {
+		paths = append(paths, globalPath)
+	}
+
+	if repoPath, err := RepoPath(); err == nil {
+		paths = append(paths, repoPath)
+	}
+
+	return paths
+}
+
+// GlobalPath returns the location of the user-wide config file
+func GlobalPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	// Follow the same XDG convention as the global hooks directory
+	configDir := filepath.Join(homeDir, ".config")
+	if runtime.GOOS == "linux" {
+		if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
+			configDir = xdgConfig
+		}
+	}
+
+	return filepath.Join(configDir, "interactive-commit", "config.json"), nil
+}
+
+// RepoPath returns the location of the config file for the current repository
+func RepoPath() (string, error) {
+	topLevel, err := gitutil.RevParse("--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("not in a git repository: %w", err)
+	}
+
+	return filepath.Join(topLevel, FileName), nil
+}
+
+// repoKeys are the settings a repository's file may override: how the line
+// looks and where it goes, and when a commit gets one. Everything else - what
+// runs to detect, and where data is sent (webhooks, ssh, players to scan,
+// API keys) - is only read from the global config, so cloning a repository
+// can't make it run commands or send your commits anywhere.
+var repoKeys = map[string]bool{
+	"source_emoji": true, "type_emoji": true, "default_emoji": true, "template": true,
+	"time_of_day_buckets": true, "presets": true, "preset": true, "mode": true,
+	"store_as": true, "machine_trailer": true, "played_on_trailer": true,
+	"artist_separator": true, "artists_separator": true, "primary_artist_only": true,
+	"split_artist_joiners": true,
+	"silence_text":         true, "dedup_consecutive": true, "still_playing_text": true,
+	"require_soundtrack": true, "show_playlist": true, "append_position": true,
+	"smart_placement": true, "badge": true, "badge_templates": true,
+	"amend_behavior": true, "wrap_width": true, "marker": true, "strict": true,
+	"hook_feedback": true, "only_interactive": true, "skip_sources": true,
+	"skip_fixup": true, "title_case": true, "redact_explicit": true,
+	"collapse_various_artists": true, "various_artists": true,
+	"infer_artist_from_title": true, "include_language": true,
+	"min_position_seconds": true, "skip_unknown_position": true,
+	"skip_if_muted": true, "skip_during_calls": true,
+}
+
+// IgnoredRepoKeys lists the keys the current repository's file sets that
+// aren't in repoKeys, and so have no effect
+func IgnoredRepoKeys() []string {
+	repoPath, err := RepoPath()
+	if err != nil {
+		return nil
+	}
+	content, err := os.ReadFile(repoPath)
+	if err != nil {
+		return nil
+	}
+
+	var settings map[string]json.RawMessage
+	if json.Unmarshal(content, &settings) != nil {
+		return nil
+	}
+	var ignored []string
+	for key := range settings {
+		if !repoKeys[key] {
+			ignored = append(ignored, key)
+		}
+	}
+	sort.Strings(ignored)
+	return ignored
+}
+
+// mergeFile overlays the settings in path onto cfg, leaving unset keys
+// untouched. A repository's file only sets repoKeys.
+func (c *Config) mergeFile(path string, repo bool) error {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	if repo {
+		var settings map[string]json.RawMessage
+		if err := json.Unmarshal(content, &settings); err != nil {
+			return fmt.Errorf("failed to parse config %s: %w", path, err)
+		}
+		for key := range settings {
+			if !repoKeys[key] {
+				delete(settings, key)
+			}
+		}
+		if content, err = json.Marshal(settings); err != nil {
+			return err
+		}
+	}
+
+	if err := json.Unmarshal(content, c); err != nil {
+		return fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// SetValue sets one top-level key in the config file at path, keeping the rest
+// of the file as it is. The file is created if it doesn't exist.
+func SetValue(path, key string, value interface{}) error {
+	settings := map[string]json.RawMessage{}
+
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+	if err == nil && len(strings.TrimSpace(string(content))) > 0 {
+		if err := json.Unmarshal(content, &settings); err != nil {
+			return fmt.Errorf("failed to parse config %s: %w", path, err)
+		}
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	settings[key] = encoded
+
+	content, err = json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
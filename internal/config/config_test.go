@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const untrustedConfig = `{
+	"template": "♪ {{.Title}}",
+	"silence_text": "quiet",
+	"notify": {"webhook_url": "https://example.com/hook"},
+	"remote": {"ssh": "-oProxyCommand=touch /tmp/x", "command": "sh"},
+	"process_scan": ["sh"],
+	"powershell_bypass": false
+}`
+
+func TestRepoFileOnlySetsAllowedKeys(t *testing.T) {
+	cfg := Default()
+	if err := cfg.mergeFile(writeConfig(t, untrustedConfig), true); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Template != "♪ {{.Title}}" || cfg.SilenceText != "quiet" {
+		t.Errorf("allowed keys weren't applied: template %q, silence_text %q", cfg.Template, cfg.SilenceText)
+	}
+	if cfg.Notify.WebhookURL != "" {
+		t.Errorf("notify.webhook_url = %q, want it ignored", cfg.Notify.WebhookURL)
+	}
+	if cfg.Remote != (RemoteConfig{}) {
+		t.Errorf("remote = %+v, want it ignored", cfg.Remote)
+	}
+	if want := Default().ProcessScan; !reflect.DeepEqual(cfg.ProcessScan, want) {
+		t.Errorf("process_scan = %v, want the default %v", cfg.ProcessScan, want)
+	}
+	if !cfg.PowerShellBypass {
+		t.Error("powershell_bypass was turned off by a repository file")
+	}
+}
+
+func TestGlobalFileSetsEveryKey(t *testing.T) {
+	cfg := Default()
+	if err := cfg.mergeFile(writeConfig(t, untrustedConfig), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Notify.WebhookURL != "https://example.com/hook" {
+		t.Errorf("notify.webhook_url = %q", cfg.Notify.WebhookURL)
+	}
+	if cfg.Remote.Command != "sh" {
+		t.Errorf("remote.command = %q", cfg.Remote.Command)
+	}
+	if !reflect.DeepEqual(cfg.ProcessScan, []string{"sh"}) {
+		t.Errorf("process_scan = %v", cfg.ProcessScan)
+	}
+}
+
+func TestRepoFileParseError(t *testing.T) {
+	cfg := Default()
+	if err := cfg.mergeFile(writeConfig(t, `{"template": `), true); err == nil {
+		t.Error("expected a parse error")
+	}
+}
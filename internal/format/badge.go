@@ -0,0 +1,40 @@
+package format
+
+import (
+	"github.com/pixare40/interactive-commit/internal/config"
+)
+
+// defaultBadges are shields.io badges for the services we recognize
+var defaultBadges = map[string]string{
+	"Spotify":       "![Spotify](https://img.shields.io/badge/Spotify-1DB954?logo=spotify&logoColor=white)",
+	"YouTube":       "![YouTube](https://img.shields.io/badge/YouTube-FF0000?logo=youtube&logoColor=white)",
+	"YouTube Music": "![YouTube Music](https://img.shields.io/badge/YouTube_Music-FF0000?logo=youtubemusic&logoColor=white)",
+	"SoundCloud":    "![SoundCloud](https://img.shields.io/badge/SoundCloud-FF5500?logo=soundcloud&logoColor=white)",
+	"Apple Music":   "![Apple Music](https://img.shields.io/badge/Apple_Music-FA243C?logo=applemusic&logoColor=white)",
+}
+
+// badge renders the markdown badge for the media's source, or "" when badges
+// are off, the source has none, or its template fails - callers then use plain text
+func badge(data TemplateData, cfg *config.Config) string {
+	if !cfg.Badge {
+		return ""
+	}
+
+	text, ok := cfg.BadgeTemplates[data.Source]
+	if !ok {
+		text = defaultBadges[data.Source]
+	}
+	if text == "" {
+		return ""
+	}
+
+	tmpl, err := CompileTemplate(text)
+	if err != nil {
+		return ""
+	}
+	rendered, err := render(tmpl, data)
+	if err != nil {
+		return ""
+	}
+	return rendered
+}
@@ -2,18 +2,164 @@ package format
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
 
 	"github.com/pixare40/interactive-commit/internal/audio"
+	"github.com/pixare40/interactive-commit/internal/config"
 )
 
+// typeEmoji is the built-in emoji for media types, used when neither the
+// source nor the type has one configured
+var typeEmoji = map[string]string{
+	"podcast":   "🎙️",
+	"video":     "🎬",
+	"audiobook": "📖",
+	"classical": "🎼",
+}
+
 // FormatCommitMessage formats audio media info into a commit message line
-func FormatCommitMessage(media *audio.MediaInfo) string {
+func FormatCommitMessage(media *audio.MediaInfo, cfg *config.Config) string {
 	if media == nil {
 		return ""
 	}
 	
-	if media.Artist != "" {
-		return fmt.Sprintf("🎵 Currently playing: \"%s\" by %s (%s)", media.Title, media.Artist, media.Source)
+	// An unknown preset leaves the base settings in effect
+	if resolved, err := cfg.Resolved(); err == nil {
+		cfg = resolved
+	}
+	
+	// A user template wins, but a broken one falls back to the built-in format
+	var tmpl *template.Template
+	if cfg.Template != "" {
+		tmpl, _ = CompileTemplate(cfg.Template)
+	}
+	withLyric := tmpl == nil || usesField(tmpl, "Lyric")
+	data := newTemplateData(media, cfg, withLyric)
+	if tmpl != nil {
+		if line, err := render(tmpl, data); err == nil {
+			return line
+		}
+		if !withLyric {
+			data.Lyric = lyricSnippet(media, cfg) // The built-in line shows it
+		}
+	}
+	
+	var line string
+	if media.Type == "classical" && media.Work != "" {
+		line = formatClassical(data)
+	} else if media.Artist != "" {
+		line = fmt.Sprintf("%s Currently playing: \"%s\" %s %s (%s)", data.prefix(), media.Title, data.Separator, media.Artist, sourceLabel(media))
+	} else {
+		line = fmt.Sprintf("%s Currently playing: \"%s\" (%s)", data.prefix(), media.Title, sourceLabel(media))
+	}
+	
+	if cfg.ShowPlaylist && media.Playlist != "" {
+		line += fmt.Sprintf(" from \"%s\"", media.Playlist)
+	}
+	
+	if data.PrimaryLanguage != "" {
+		line += " while coding " + data.PrimaryLanguage
+	}
+	
+	if vibe := formatVibe(media); vibe != "" {
+		line += " — " + vibe
+	}
+	
+	if data.Lyric != "" {
+		line += " — “" + data.Lyric + "”"
+	}
+	
+	if data.TrackCommitCount > 1 {
+		line += fmt.Sprintf(" — commit #%d to this track", data.TrackCommitCount)
+	}
+	return line
+}
+
+// Marker is an invisible sentinel (zero-width space, non-joiner, space) the hook
+// appends to its line, so the line can be found again whatever the template
+// and however the rest of the message was edited
+const Marker = "\u200b\u200c\u200b"
+
+// playingShape is the built-in line's track, from "Currently playing"
+const playingShape = `Currently playing: ".+"(?: .+)? \(.+\)(?: .+)?`
+
+// commitLine is a built-in line without the marker: the emoji, a ":shortcode:"
+// or a badge, then the track. Where the commit encoding can't hold the emoji
+// the prefix is dropped along with its space, so a line without one has to
+// have the whole shape, parentheses and all.
+var commitLine = regexp.MustCompile(`^ ?(?:(?:[^\s\p{L}\p{N}]+|:[\w+-]+:|!\[[^\]]*\]\([^)]*\)) (?:` + playingShape + `|Listening to: .+)|` + playingShape + `|Listening to: .+ \(.+\))$`)
+
+// IsCommitLine reports whether line carries the marker or looks like one
+// FormatCommitMessage wrote with the built-in format, so an existing soundtrack
+// line can be found again. A line that only mentions "Listening to: " isn't one.
+func IsCommitLine(line string) bool {
+	if strings.Contains(line, Marker) {
+		return true
+	}
+	return commitLine.MatchString(line)
+}
+
+// sourceLabel names the source, and the device when playing elsewhere: "Spotify on Living Room"
+func sourceLabel(media *audio.MediaInfo) string {
+	if media.OutputDevice != "" {
+		return media.Source + " on " + media.OutputDevice
+	}
+	return media.Source
+}
+
+// formatVibe describes a track's audio features, e.g. "128 BPM, upbeat"
+func formatVibe(media *audio.MediaInfo) string {
+	var parts []string
+	if media.Tempo > 0 {
+		parts = append(parts, fmt.Sprintf("%d BPM", media.Tempo))
+	}
+	if media.Mood != "" {
+		parts = append(parts, media.Mood)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatClassical describes a classical track as "Work — Movement (Composer)"
+func formatClassical(data TemplateData) string {
+	line := fmt.Sprintf("%s Listening to: %s", data.prefix(), data.Work)
+	if data.Movement != "" {
+		line += " — " + data.Movement
+	}
+	
+	composer := data.Composer
+	if composer == "" {
+		composer = data.Artist
+	}
+	if composer != "" {
+		line += fmt.Sprintf(" (%s)", composer)
+	}
+	return line
+}
+
+// ArtistSeparator returns the word or symbol placed between title and artist
+func ArtistSeparator(cfg *config.Config) string {
+	if cfg.ArtistSeparator == "" {
+		return "by"
+	}
+	return cfg.ArtistSeparator
+}
+
+// Emoji picks the line's emoji: source_emoji, then type_emoji, then the
+// built-in type emoji, then default_emoji
+func Emoji(media *audio.MediaInfo, cfg *config.Config) string {
+	if emoji, ok := cfg.SourceEmoji[media.Source]; ok && emoji != "" {
+		return emoji
+	}
+	if emoji, ok := cfg.TypeEmoji[media.Type]; ok && emoji != "" {
+		return emoji
+	}
+	if emoji, ok := typeEmoji[media.Type]; ok {
+		return emoji
+	}
+	if cfg.DefaultEmoji != "" {
+		return cfg.DefaultEmoji
 	}
-	return fmt.Sprintf("🎵 Currently playing: \"%s\" (%s)", media.Title, media.Source)
-} 
\ No newline at end of file
+	return "🎵"
+}
\ No newline at end of file
@@ -0,0 +1,175 @@
+package format
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+	"github.com/pixare40/interactive-commit/internal/config"
+)
+
+func TestArtistSeparator(t *testing.T) {
+	tests := []struct {
+		name      string
+		separator string
+		media     audio.MediaInfo
+		want      string
+	}{
+		{"default", "", audio.MediaInfo{Title: "Nightcall", Artist: "Kavinsky", Source: "Spotify", Type: "song"}, `🎵 Currently playing: "Nightcall" by Kavinsky (Spotify)`},
+		{"em dash", "—", audio.MediaInfo{Title: "Nightcall", Artist: "Kavinsky", Source: "Spotify", Type: "song"}, `🎵 Currently playing: "Nightcall" — Kavinsky (Spotify)`},
+		{"other language", "von", audio.MediaInfo{Title: "Das Model", Artist: "Kraftwerk", Source: "Spotify", Type: "song"}, `🎵 Currently playing: "Das Model" von Kraftwerk (Spotify)`},
+		{"empty artist, default", "", audio.MediaInfo{Title: "Lo-fi beats to code to", Source: "YouTube", Type: "song"}, `🎵 Currently playing: "Lo-fi beats to code to" (YouTube)`},
+		{"empty artist, em dash", "—", audio.MediaInfo{Title: "Lo-fi beats to code to", Source: "YouTube", Type: "song"}, `🎵 Currently playing: "Lo-fi beats to code to" (YouTube)`},
+	}
+
+	for _, tt := range tests {
+		cfg := config.Default()
+		cfg.ArtistSeparator = tt.separator
+		media := tt.media
+		if got := FormatCommitMessage(&media, cfg); got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestArtistSeparatorInTemplate(t *testing.T) {
+	cfg := config.Default()
+	cfg.ArtistSeparator = "·"
+	cfg.Template = "{{.Title}} {{.Separator}} {{.Artist}}"
+
+	media := &audio.MediaInfo{Title: "Nightcall", Artist: "Kavinsky", Source: "Spotify", Type: "song"}
+	if got, want := FormatCommitMessage(media, cfg), "Nightcall · Kavinsky"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestIsCommitLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{`🎵 Currently playing: "Nightcall" by Kavinsky (Spotify)`, true},
+		{`🎵 Currently playing: "Nightcall" by Kavinsky (Spotify) from "Drive" — 128 BPM`, true},
+		{` Currently playing: "Nightcall" by Kavinsky (Spotify)`, true},
+		{`:notes: Currently playing: "Nightcall" by Kavinsky (Spotify)`, true},
+		{`![YouTube Music](https://img.shields.io/badge/YouTube_Music-FF0000) Currently playing: "Nightcall" (YouTube Music)`, true},
+		{"🎼 Listening to: Symphony No. 5 — Allegro con brio (Beethoven)", true},
+		{"♪ Nightcall ~ Kavinsky" + Marker, true},
+		{`Currently playing: "Nightcall" by Kavinsky (Spotify)`, true},
+		{"Listening to: Symphony No. 5 (Beethoven)", true},
+		{"Listening to: the reviewers, the parser now keeps tabs", false},
+		{"Notes: Listening to: the reviewers' feedback", false},
+		{"I was Listening to: music while fixing this", false},
+		{`Quote the user: Currently playing: "x" (y)`, false},
+		{"Fix the parser", false},
+	}
+	for _, tt := range tests {
+		if got := IsCommitLine(tt.line); got != tt.want {
+			t.Errorf("IsCommitLine(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestLyricFetchedOnlyWhenShown(t *testing.T) {
+	var fetches int
+	saved := fetchLyric
+	fetchLyric = func(ctx context.Context, provider, apiKey, title, artist string, duration time.Duration) (string, error) {
+		fetches++
+		return "I'm giving you a nightcall", nil
+	}
+	t.Cleanup(func() { fetchLyric = saved })
+
+	tests := []struct {
+		name     string
+		template string
+		want     int
+	}{
+		{"built-in", "", 1},
+		{"template without lyric", "♪ {{.Title}} ~ {{.Artist}}", 0},
+		{"template with lyric", "♪ {{.Title}} — {{.Lyric}}", 1},
+		{"lyric in a with", "♪ {{.Title}}{{with .Lyric}} — {{.}}{{end}}", 1},
+		{"lyric through $", "{{range .Artists}}{{$.Lyric}}{{end}}", 1},
+		{"broken template falls back to built-in", "{{.Title", 1},
+	}
+	for _, tt := range tests {
+		fetches = 0
+		cfg := config.Default()
+		cfg.IncludeLyrics = true
+		cfg.Template = tt.template
+		FormatCommitMessage(&audio.MediaInfo{Title: "Nightcall", Artist: "Kavinsky", Artists: []string{"Kavinsky"}, Source: "Spotify", Type: "song"}, cfg)
+		if fetches != tt.want {
+			t.Errorf("%s: fetched the lyric %d times, want %d", tt.name, fetches, tt.want)
+		}
+	}
+}
+
+func TestEmojiPrecedence(t *testing.T) {
+	cfg := config.Default()
+	cfg.SourceEmoji = map[string]string{"Spotify": "🟢"}
+	cfg.TypeEmoji = map[string]string{"podcast": "📻", "video": ""}
+	cfg.DefaultEmoji = "🎶"
+
+	tests := []struct {
+		name  string
+		media audio.MediaInfo
+		want  string
+	}{
+		{"source wins over type", audio.MediaInfo{Source: "Spotify", Type: "podcast"}, "🟢"},
+		{"type override", audio.MediaInfo{Source: "Pocket Casts", Type: "podcast"}, "📻"},
+		{"empty type override keeps the built-in", audio.MediaInfo{Source: "YouTube", Type: "video"}, "🎬"},
+		{"built-in type", audio.MediaInfo{Source: "Audible", Type: "audiobook"}, "📖"},
+		{"configured default", audio.MediaInfo{Source: "MPD", Type: "song"}, "🎶"},
+	}
+	for _, tt := range tests {
+		media := tt.media
+		if got := Emoji(&media, cfg); got != tt.want {
+			t.Errorf("%s: Emoji = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	cfg.DefaultEmoji = ""
+	if got := Emoji(&audio.MediaInfo{Source: "MPD", Type: "song"}, cfg); got != "🎵" {
+		t.Errorf("without any configured emoji: %q, want 🎵", got)
+	}
+}
+
+func TestTrackID(t *testing.T) {
+	cfg := config.Default()
+	cfg.Template = "{{.TrackID}}"
+	id := func(media audio.MediaInfo) string {
+		return FormatCommitMessage(&media, cfg)
+	}
+
+	base := audio.MediaInfo{Title: "Nightcall", Artist: "Kavinsky", Source: "Spotify", Type: "song"}
+	want := id(base)
+	if len(want) != 8 {
+		t.Fatalf("TrackID = %q, want 8 hex digits", want)
+	}
+
+	same := []audio.MediaInfo{
+		{Title: "Nightcall", Artist: "Kavinsky", Source: "Spotify", Type: "song", Position: 3 * time.Minute, Duration: 4 * time.Minute},
+		{Title: "Nightcall", Artist: "Kavinsky", Source: "Spotify", Type: "song", Volume: 20, Muted: true},
+		{Title: "NIGHTCALL", Artist: " Kavinsky ", Source: "YouTube Music", Type: "song"},
+	}
+	for _, media := range same {
+		if got := id(media); got != want {
+			t.Errorf("TrackID for %+v = %q, want %q", media, got, want)
+		}
+	}
+
+	different := []audio.MediaInfo{
+		{Title: "Nightcall", Artist: "London Grammar", Source: "Spotify", Type: "song"},
+		{Title: "Odd Look", Artist: "Kavinsky", Source: "Spotify", Type: "song"},
+	}
+	for _, media := range different {
+		if got := id(media); got == want {
+			t.Errorf("TrackID for %q by %q matches Nightcall by Kavinsky", media.Title, media.Artist)
+		}
+	}
+
+	// NFC and NFD spellings of the same title agree
+	if TrackID("Café", "Kavinsky") != TrackID("Café", "Kavinsky") {
+		t.Error("TrackID depends on Unicode composition")
+	}
+}
@@ -0,0 +1,98 @@
+package format
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pixare40/interactive-commit/internal/gitutil"
+)
+
+// languages maps file extensions to the language they're written in. Docs,
+// data and config files are left out so they don't outvote the code.
+var languages = map[string]string{
+	".go":     "Go",
+	".py":     "Python",
+	".js":     "JavaScript",
+	".jsx":    "JavaScript",
+	".mjs":    "JavaScript",
+	".cjs":    "JavaScript",
+	".ts":     "TypeScript",
+	".tsx":    "TypeScript",
+	".rs":     "Rust",
+	".java":   "Java",
+	".kt":     "Kotlin",
+	".kts":    "Kotlin",
+	".scala":  "Scala",
+	".swift":  "Swift",
+	".m":      "Objective-C",
+	".c":      "C",
+	".h":      "C",
+	".cc":     "C++",
+	".cpp":    "C++",
+	".cxx":    "C++",
+	".hpp":    "C++",
+	".cs":     "C#",
+	".fs":     "F#",
+	".rb":     "Ruby",
+	".php":    "PHP",
+	".sh":     "Shell",
+	".bash":   "Shell",
+	".zsh":    "Shell",
+	".ps1":    "PowerShell",
+	".html":   "HTML",
+	".css":    "CSS",
+	".scss":   "CSS",
+	".vue":    "Vue",
+	".svelte": "Svelte",
+	".sql":    "SQL",
+	".lua":    "Lua",
+	".dart":   "Dart",
+	".ex":     "Elixir",
+	".exs":    "Elixir",
+	".erl":    "Erlang",
+	".hs":     "Haskell",
+	".ml":     "OCaml",
+	".clj":    "Clojure",
+	".r":      "R",
+	".jl":     "Julia",
+	".pl":     "Perl",
+	".zig":    "Zig",
+	".nim":    "Nim",
+	".tf":     "Terraform",
+}
+
+// stagedLanguage names the language of the files staged for commit. Like the
+// rest of the line's extras it goes without when git can't say.
+func stagedLanguage() string {
+	output, err := gitutil.Output("diff", "--cached", "--name-only", "-z")
+	if err != nil {
+		return ""
+	}
+	return PrimaryLanguage(strings.Split(output, "\x00"))
+}
+
+// PrimaryLanguage names the language most of the files are written in, by
+// extension. Ties go to the language first in alphabetical order.
+func PrimaryLanguage(files []string) string {
+	counts := map[string]int{}
+	for _, file := range files {
+		if language, ok := languages[strings.ToLower(path.Ext(file))]; ok {
+			counts[language]++
+		}
+	}
+
+	var names []string
+	for language := range counts {
+		names = append(names, language)
+	}
+	sort.Strings(names)
+
+	primary := ""
+	for _, language := range names {
+		if counts[language] > counts[primary] {
+			primary = language
+		}
+	}
+	return primary
+}
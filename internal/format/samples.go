@@ -0,0 +1,50 @@
+package format
+
+import (
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+)
+
+// Sample is a representative track used to preview templates
+type Sample struct {
+	Name  string
+	Media *audio.MediaInfo
+}
+
+// Samples returns tracks covering the edge cases templates need to handle
+func Samples() []Sample {
+	return []Sample{
+		{"Song with artist", &audio.MediaInfo{
+			Title: "Bohemian Rhapsody", Artist: "Queen", Artists: []string{"Queen"}, Album: "A Night at the Opera",
+			Source: "Spotify", Type: "song", Duration: 5*time.Minute + 55*time.Second,
+		}},
+		{"Several artists", &audio.MediaInfo{
+			Title: "Get Lucky", Artist: "Daft Punk, Pharrell Williams, Nile Rodgers",
+			Artists: []string{"Daft Punk", "Pharrell Williams", "Nile Rodgers"},
+			Album:   "Random Access Memories", Source: "Spotify", Type: "song",
+		}},
+		{"Song without artist", &audio.MediaInfo{
+			Title: "Lo-fi beats to code to", Source: "YouTube", Type: "video",
+		}},
+		{"Podcast episode", &audio.MediaInfo{
+			Title: "The Changelog #423: Building Better APIs", Artist: "Changelog Media",
+			Album: "The Changelog", Source: "Apple Music", Type: "podcast", Duration: 74 * time.Minute,
+		}},
+		{"Livestream", &audio.MediaInfo{
+			Title: "lofi hip hop radio 📚 beats to relax/study to", Artist: "Lofi Girl", Artists: []string{"Lofi Girl"},
+			Source: "YouTube", Type: "livestream",
+		}},
+		{"Long title", &audio.MediaInfo{
+			Title:  "Symphony No. 9 in D Minor, Op. 125 \"Choral\": IV. Presto - Allegro assai - Allegro molto - Presto",
+			Artist: "Wiener Philharmoniker", Artists: []string{"Wiener Philharmoniker"}, Composer: "Ludwig van Beethoven", Source: "Apple Music", Type: "classical",
+			Work: "Symphony No. 9 in D Minor, Op. 125 \"Choral\"", Movement: "IV. Presto - Allegro assai - Allegro molto - Presto",
+		}},
+		{"Emoji title", &audio.MediaInfo{
+			Title: "🔥 Fire 🔥", Artist: "DJ Emoji", Artists: []string{"DJ Emoji"}, Album: "🎧", Source: "SoundCloud", Type: "song",
+		}},
+		{"Missing album", &audio.MediaInfo{
+			Title: "Kerala", Artist: "Bonobo", Artists: []string{"Bonobo"}, Source: "MPRIS", Type: "song",
+		}},
+	}
+}
@@ -0,0 +1,39 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pixare40/interactive-commit/internal/config"
+)
+
+func TestSamplesFormatCleanly(t *testing.T) {
+	templated := config.Default()
+	templated.Template = `{{.Emoji}} "{{.Title}}"{{with .Artist}} {{$.Separator}} {{.}}{{end}} ({{.Source}}) {{join .Artists " & "}}`
+
+	for _, cfg := range []*config.Config{config.Default(), templated} {
+		for _, sample := range Samples() {
+			line := FormatCommitMessage(sample.Media, cfg)
+			if line == "" || strings.Contains(line, "<no value>") || strings.Contains(line, "\n") {
+				t.Errorf("%s: %q", sample.Name, line)
+			}
+			// The built-in classical line names the composer instead of the source
+			if cfg.Template != "" && !strings.Contains(line, sample.Media.Source) {
+				t.Errorf("%s: the source is missing from %q", sample.Name, line)
+			}
+		}
+	}
+}
+
+func TestSamplesPassValidation(t *testing.T) {
+	cfg := config.Default()
+	for _, sample := range Samples() {
+		line := FormatCommitMessage(sample.Media, cfg)
+		if !IsCommitLine(line) {
+			t.Errorf("%s: %q isn't recognized as a commit line", sample.Name, line)
+		}
+		if err := ValidateLine(line+Marker, cfg); err != nil {
+			t.Errorf("%s: %q: %v", sample.Name, line, err)
+		}
+	}
+}
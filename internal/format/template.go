@@ -0,0 +1,233 @@
+package format
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+	"github.com/pixare40/interactive-commit/internal/config"
+	"github.com/pixare40/interactive-commit/internal/history"
+	"github.com/pixare40/interactive-commit/internal/lyrics"
+	"golang.org/x/text/unicode/norm"
+)
+
+// TemplateData is what commit line templates are rendered against.
+// All MediaInfo fields are available directly, e.g. {{.Title}} or {{.Artist}}.
+type TemplateData struct {
+	*audio.MediaInfo
+	Emoji     string
+	Separator string // The configured artist separator, e.g. "by"
+
+	// Badge is a markdown service badge when badges are enabled and the source has one
+	Badge string
+
+	// TrackCommitCount counts commits made to this track, including this one.
+	// Zero when history is disabled.
+	TrackCommitCount int
+
+	// With history enabled, the listening session this commit ends: SessionDuration
+	// ("47m"), SessionTracks (12) and Session ("coded for 47m to 12 tracks").
+	// Empty when there's no earlier commit in the session.
+	SessionDuration string
+	SessionTracks   int
+	Session         string
+
+	// TimeOfDay names the part of the day ("morning", ..., "late-night") and
+	// ClockTime is the local time ("23:14") when the commit is made
+	TimeOfDay string
+	ClockTime string
+
+	// Lyric is the first line of the song's lyrics, with include_lyrics
+	Lyric string
+
+	// PrimaryLanguage is the language most of the staged files are written in
+	// ("Go"), with include_language. Empty when none is recognized.
+	PrimaryLanguage string
+
+	// TrackID is a short hash of the title and artist ("3f9a0c1e"), the same
+	// for every commit to a track, for grouping commits without naming it
+	TrackID string
+}
+
+// newTemplateData gathers everything a commit line can show about media. The
+// lyric, a network lookup, is only fetched when withLyric says the line shows it.
+func newTemplateData(media *audio.MediaInfo, cfg *config.Config, withLyric bool) TemplateData {
+	now := Now()
+	data := TemplateData{
+		MediaInfo: media,
+		Emoji:     Emoji(media, cfg),
+		Separator: ArtistSeparator(cfg),
+		TimeOfDay: timeOfDay(now, cfg.TimeOfDayBuckets),
+		ClockTime: now.Format("15:04"),
+		TrackID:   TrackID(media.Title, media.Artist),
+	}
+
+	data.Badge = badge(data, cfg)
+	if withLyric {
+		data.Lyric = lyricSnippet(media, cfg)
+	}
+	if cfg.IncludeLanguage {
+		data.PrimaryLanguage = stagedLanguage()
+	}
+
+	if cfg.History {
+		if count, err := history.CountTrack(media.Title, media.Artist); err == nil {
+			data.TrackCommitCount = count + 1
+		}
+
+		gap := time.Duration(cfg.SessionGapMinutes) * time.Minute
+		if session, ok := history.CurrentSession(now, gap, media.Title, media.Artist); ok {
+			data.SessionDuration = formatSessionDuration(now.Sub(session.Start))
+			data.SessionTracks = session.Tracks
+			data.Session = fmt.Sprintf("coded for %s to %d tracks", data.SessionDuration, session.Tracks)
+		}
+	}
+
+	return data
+}
+
+// TrackID hashes a track's title and artist into 8 hex digits. Case, spacing
+// and Unicode composition don't change it, so the same song reported slightly
+// differently by two players gets the same ID.
+func TrackID(title, artist string) string {
+	sum := sha256.Sum256([]byte(trackIDField(title) + "\x00" + trackIDField(artist)))
+	return hex.EncodeToString(sum[:4])
+}
+
+// trackIDField normalizes one field for TrackID
+func trackIDField(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(norm.NFC.String(value)), " "))
+}
+
+// fetchLyric looks up a lyric snippet; tests replace it to stay offline
+var fetchLyric = lyrics.Snippet
+
+// lyricTimeout bounds the lyrics lookup, which the commit waits for
+const lyricTimeout = 2 * time.Second
+
+// lyricSnippet looks up the first line of a song's lyrics when include_lyrics
+// is set. The line simply goes without when the lookup fails.
+func lyricSnippet(media *audio.MediaInfo, cfg *config.Config) string {
+	if !cfg.IncludeLyrics || media.Type != "song" || (media.Explicit && cfg.RedactExplicit) {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lyricTimeout)
+	defer cancel()
+
+	snippet, _ := fetchLyric(ctx, cfg.Lyrics.Provider, cfg.Lyrics.APIKey, media.Title, media.Artist, media.Duration)
+	return snippet
+}
+
+// formatSessionDuration renders a session length as "47m" or "2h5m"
+func formatSessionDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// templateFuncs are available to commit line templates, e.g. {{join .Artists " & "}}
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// CompileTemplate parses a text/template commit line template
+func CompileTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("commit").Option("missingkey=error").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Render executes a compiled template against the media info
+func Render(tmpl *template.Template, media *audio.MediaInfo, cfg *config.Config) (string, error) {
+	return render(tmpl, newTemplateData(media, cfg, usesField(tmpl, "Lyric")))
+}
+
+// usesField reports whether any of tmpl's templates reads the named field, or
+// prints the whole data with {{.}}
+func usesField(tmpl *template.Template, field string) bool {
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil && nodeUsesField(t.Tree.Root, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeUsesField is usesField for one node of a template's parse tree
+func nodeUsesField(node parse.Node, field string) bool {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return false
+		}
+		for _, child := range node.Nodes {
+			if nodeUsesField(child, field) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return nodeUsesField(node.Pipe, field)
+	case *parse.IfNode:
+		return nodeUsesField(node.Pipe, field) || nodeUsesField(node.List, field) || nodeUsesField(node.ElseList, field)
+	case *parse.RangeNode:
+		return nodeUsesField(node.Pipe, field) || nodeUsesField(node.List, field) || nodeUsesField(node.ElseList, field)
+	case *parse.WithNode:
+		return nodeUsesField(node.Pipe, field) || nodeUsesField(node.List, field) || nodeUsesField(node.ElseList, field)
+	case *parse.TemplateNode:
+		return nodeUsesField(node.Pipe, field)
+	case *parse.PipeNode:
+		if node == nil {
+			return false
+		}
+		for _, cmd := range node.Cmds {
+			if nodeUsesField(cmd, field) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			if nodeUsesField(arg, field) {
+				return true
+			}
+		}
+	case *parse.FieldNode:
+		return slices.Contains(node.Ident, field)
+	case *parse.VariableNode:
+		return slices.Contains(node.Ident, field)
+	case *parse.ChainNode:
+		return nodeUsesField(node.Node, field) || slices.Contains(node.Field, field)
+	case *parse.DotNode:
+		return true
+	}
+	return false
+}
+
+// prefix starts the built-in commit lines: the badge when there is one, else the emoji
+func (d TemplateData) prefix() string {
+	if d.Badge != "" {
+		return d.Badge
+	}
+	return d.Emoji
+}
+
+func render(tmpl *template.Template, data TemplateData) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+
+	// The result is a single commit line, so fold any newlines the template produced
+	return strings.Join(strings.Fields(sb.String()), " "), nil
+}
@@ -0,0 +1,50 @@
+package format
+
+import (
+	"sort"
+	"time"
+)
+
+// Now is the formatter's clock, for time-of-day and session placeholders.
+// Tests and previews can replace it to render a fixed moment.
+var Now = time.Now
+
+// defaultTimeBuckets name parts of the day by the hour they start at
+var defaultTimeBuckets = map[string]int{
+	"morning":    5,
+	"afternoon":  12,
+	"evening":    17,
+	"late-night": 22,
+}
+
+// timeOfDay names the bucket t falls in: the one that started most recently,
+// wrapping around midnight, so 02:00 is still "late-night" by default
+func timeOfDay(t time.Time, buckets map[string]int) string {
+	if len(buckets) == 0 {
+		buckets = defaultTimeBuckets
+	}
+
+	type bucket struct {
+		name  string
+		start int
+	}
+	sorted := make([]bucket, 0, len(buckets))
+	for name, start := range buckets {
+		sorted = append(sorted, bucket{name, start})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].start != sorted[j].start {
+			return sorted[i].start < sorted[j].start
+		}
+		return sorted[i].name < sorted[j].name
+	})
+
+	current := sorted[len(sorted)-1].name // Before the first start, the last bucket carries over
+	for _, b := range sorted {
+		if b.start > t.Hour() {
+			break
+		}
+		current = b.name
+	}
+	return current
+}
@@ -0,0 +1,44 @@
+package format
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+)
+
+// TrailerKey is the git trailer carrying machine-readable media info
+const TrailerKey = "X-Now-Playing"
+
+// PlayedOnKey is the git trailer naming the OS and source a track was heard on
+const PlayedOnKey = "Played-On"
+
+// SoundtrackKey is the git trailer holding the soundtrack line when
+// smart_placement moves it below a body
+const SoundtrackKey = "Soundtrack"
+
+// MachineTrailer encodes media as URL-safe base64 (no padding) of compact JSON.
+// The alphabet contains no spaces or colons, so the value survives
+// `git interpret-trailers` untouched.
+func MachineTrailer(media *audio.MediaInfo) (string, error) {
+	data, err := json.Marshal(media)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeMachineTrailer reverses MachineTrailer
+func DecodeMachineTrailer(value string) (*audio.MediaInfo, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s trailer: %w", TrailerKey, err)
+	}
+
+	var media audio.MediaInfo
+	if err := json.Unmarshal(data, &media); err != nil {
+		return nil, fmt.Errorf("invalid %s trailer: %w", TrailerKey, err)
+	}
+	return &media, nil
+}
@@ -0,0 +1,119 @@
+package format
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template/parse"
+
+	"github.com/pixare40/interactive-commit/internal/config"
+)
+
+// ErrMalformedLine is returned for a soundtrack line that no configured format
+// could have written, e.g. after a manual edit cut it short
+var ErrMalformedLine = errors.New("soundtrack line doesn't match the configured format")
+
+// builtinLine matches every variant of the built-in format: an optional
+// emoji or badge, the track, and the optional playlist, language, vibe,
+// lyric and commit count. Classical lines are "Listening to: Work ...".
+var builtinLine = regexp.MustCompile(`^(?:.* )?(?:Currently playing: ".+"(?: .+)? \(.+\)(?: from ".*")?(?: while coding .+)?(?: — .+)*|Listening to: .+)$`)
+
+// ValidateLine checks that line could have been written with cfg: by the
+// built-in format, the template, any preset's template, or as the silence or
+// still-playing text. A trailing marker and surrounding space are ignored.
+func ValidateLine(line string, cfg *config.Config) error {
+	line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), Marker))
+	if line == "" {
+		return fmt.Errorf("%w: the line is empty", ErrMalformedLine)
+	}
+	if cfg.SilenceText != "" && line == cfg.SilenceText {
+		return nil
+	}
+	if cfg.StillPlayingText != "" && line == cfg.StillPlayingText {
+		return nil
+	}
+	if builtinLine.MatchString(line) {
+		return nil
+	}
+
+	templates := []string{cfg.Template}
+	for _, preset := range cfg.Presets {
+		templates = append(templates, preset.Template)
+	}
+	for _, text := range templates {
+		if text == "" {
+			continue
+		}
+		// A broken template falls back to the built-in format, checked above
+		if pattern, err := TemplatePattern(text); err == nil && pattern.MatchString(line) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrMalformedLine, line)
+}
+
+// whitespaceRun is a run of the whitespace render folds
+var whitespaceRun = regexp.MustCompile(`\s+`)
+
+// TemplatePattern turns a commit line template into a regexp matching any
+// line it can render. Text outside actions must appear as written; actions
+// match any text, and the branches of if, with and range are all allowed.
+func TemplatePattern(text string) (*regexp.Regexp, error) {
+	tmpl, err := CompileTemplate(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`^\s*`)
+	writeNodePattern(&sb, tmpl.Tree.Root)
+	sb.WriteString(`\s*$`)
+	return regexp.Compile(sb.String())
+}
+
+// writeNodePattern appends the pattern for one template node
+func writeNodePattern(sb *strings.Builder, node parse.Node) {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, child := range node.Nodes {
+			writeNodePattern(sb, child)
+		}
+	case *parse.TextNode:
+		// render folds each run of whitespace into one space, which may go
+		// entirely next to an action that rendered empty
+		text := string(node.Text)
+		last := 0
+		for _, run := range whitespaceRun.FindAllStringIndex(text, -1) {
+			sb.WriteString(regexp.QuoteMeta(text[last:run[0]]))
+			sb.WriteString(` ?`)
+			last = run[1]
+		}
+		sb.WriteString(regexp.QuoteMeta(text[last:]))
+	case *parse.ActionNode, *parse.TemplateNode:
+		sb.WriteString(`.*?`)
+	case *parse.IfNode:
+		writeBranchPattern(sb, node.List, node.ElseList, false)
+	case *parse.WithNode:
+		writeBranchPattern(sb, node.List, node.ElseList, false)
+	case *parse.RangeNode:
+		writeBranchPattern(sb, node.List, node.ElseList, true)
+	}
+}
+
+// writeBranchPattern appends either branch of a conditional; the body of a
+// range may repeat any number of times
+func writeBranchPattern(sb *strings.Builder, list, elseList *parse.ListNode, repeat bool) {
+	sb.WriteString(`(?:(?:`)
+	writeNodePattern(sb, list)
+	sb.WriteString(`)`)
+	if repeat {
+		sb.WriteString(`*`)
+	}
+	sb.WriteString(`|`)
+	writeNodePattern(sb, elseList)
+	sb.WriteString(`)`)
+}
@@ -0,0 +1,49 @@
+package format
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pixare40/interactive-commit/internal/config"
+)
+
+func TestValidateLine(t *testing.T) {
+	cfg := config.Default()
+	cfg.SilenceText = "🔇 Committed in silence"
+	cfg.StillPlayingText = "🎵 (still playing)"
+
+	templated := config.Default()
+	templated.Template = "♪ {{.Title}} ~ {{.Artist}}"
+
+	spaced := config.Default()
+	spaced.Template = "♪  {{.Title}}\n\t~ {{.Artist}} {{.Album}} !"
+
+	tests := []struct {
+		name string
+		cfg  *config.Config
+		line string
+		ok   bool
+	}{
+		{"built-in", cfg, `🎵 Currently playing: "Nightcall" by Kavinsky (Spotify)`, true},
+		{"built-in with marker", cfg, `🎵 Currently playing: "Nightcall" by Kavinsky (Spotify)` + Marker, true},
+		{"silence text", cfg, "🔇 Committed in silence" + Marker, true},
+		{"still playing text", cfg, "🎵 (still playing)" + Marker, true},
+		{"still playing text unset", config.Default(), "🎵 (still playing)" + Marker, false},
+		{"cut short", cfg, `🎵 Currently playing: "Nightcall`, false},
+		{"template", templated, "♪ Nightcall ~ Kavinsky", true},
+		{"template edited", templated, "♪ Nightcall by Kavinsky", false},
+		{"template whitespace folded", spaced, "♪ Nightcall ~ Kavinsky OutRun !", true},
+		{"template empty action folded", spaced, "♪ Nightcall ~ Kavinsky !", true},
+		{"template whitespace edited", spaced, "♪ Nightcall by Kavinsky !", false},
+		{"empty", cfg, Marker, false},
+	}
+	for _, tt := range tests {
+		err := ValidateLine(tt.line, tt.cfg)
+		if tt.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrMalformedLine) {
+			t.Errorf("%s: err = %v, want ErrMalformedLine", tt.name, err)
+		}
+	}
+}
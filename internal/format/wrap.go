@@ -0,0 +1,62 @@
+package format
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Wrap word-wraps a commit line to width columns, counting runes rather than
+// bytes, so git's 72-column body convention holds for long titles. The
+// leading emoji stays with the first word; continuation lines go without.
+// Words longer than width, like URLs, are kept whole. Zero or less disables
+// wrapping.
+func Wrap(line string, width int) string {
+	words := strings.Fields(line)
+	if width <= 0 || len(words) < 2 || columns(line) <= width {
+		return line
+	}
+
+	// The emoji is never left alone on the first line
+	if isPrefix(words[0]) {
+		words = append([]string{words[0] + " " + words[1]}, words[2:]...)
+	}
+
+	var lines []string
+	current := words[0]
+	for _, word := range words[1:] {
+		if columns(current)+1+columns(word) > width {
+			lines = append(lines, current)
+			current = word
+			continue
+		}
+		current += " " + word
+	}
+	lines = append(lines, current)
+	return strings.Join(lines, "\n")
+}
+
+// columns counts the runes of s that take up space, leaving out zero-width
+// ones like the marker and emoji variation selectors
+func columns(s string) int {
+	n := 0
+	for _, r := range s {
+		if !unicode.In(r, unicode.Cf, unicode.Mn) {
+			n++
+		}
+	}
+	return n
+}
+
+// isPrefix reports whether word is a line's leading emoji or badge rather
+// than a word of text
+func isPrefix(word string) bool {
+	if strings.HasPrefix(word, "[![") {
+		return true
+	}
+	for _, r := range word {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		width int
+		want  string
+	}{
+		{"disabled", "🎵 aaa bbb ccc", 0, "🎵 aaa bbb ccc"},
+		{"exactly the width", "aaaa bbbb", 9, "aaaa bbbb"},
+		{"one over the width", "aaaa bbbbb", 9, "aaaa\nbbbbb"},
+		{"word longer than the width kept whole", "see https://example.com/a/very/long/path here", 10, "see\nhttps://example.com/a/very/long/path\nhere"},
+		{"emoji stays with the first word", "🎵 Currently playing", 10, "🎵 Currently\nplaying"},
+		{"multibyte runes count once", "éééé éééé", 9, "éééé éééé"},
+		{"marker takes no columns", "aaaa bbbb" + Marker, 9, "aaaa bbbb" + Marker},
+	}
+
+	for _, tt := range tests {
+		if got := Wrap(tt.line, tt.width); got != tt.want {
+			t.Errorf("%s: Wrap(%q, %d) = %q, want %q", tt.name, tt.line, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestWrapLongEpisode(t *testing.T) {
+	line := `🎙️ Currently playing: "Episode 212: Why Every Über-Engineered Système Eventually Collapses Under Its Own Weight" by Café Conversations (Pocket Casts)`
+	wrapped := Wrap(line, 72)
+
+	lines := strings.Split(wrapped, "\n")
+	if len(lines) < 2 {
+		t.Fatalf("not wrapped: %q", wrapped)
+	}
+	for _, l := range lines {
+		if columns(l) > 72 {
+			t.Errorf("line over 72 columns (%d): %q", columns(l), l)
+		}
+	}
+	if !strings.HasPrefix(lines[0], "🎙️ Currently") || strings.Contains(strings.Join(lines[1:], "\n"), "🎙️") {
+		t.Errorf("the emoji should lead the first line only:\n%s", wrapped)
+	}
+	if strings.Join(strings.Fields(wrapped), " ") != line {
+		t.Errorf("wrapping changed the words:\n%s", wrapped)
+	}
+}
@@ -0,0 +1,104 @@
+// Package gitutil runs the git queries shared across commands. Answers are
+// cached for the life of the process: a single hook run asks for the top
+// level, git directory and a handful of config values, none of which change
+// while it runs.
+package gitutil
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+var (
+	mu      sync.Mutex
+	answers = map[string]answer{}
+)
+
+// answer is the cached result of one git query
+type answer struct {
+	output string
+	err    error
+}
+
+// Output runs a read-only git command and returns its output, trimmed.
+// Failures carry git's own message.
+func Output(args ...string) (string, error) {
+	key := strings.Join(args, "\x00")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if cached, ok := answers[key]; ok {
+		return cached.output, cached.err
+	}
+
+	output, err := run(args...)
+	answers[key] = answer{output: output, err: err}
+	return output, err
+}
+
+// Run runs a git command that changes something, uncached. Cached answers
+// are dropped since they may no longer hold.
+func Run(args ...string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	answers = map[string]answer{}
+
+	_, err := run(args...)
+	return err
+}
+
+// Forget drops the cached answers, for a process that moves to another
+// repository, such as a test
+func Forget() {
+	mu.Lock()
+	defer mu.Unlock()
+	answers = map[string]answer{}
+}
+
+func run(args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// RevParse runs git rev-parse with args
+func RevParse(args ...string) (string, error) {
+	return Output(append([]string{"rev-parse"}, args...)...)
+}
+
+// InsideWorkTree reports whether the current directory is in a git working
+// tree, worktrees and submodules included
+func InsideWorkTree() bool {
+	output, err := RevParse("--is-inside-work-tree")
+	return err == nil && output == "true"
+}
+
+// Config reads a git config value; ok is false when it isn't set
+func Config(key string) (value string, ok bool) {
+	value, err := Output("config", key)
+	return value, err == nil
+}
+
+// ConfigPath reads a path-valued git config value, with "~/" expanded by git
+func ConfigPath(key string) (value string, ok bool) {
+	value, err := Output("config", "--path", key)
+	return value, err == nil
+}
+
+// GlobalConfig reads a value from the user's global git config
+func GlobalConfig(key string) (value string, ok bool) {
+	value, err := Output("config", "--global", key)
+	return value, err == nil
+}
@@ -0,0 +1,180 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+)
+
+// Entry records the track that was playing when a commit was tagged
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Title  string    `json:"title"`
+	Artist string    `json:"artist,omitempty"`
+	Album  string    `json:"album,omitempty"`
+	Source string    `json:"source,omitempty"`
+	Type   string    `json:"type,omitempty"`
+	Repo   string    `json:"repo,omitempty"` // The repository's directory name, for display
+
+	// RepoPath is the repository's top-level directory, telling apart
+	// repositories with the same name
+	RepoPath string `json:"repo_path,omitempty"`
+
+	// TrackURI identifies the track at its service, e.g. "spotify:track:..."
+	TrackURI string `json:"track_uri,omitempty"`
+}
+
+// NewEntry builds a history entry for media tagged now in the repository
+// whose top-level directory is repoPath
+func NewEntry(media *audio.MediaInfo, repoPath string) Entry {
+	var repo string
+	if repoPath != "" {
+		repo = filepath.Base(repoPath)
+	}
+	return Entry{
+		Time:     time.Now(),
+		Title:    media.Title,
+		Artist:   media.Artist,
+		Album:    media.Album,
+		Source:   media.Source,
+		Type:     media.Type,
+		Repo:     repo,
+		RepoPath: repoPath,
+
+		TrackURI: media.TrackURI,
+	}
+}
+
+// Path returns the location of the JSONL history file
+func Path() (string, error) {
+	dataDir := os.Getenv("XDG_DATA_HOME")
+	if dataDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataDir = filepath.Join(homeDir, ".local", "share")
+	}
+
+	return filepath.Join(dataDir, "interactive-commit", "history.jsonl"), nil
+}
+
+// Append adds an entry to the end of the history file
+func Append(entry Entry) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	return nil
+}
+
+// Load reads all entries recorded at or after since.
+// A missing history file simply yields no entries.
+func Load(since time.Time) ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // Skip corrupt lines rather than losing the whole history
+		}
+		if entry.Time.Before(since) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	return entries, nil
+}
+
+// Last returns the most recent entry recorded for the repository whose
+// top-level directory is repoPath; ok is false when there is none
+func Last(repoPath string) (entry Entry, ok bool) {
+	entries, err := Load(time.Time{})
+	if err != nil {
+		return Entry{}, false
+	}
+	return last(entries, repoPath)
+}
+
+func last(entries []Entry, repoPath string) (entry Entry, ok bool) {
+	for _, e := range entries {
+		if e.RepoPath == repoPath && !e.Time.Before(entry.Time) {
+			entry, ok = e, true
+		}
+	}
+	return entry, ok
+}
+
+// IsTrack reports whether the entry was recorded for media's track: the same
+// service URI when both have one, otherwise the same title and artist
+func (e Entry) IsTrack(media *audio.MediaInfo) bool {
+	if e.TrackURI != "" && media.TrackURI != "" {
+		return e.TrackURI == media.TrackURI
+	}
+	return sameTrack(e.Title, e.Artist, media.Title, media.Artist)
+}
+
+// CountTrack returns how many recorded commits were tagged with this title and artist
+func CountTrack(title, artist string) (int, error) {
+	entries, err := Load(time.Time{})
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if sameTrack(entry.Title, entry.Artist, title, artist) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// sameTrack compares tracks ignoring case and surrounding whitespace
+func sameTrack(titleA, artistA, titleB, artistB string) bool {
+	return strings.EqualFold(strings.TrimSpace(titleA), strings.TrimSpace(titleB)) &&
+		strings.EqualFold(strings.TrimSpace(artistA), strings.TrimSpace(artistB))
+}
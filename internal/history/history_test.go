@@ -0,0 +1,107 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+)
+
+func TestLastTellsApartReposWithTheSameName(t *testing.T) {
+	now := time.Now()
+	entries := []Entry{
+		{Time: now.Add(-3 * time.Minute), Title: "Nightcall", Artist: "Kavinsky", Repo: "api", RepoPath: "/work/api"},
+		{Time: now.Add(-2 * time.Minute), Title: "Midnight City", Artist: "M83", Repo: "api", RepoPath: "/home/me/api"},
+		{Time: now.Add(-1 * time.Minute), Title: "Genesis", Artist: "Justice", Repo: "web", RepoPath: "/work/web"},
+	}
+
+	entry, ok := last(entries, "/work/api")
+	if !ok || entry.Title != "Nightcall" {
+		t.Errorf("last(/work/api) = %+v, %v; want Nightcall", entry, ok)
+	}
+	entry, ok = last(entries, "/home/me/api")
+	if !ok || entry.Title != "Midnight City" {
+		t.Errorf("last(/home/me/api) = %+v, %v; want Midnight City", entry, ok)
+	}
+	if _, ok := last(entries, "/elsewhere/api"); ok {
+		t.Error("found an entry for a repository with no history")
+	}
+	if _, ok := last(nil, "/work/api"); ok {
+		t.Error("found an entry in an empty history")
+	}
+}
+
+func TestLastWithoutReadableHistory(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	if _, ok := Last("/work/api"); ok {
+		t.Error("found an entry with no history file")
+	}
+
+	path, err := Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("not json\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Last("/work/api"); ok {
+		t.Error("found an entry in a history of corrupt lines")
+	}
+
+	// Corrupt lines are skipped, not the entries around them
+	if err := Append(Entry{Time: time.Now(), Title: "Nightcall", Artist: "Kavinsky", RepoPath: "/work/api"}); err != nil {
+		t.Fatal(err)
+	}
+	if entry, ok := Last("/work/api"); !ok || entry.Title != "Nightcall" {
+		t.Errorf("Last() = %+v, %v; want Nightcall past the corrupt lines", entry, ok)
+	}
+
+	// A history that can't be read at all is no history
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Last("/work/api"); ok {
+		t.Error("found an entry in an unreadable history")
+	}
+}
+
+func TestIsTrack(t *testing.T) {
+	entry := Entry{Title: "Nightcall", Artist: "Kavinsky"}
+	tests := []struct {
+		name  string
+		entry Entry
+		media audio.MediaInfo
+		want  bool
+	}{
+		{"same track", entry, audio.MediaInfo{Title: "Nightcall", Artist: "Kavinsky"}, true},
+		{"case and spacing", entry, audio.MediaInfo{Title: " nightcall", Artist: "KAVINSKY "}, true},
+		{"different track", entry, audio.MediaInfo{Title: "Odd Look", Artist: "Kavinsky"}, false},
+		{"different artist", entry, audio.MediaInfo{Title: "Nightcall", Artist: "London Grammar"}, false},
+		{"same URI", Entry{Title: "Nightcall", TrackURI: "spotify:track:1"}, audio.MediaInfo{Title: "Nightcall (Remastered)", TrackURI: "spotify:track:1"}, true},
+		{"different URI", Entry{Title: "Nightcall", TrackURI: "spotify:track:1"}, audio.MediaInfo{Title: "Nightcall", TrackURI: "spotify:track:2"}, false},
+		{"URI on one side only", Entry{Title: "Nightcall", Artist: "Kavinsky", TrackURI: "spotify:track:1"}, audio.MediaInfo{Title: "Nightcall", Artist: "Kavinsky"}, true},
+		{"no artist on either", Entry{Title: "Nightcall"}, audio.MediaInfo{Title: "Nightcall"}, true},
+		{"artist on one side only", entry, audio.MediaInfo{Title: "Nightcall"}, false},
+		{"empty media", entry, audio.MediaInfo{}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.entry.IsTrack(&tt.media); got != tt.want {
+			t.Errorf("%s: IsTrack = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewEntryKeepsTheRepoPath(t *testing.T) {
+	entry := NewEntry(&audio.MediaInfo{Title: "Nightcall"}, "/work/api")
+	if entry.Repo != "api" || entry.RepoPath != "/work/api" {
+		t.Errorf("Repo = %q, RepoPath = %q", entry.Repo, entry.RepoPath)
+	}
+}
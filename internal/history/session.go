@@ -0,0 +1,52 @@
+package history
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Session is a stretch of commits without a long break between them
+type Session struct {
+	Start  time.Time
+	Tracks int // Distinct tracks, including the one playing now
+}
+
+// CurrentSession finds the session the current commit belongs to: recorded commits
+// going back from now until a gap longer than gap. ok is false when no earlier
+// commit falls within the session, as a session of one says nothing.
+func CurrentSession(now time.Time, gap time.Duration, title, artist string) (session Session, ok bool) {
+	entries, err := Load(time.Time{})
+	if err != nil {
+		return Session{}, false
+	}
+	return currentSession(entries, now, gap, title, artist)
+}
+
+func currentSession(entries []Entry, now time.Time, gap time.Duration, title, artist string) (Session, bool) {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+
+	tracks := map[string]bool{trackKey(title, artist): true}
+	start := now
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if entry.Time.After(now) {
+			continue
+		}
+		if start.Sub(entry.Time) > gap {
+			break
+		}
+		start = entry.Time
+		tracks[trackKey(entry.Title, entry.Artist)] = true
+	}
+
+	if start.Equal(now) {
+		return Session{}, false
+	}
+	return Session{Start: start, Tracks: len(tracks)}, true
+}
+
+// trackKey identifies a track the same way sameTrack compares them
+func trackKey(title, artist string) string {
+	return strings.ToLower(strings.TrimSpace(title)) + "\x00" + strings.ToLower(strings.TrimSpace(artist))
+}
@@ -0,0 +1,67 @@
+package history
+
+import (
+	"sort"
+)
+
+// Count is how many commits were tagged with a given artist, track or source
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Stats summarizes a set of history entries
+type Stats struct {
+	Commits int     `json:"commits"`
+	Artists []Count `json:"artists"`
+	Tracks  []Count `json:"tracks"`
+	Sources []Count `json:"sources"`
+}
+
+// Summarize counts the most frequent artists, tracks and sources, keeping the top limit of each
+func Summarize(entries []Entry, limit int) Stats {
+	artists := map[string]int{}
+	tracks := map[string]int{}
+	sources := map[string]int{}
+
+	for _, entry := range entries {
+		if entry.Artist != "" {
+			artists[entry.Artist]++
+		}
+		track := entry.Title
+		if entry.Artist != "" {
+			track = entry.Title + " by " + entry.Artist
+		}
+		tracks[track]++
+		if entry.Source != "" {
+			sources[entry.Source]++
+		}
+	}
+
+	return Stats{
+		Commits: len(entries),
+		Artists: top(artists, limit),
+		Tracks:  top(tracks, limit),
+		Sources: top(sources, limit),
+	}
+}
+
+func top(counts map[string]int, limit int) []Count {
+	result := make([]Count, 0, len(counts))
+	for name, count := range counts {
+		result = append(result, Count{Name: name, Count: count})
+	}
+
+	// Highest count first, alphabetical for ties so output is stable
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
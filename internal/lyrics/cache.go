@@ -0,0 +1,85 @@
+package lyrics
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/cache"
+)
+
+// cacheTTL is how long a looked-up snippet is kept. Lyrics don't change, but
+// a provider may learn a track it didn't know.
+const cacheTTL = 30 * 24 * time.Hour
+
+// cacheEntry is a snippet as last looked up; "" means the track had none
+type cacheEntry struct {
+	Snippet string    `json:"snippet"`
+	Fetched time.Time `json:"fetched"`
+}
+
+func cacheKey(provider, title, artist string) string {
+	return strings.ToLower(provider + "\x00" + strings.TrimSpace(artist) + "\x00" + strings.TrimSpace(title))
+}
+
+func cachePath() (string, error) {
+	dir, err := cache.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lyrics.json"), nil
+}
+
+// loadCache reads the cached snippets; a missing or unreadable cache is empty
+func loadCache() map[string]cacheEntry {
+	entries := map[string]cacheEntry{}
+	path, err := cachePath()
+	if err != nil {
+		return entries
+	}
+	if content, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(content, &entries)
+	}
+	return entries
+}
+
+// cached returns the snippet stored for key, if still fresh
+func cached(key string) (string, bool) {
+	entry, ok := loadCache()[key]
+	if !ok || time.Since(entry.Fetched) > cacheTTL {
+		return "", false
+	}
+	return entry.Snippet, true
+}
+
+// store saves a snippet, dropping expired entries. Failing to save only costs
+// a lookup next time.
+func store(key, snippet string) {
+	path, err := cachePath()
+	if err != nil {
+		return
+	}
+
+	entries := loadCache()
+	for k, entry := range entries {
+		if time.Since(entry.Fetched) > cacheTTL {
+			delete(entries, k)
+		}
+	}
+	entries[key] = cacheEntry{Snippet: snippet, Fetched: time.Now()}
+
+	content, err := json.Marshal(entries)
+	if err != nil {
+		return
+	}
+
+	// Write then rename so a concurrent commit never reads half a file
+	tmp := path + "." + strconv.Itoa(os.Getpid())
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return
+	}
+	os.Rename(tmp, path)
+}
@@ -0,0 +1,176 @@
+package lyrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Providers
+const (
+	LRCLIB     = "lrclib"     // lrclib.net, free and needs no key
+	Musixmatch = "musixmatch" // Needs an API key from developer.musixmatch.com
+)
+
+// MaxSnippet caps a snippet's length in characters, so it can't take over the commit line
+const MaxSnippet = 80
+
+// Snippet returns the first line of a track's lyrics, cleaned up to sit in a
+// commit line. It's "" for instrumentals and tracks the provider doesn't know.
+// Answers are cached, so each track is looked up once.
+func Snippet(ctx context.Context, provider, apiKey, title, artist string, duration time.Duration) (string, error) {
+	if title == "" || artist == "" {
+		return "", nil
+	}
+
+	key := cacheKey(provider, title, artist)
+	if snippet, ok := cached(key); ok {
+		return snippet, nil
+	}
+
+	var text string
+	var err error
+	switch provider {
+	case "", LRCLIB:
+		text, err = fetchLRCLIB(ctx, title, artist, duration)
+	case Musixmatch:
+		if apiKey == "" {
+			return "", fmt.Errorf("the musixmatch lyrics provider needs lyrics.api_key")
+		}
+		text, err = fetchMusixmatch(ctx, apiKey, title, artist)
+	default:
+		return "", fmt.Errorf("unknown lyrics provider %q (use %s or %s)", provider, LRCLIB, Musixmatch)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Not finding lyrics is an answer too, so it's cached as well
+	snippet := Sanitize(text)
+	store(key, snippet)
+	return snippet, nil
+}
+
+// sectionLine matches headings like "[Chorus]" and lrcTimestamps the
+// timestamps of synced lyrics like "[00:12.34]"; neither is part of the lyrics
+var (
+	sectionLine   = regexp.MustCompile(`^[\[(][^\])]*[\])]$`)
+	lrcTimestamps = regexp.MustCompile(`\[\d{1,2}:\d{2}(?:[.:]\d{1,3})?\]`)
+)
+
+// Sanitize reduces lyrics to their first real line: no headings, timestamps,
+// control characters or runs of spaces, and at most MaxSnippet characters
+func Sanitize(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = lrcTimestamps.ReplaceAllString(line, "")
+		line = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+				return ' '
+			}
+			return r
+		}, line)
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" || sectionLine.MatchString(line) {
+			continue
+		}
+
+		if runes := []rune(line); len(runes) > MaxSnippet {
+			line = strings.TrimSpace(string(runes[:MaxSnippet-1])) + "…"
+		}
+		return line
+	}
+	return ""
+}
+
+// fetchLRCLIB looks the track up on lrclib.net; a track it doesn't know has no lyrics
+func fetchLRCLIB(ctx context.Context, title, artist string, duration time.Duration) (string, error) {
+	query := url.Values{"track_name": {title}, "artist_name": {artist}}
+	if duration > 0 {
+		query.Set("duration", strconv.Itoa(int(duration.Seconds())))
+	}
+
+	var result struct {
+		Instrumental bool   `json:"instrumental"`
+		PlainLyrics  string `json:"plainLyrics"`
+		SyncedLyrics string `json:"syncedLyrics"`
+	}
+	found, err := getJSON(ctx, "https://lrclib.net/api/get?"+query.Encode(), &result)
+	if err != nil || !found || result.Instrumental {
+		return "", err
+	}
+	if result.PlainLyrics != "" {
+		return result.PlainLyrics, nil
+	}
+	return result.SyncedLyrics, nil
+}
+
+// fetchMusixmatch asks Musixmatch for the lyrics; free keys get a partial text,
+// which still starts at the first line
+func fetchMusixmatch(ctx context.Context, apiKey, title, artist string) (string, error) {
+	query := url.Values{"q_track": {title}, "q_artist": {artist}, "apikey": {apiKey}}
+
+	var result struct {
+		Message struct {
+			Header struct {
+				StatusCode int `json:"status_code"`
+			} `json:"header"`
+			Body json.RawMessage `json:"body"`
+		} `json:"message"`
+	}
+	if _, err := getJSON(ctx, "https://api.musixmatch.com/ws/1.1/matcher.lyrics.get?"+query.Encode(), &result); err != nil {
+		return "", err
+	}
+
+	switch result.Message.Header.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", nil
+	default:
+		return "", fmt.Errorf("Musixmatch returned status %d", result.Message.Header.StatusCode)
+	}
+
+	var body struct {
+		Lyrics struct {
+			Body string `json:"lyrics_body"`
+		} `json:"lyrics"`
+	}
+	if err := json.Unmarshal(result.Message.Body, &body); err != nil {
+		return "", fmt.Errorf("failed to parse Musixmatch response: %w", err)
+	}
+	return body.Lyrics.Body, nil
+}
+
+// getJSON decodes a response into out. It reports false for 404 Not Found.
+func getJSON(ctx context.Context, endpoint string, out interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("User-Agent", "interactive-commit (https://github.com/pixare40/interactive-commit)")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch lyrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("lyrics provider returned %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("failed to parse lyrics response: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,85 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/ianaindex"
+)
+
+// IsUTF8 reports whether charset, as named by i18n.commitEncoding, means UTF-8.
+// Unset counts as UTF-8, git's default.
+func IsUTF8(charset string) bool {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "", "utf-8", "utf8":
+		return true
+	}
+	return false
+}
+
+// lookupEncoding resolves an encoding name like "ISO-8859-1", "latin1" or "Shift_JIS"
+func lookupEncoding(charset string) (encoding.Encoding, error) {
+	// IANA names first, so ISO-8859-1 means Latin-1 rather than the WHATWG's windows-1252
+	if enc, err := ianaindex.IANA.Encoding(charset); err == nil && enc != nil {
+		return enc, nil
+	}
+	if enc, err := htmlindex.Get(charset); err == nil {
+		return enc, nil
+	}
+	return nil, fmt.Errorf("unsupported commit encoding %q", charset)
+}
+
+// Decode converts a commit message written in charset to UTF-8
+func Decode(content []byte, charset string) (string, error) {
+	if IsUTF8(charset) {
+		return string(content), nil
+	}
+
+	enc, err := lookupEncoding(charset)
+	if err != nil {
+		return "", err
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode commit message as %s: %w", charset, err)
+	}
+	return string(decoded), nil
+}
+
+// Encode converts a UTF-8 commit message back to charset. Characters the
+// encoding can't represent, like emoji or the invisible marker in ISO-8859-1,
+// are left out, along with the space after one that started a line.
+func Encode(content, charset string) ([]byte, error) {
+	if IsUTF8(charset) {
+		return []byte(content), nil
+	}
+
+	enc, err := lookupEncoding(charset)
+	if err != nil {
+		return nil, err
+	}
+
+	if encoded, err := enc.NewEncoder().Bytes([]byte(content)); err == nil {
+		return encoded, nil
+	}
+
+	// Drop whatever can't be encoded, one character at a time
+	var sb strings.Builder
+	lineStart, dropped := true, false
+	for _, r := range content {
+		if _, err := enc.NewEncoder().String(string(r)); err != nil {
+			dropped = dropped || lineStart
+			continue
+		}
+		if r == ' ' && dropped {
+			dropped = false
+			continue
+		}
+		sb.WriteRune(r)
+		lineStart, dropped = r == '\n', false
+	}
+	return enc.NewEncoder().Bytes([]byte(sb.String()))
+}
@@ -0,0 +1,67 @@
+package message
+
+import (
+	"testing"
+)
+
+func TestEncodeRoundTrip(t *testing.T) {
+	text := "Corrige l'analyseur\n\nÉcrit au café, déjà testé.\n"
+	encoded, err := Encode(text, "ISO-8859-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(encoded) != len([]rune(text)) {
+		t.Errorf("encoded %d bytes, want one per character (%d)", len(encoded), len([]rune(text)))
+	}
+	decoded, err := Decode(encoded, "ISO-8859-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != text {
+		t.Errorf("round trip = %q, want %q", decoded, text)
+	}
+}
+
+func TestEncodeDropsWhatItCantHold(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"emoji prefix and its space", "Fix\n\n🎵 Currently playing: \"Café\" by Kavinsky (Spotify)\n", "Fix\n\nCurrently playing: \"Café\" by Kavinsky (Spotify)\n"},
+		{"marker", "Fix\n\n♪ Café\u200b\u200c\u200b\n", "Fix\n\nCafé\n"},
+		{"mid-line", "Fix ✓ café\n", "Fix  café\n"},
+		{"CRLF kept", "Fix\r\n\r\n🎵 Café\r\n", "Fix\r\n\r\nCafé\r\n"},
+	}
+	for _, tt := range tests {
+		encoded, err := Encode(tt.text, "ISO-8859-1")
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		got, err := Decode(encoded, "ISO-8859-1")
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeUTF8AndUnknown(t *testing.T) {
+	text := "Fix\n\n🎵 Café\n"
+	for _, charset := range []string{"", "UTF-8", "utf8"} {
+		encoded, err := Encode(text, charset)
+		if err != nil || string(encoded) != text {
+			t.Errorf("Encode(%q) = %q, %v; want it unchanged", charset, encoded, err)
+		}
+	}
+	if _, err := Encode(text, "no-such-charset"); err == nil {
+		t.Error("expected an error for an unknown charset")
+	}
+	if _, err := Decode([]byte(text), "no-such-charset"); err == nil {
+		t.Error("expected an error decoding an unknown charset")
+	}
+}
@@ -0,0 +1,350 @@
+package message
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Positions where the audio line can be placed in a commit message
+const (
+	PositionBottom = "bottom" // After the body (default)
+	PositionTop    = "top"    // Right after the subject line's blank separator
+)
+
+// LineEnding returns "\r\n" when the message uses CRLF line endings, otherwise "\n"
+func LineEnding(content string) string {
+	if strings.Contains(content, "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// ToLF normalizes CRLF line endings so the message can be edited line by line
+func ToLF(content string) string {
+	return strings.ReplaceAll(content, "\r\n", "\n")
+}
+
+// FromLF restores the line ending convention returned by LineEnding
+func FromLF(content, ending string) string {
+	if ending == "\n" {
+		return content
+	}
+	return strings.ReplaceAll(content, "\n", ending)
+}
+
+// HasContent reports whether the message has any non-comment, non-whitespace lines
+func HasContent(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return true
+		}
+	}
+	return false
+}
+
+// Subject returns the first non-comment, non-blank line of the message
+func Subject(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return trimmed
+		}
+	}
+	return ""
+}
+
+// HasBody reports whether the message has text below its subject. Lines skip
+// accepts, such as one an earlier run of the hook added, don't count, and
+// neither does a body made only of trailers like Signed-off-by.
+func HasBody(content string, skip func(string) bool) bool {
+	var body []string
+	subject := false
+	for _, line := range strings.Split(content, "\n") {
+		if line == scissorsLine {
+			break
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if !subject {
+			subject = true
+			continue
+		}
+		if skip != nil && skip(line) {
+			continue
+		}
+		body = append(body, line)
+	}
+	return len(body) > 0 && !isTrailerBlock(body)
+}
+
+// autosquashPrefixes start the subjects git commit --fixup and --squash write,
+// and --fixup=amend: and --fixup=reword:
+var autosquashPrefixes = []string{"fixup! ", "squash! ", "amend! "}
+
+// IsAutosquash reports whether the message is a fixup or squash commit that
+// rebase --autosquash will fold into an earlier one
+func IsAutosquash(content string) bool {
+	subject := Subject(content)
+	for _, prefix := range autosquashPrefixes {
+		if strings.HasPrefix(subject, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SameText reports whether two messages read the same once cleaned up the way
+// git does before committing: comments and any verbose diff dropped, trailing
+// whitespace trimmed and runs of blank lines collapsed
+func SameText(a, b string) bool {
+	return cleanup(a) == cleanup(b)
+}
+
+func cleanup(content string) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if line == scissorsLine {
+			break
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		line = strings.TrimRight(line, " \t")
+		if line == "" && (len(lines) == 0 || lines[len(lines)-1] == "") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// Insert places line into the commit message at the given position
+func Insert(content, line, position string) (string, error) {
+	switch position {
+	case "", PositionBottom:
+		return insertBottom(content, line), nil
+	case PositionTop:
+		return insertTop(content, line), nil
+	default:
+		return "", fmt.Errorf("unknown append position %q (expected %q or %q)", position, PositionTop, PositionBottom)
+	}
+}
+
+func insertBottom(content, line string) string {
+	// Preserve original content structure, only trim trailing newlines
+	originalContent := strings.TrimRight(content, "\n")
+
+	// Append audio info with proper spacing
+	return originalContent + "\n\n" + line + "\n"
+}
+
+func insertTop(content, line string) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+
+	subject := -1
+	for i, l := range lines {
+		trimmed := strings.TrimSpace(l)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			subject = i
+			break
+		}
+	}
+	if subject < 0 {
+		return insertBottom(content, line)
+	}
+
+	// Skip the blank separator after the subject if there is one - we recreate it
+	rest := lines[subject+1:]
+	if len(rest) > 0 && strings.TrimSpace(rest[0]) == "" {
+		rest = rest[1:]
+	}
+
+	result := append([]string{}, lines[:subject+1]...)
+	result = append(result, "", line)
+	if len(rest) > 0 {
+		// Keep the body, comments and any verbose diff below our line
+		result = append(result, "")
+		result = append(result, rest...)
+	}
+
+	return strings.Join(result, "\n") + "\n"
+}
+
+// Suggest adds lines as comments just above git's own comment lines (or at the
+// end), so the editor shows them but the commit drops them unless uncommented
+func Suggest(content string, lines []string) string {
+	all := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	at := len(all)
+	for i, line := range all {
+		if strings.HasPrefix(line, "#") {
+			at = i
+			break
+		}
+	}
+
+	var comment []string
+	for _, line := range lines {
+		comment = append(comment, "# "+line)
+	}
+	if at < len(all) {
+		comment = append(comment, "#") // Keep git's instructions apart
+	}
+
+	result := append([]string{}, all[:at]...)
+	result = append(result, comment...)
+	result = append(result, all[at:]...)
+	return strings.Join(result, "\n") + "\n"
+}
+
+// scissorsLine marks the start of the diff git appends with commit --verbose;
+// nothing below it is part of the message
+const scissorsLine = "# ------------------------ >8 ------------------------"
+
+// FindLine returns the index (into the "\n"-separated lines) of the first message
+// line that match accepts, or -1. Comments and any verbose diff are not searched.
+func FindLine(content string, match func(string) bool) int {
+	for i, line := range strings.Split(content, "\n") {
+		if line == scissorsLine {
+			break
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		if match(line) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ReplaceLine swaps the line at index, as returned by FindLine, for line
+func ReplaceLine(content string, index int, line string) string {
+	lines := strings.Split(content, "\n")
+	if index < 0 || index >= len(lines) {
+		return content
+	}
+	lines[index] = line
+	return strings.Join(lines, "\n")
+}
+
+// ReplaceParagraph swaps the paragraph holding the line at index, as returned
+// by FindLine, for text. A wrapped audio line fills a paragraph of its own.
+func ReplaceParagraph(content string, index int, text string) string {
+	lines := strings.Split(content, "\n")
+	if index < 0 || index >= len(lines) {
+		return content
+	}
+
+	start, end := paragraphBounds(lines, index)
+	result := append([]string{}, lines[:start]...)
+	result = append(result, text)
+	result = append(result, lines[end:]...)
+	return strings.Join(result, "\n")
+}
+
+// Paragraph returns the paragraph holding the line at index, as returned by
+// FindLine, joined into one line. It undoes wrapping an audio line.
+func Paragraph(content string, index int) string {
+	lines := strings.Split(content, "\n")
+	if index < 0 || index >= len(lines) {
+		return ""
+	}
+
+	start, end := paragraphBounds(lines, index)
+	return strings.Join(lines[start:end], " ")
+}
+
+// paragraphBounds finds the lines [start, end) of the paragraph holding index
+func paragraphBounds(lines []string, index int) (int, int) {
+	inParagraph := func(line string) bool {
+		trimmed := strings.TrimSpace(line)
+		return trimmed != "" && !strings.HasPrefix(trimmed, "#")
+	}
+	start, end := index, index+1
+	for start > 0 && inParagraph(lines[start-1]) {
+		start--
+	}
+	for end < len(lines) && inParagraph(lines[end]) {
+		end++
+	}
+	return start, end
+}
+
+// SetTrailer updates the value of an existing key trailer, or appends one
+func SetTrailer(content, key, value string) string {
+	index := FindLine(content, func(line string) bool {
+		return strings.HasPrefix(line, key+": ")
+	})
+	if index < 0 {
+		return AppendTrailer(content, key, value)
+	}
+	return ReplaceLine(content, index, key+": "+value)
+}
+
+// trailerLine matches a git trailer such as "Signed-off-by: Name <email>"
+var trailerLine = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*:\s`)
+
+// AppendTrailer adds a "key: value" trailer, joining an existing trailer block
+// when the message already ends with one so git still recognizes them all.
+// It goes above git's comments and any verbose diff, which git drops.
+func AppendTrailer(content, key, value string) string {
+	content, comments := splitComments(content)
+	trimmed := strings.TrimRight(content, "\n")
+	trailer := key + ": " + value
+
+	lines := strings.Split(trimmed, "\n")
+	start := len(lines)
+	for start > 0 && strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+
+	result := trimmed + "\n\n" + trailer + "\n"
+	if start < len(lines) && isTrailerBlock(lines[start:]) {
+		result = trimmed + "\n" + trailer + "\n"
+	}
+	if comments != "" {
+		result += "\n" + comments
+	}
+	return result
+}
+
+// splitComments splits content before the comment lines that end it and any
+// verbose diff, with the blank lines between; comments is "" when there are none
+func splitComments(content string) (message, comments string) {
+	lines := strings.Split(content, "\n")
+	end := len(lines)
+	for i, line := range lines {
+		if line == scissorsLine {
+			end = i
+			break
+		}
+	}
+	cut := end
+	for cut > 0 && (strings.TrimSpace(lines[cut-1]) == "" || strings.HasPrefix(strings.TrimSpace(lines[cut-1]), "#")) {
+		cut--
+	}
+
+	comments = strings.TrimLeft(strings.Join(lines[cut:], "\n"), "\n")
+	if strings.TrimSpace(comments) == "" {
+		return content, ""
+	}
+	return strings.Join(lines[:cut], "\n"), comments
+}
+
+func isTrailerBlock(lines []string) bool {
+	found := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		if !trailerLine.MatchString(line) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
@@ -0,0 +1,203 @@
+package message
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+const line = "🎵 Nightcall by Kavinsky"
+
+func TestInsert(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		position string
+		want     string
+	}{
+		{
+			name:     "subject only, bottom",
+			content:  "Fix the parser\n",
+			position: PositionBottom,
+			want:     "Fix the parser\n\n" + line + "\n",
+		},
+		{
+			name:     "subject only, top",
+			content:  "Fix the parser\n",
+			position: PositionTop,
+			want:     "Fix the parser\n\n" + line + "\n",
+		},
+		{
+			name:     "subject without a newline, top",
+			content:  "Fix the parser",
+			position: PositionTop,
+			want:     "Fix the parser\n\n" + line + "\n",
+		},
+		{
+			name:     "multi-paragraph body, bottom",
+			content:  "Fix the parser\n\nIt choked on tabs.\n\nNow it doesn't.\n",
+			position: "",
+			want:     "Fix the parser\n\nIt choked on tabs.\n\nNow it doesn't.\n\n" + line + "\n",
+		},
+		{
+			name:     "multi-paragraph body, top",
+			content:  "Fix the parser\n\nIt choked on tabs.\n\nNow it doesn't.\n",
+			position: PositionTop,
+			want:     "Fix the parser\n\n" + line + "\n\nIt choked on tabs.\n\nNow it doesn't.\n",
+		},
+		{
+			name:     "body without a blank separator, top",
+			content:  "Fix the parser\nIt choked on tabs.\n",
+			position: PositionTop,
+			want:     "Fix the parser\n\n" + line + "\n\nIt choked on tabs.\n",
+		},
+		{
+			name:     "comments and verbose diff kept below, top",
+			content:  "Fix the parser\n\n# Please enter the commit message\n" + scissorsLine + "\ndiff --git a/x b/x\n",
+			position: PositionTop,
+			want:     "Fix the parser\n\n" + line + "\n\n# Please enter the commit message\n" + scissorsLine + "\ndiff --git a/x b/x\n",
+		},
+		{
+			name:     "leading comments before the subject, top",
+			content:  "# Amending\nFix the parser\n",
+			position: PositionTop,
+			want:     "# Amending\nFix the parser\n\n" + line + "\n",
+		},
+		{
+			name:     "only comments, top falls back to bottom",
+			content:  "# Please enter the commit message\n",
+			position: PositionTop,
+			want:     "# Please enter the commit message\n\n" + line + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := Insert(tt.content, line, tt.position)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInsertUnknownPosition(t *testing.T) {
+	if _, err := Insert("Fix the parser\n", line, "middle"); err == nil {
+		t.Error("expected an error for an unknown position")
+	}
+}
+
+func TestInsertKeepsCRLF(t *testing.T) {
+	content, err := os.ReadFile("testdata/crlf.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ending := LineEnding(string(content))
+	if ending != "\r\n" {
+		t.Fatalf("LineEnding = %q, want CRLF", ending)
+	}
+	text := ToLF(string(content))
+	if !HasContent(text) || Subject(text) != "Fix the parser" {
+		t.Errorf("content check misread the CRLF file: subject %q", Subject(text))
+	}
+
+	for _, position := range []string{PositionBottom, PositionTop} {
+		inserted, err := Insert(text, line, position)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := FromLF(inserted, ending)
+
+		if !strings.Contains(got, "\r\n"+line+"\r\n") {
+			t.Errorf("%s: the line doesn't use CRLF: %q", position, got)
+		}
+		if strings.Count(got, "\n") != strings.Count(got, "\r\n") {
+			t.Errorf("%s: mixed line endings: %q", position, got)
+		}
+	}
+}
+
+func TestLineEndingLF(t *testing.T) {
+	if got := LineEnding("Fix the parser\n\nbody\n"); got != "\n" {
+		t.Errorf("LineEnding = %q, want LF", got)
+	}
+	if got := FromLF("a\nb\n", "\n"); got != "a\nb\n" {
+		t.Errorf("FromLF changed an LF message: %q", got)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	long := []string{"Uncomment the next lines to add what's playing:", "🎵 Currently playing: \"A Very Long Episode Title That Goes On\"", "by Someone (Pocket Casts)"}
+	tests := []struct {
+		name    string
+		content string
+		lines   []string
+		want    string
+	}{
+		{"empty message", "", []string{"intro", line}, "\n# intro\n# " + line + "\n"},
+		{"above git's comments", "Fix the parser\n\n# Please enter the commit message\n", []string{"intro", line}, "Fix the parser\n\n# intro\n# " + line + "\n#\n# Please enter the commit message\n"},
+		{"wrapped line, every part commented", "\n# Please enter the commit message\n", long, "\n# " + long[0] + "\n# " + long[1] + "\n# " + long[2] + "\n#\n# Please enter the commit message\n"},
+		{"already tagged, line kept as is", "Fix the parser\n\n" + line + "\n", []string{"intro", line}, "Fix the parser\n\n" + line + "\n# intro\n# " + line + "\n"},
+	}
+
+	for _, tt := range tests {
+		if got := Suggest(tt.content, tt.lines); got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAppendTrailer(t *testing.T) {
+	const trailer = "Soundtrack: " + line
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"new block", "Fix the parser\n\nIt choked on tabs.\n", "Fix the parser\n\nIt choked on tabs.\n\n" + trailer + "\n"},
+		{"joins trailers", "Fix the parser\n\nIt choked on tabs.\n\nSigned-off-by: Ada <ada@example.com>\n", "Fix the parser\n\nIt choked on tabs.\n\nSigned-off-by: Ada <ada@example.com>\n" + trailer + "\n"},
+		{
+			"above git's comments",
+			"Fix the parser\n\nSigned-off-by: Ada <ada@example.com>\n\n# Please enter the commit message\n",
+			"Fix the parser\n\nSigned-off-by: Ada <ada@example.com>\n" + trailer + "\n\n# Please enter the commit message\n",
+		},
+		{
+			"above a verbose diff",
+			"Fix the parser\n\nIt choked on tabs.\n\n# Please enter the commit message\n" + scissorsLine + "\ndiff --git a/x b/x\n+Signed-off-by: nobody\n",
+			"Fix the parser\n\nIt choked on tabs.\n\n" + trailer + "\n\n# Please enter the commit message\n" + scissorsLine + "\ndiff --git a/x b/x\n+Signed-off-by: nobody\n",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := AppendTrailer(tt.content, "Soundtrack", line); got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsAutosquash(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"fixup! Fix the parser\n", true},
+		{"squash! Fix the parser\n\nAlso tabs in keys.\n", true},
+		{"amend! Fix the parser\n\nFix the parser properly\n", true},
+		{"  fixup! Fix the parser\n", true},
+		{"# Amending\nsquash! Fix the parser\n", true},
+		{"Fix the parser\n", false},
+		{"Add a fixup for the parser\n", false},
+		{"fixup!Fix the parser\n", false},
+		{"Fix the parser\n\nfixup! Not the subject\n", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsAutosquash(tt.content); got != tt.want {
+			t.Errorf("IsAutosquash(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+)
+
+// Event is a tagged commit worth telling others about
+type Event struct {
+	Media   *audio.MediaInfo `json:"media"`
+	Subject string           `json:"subject"`
+	Repo    string           `json:"repo,omitempty"`
+}
+
+// Notifier sends an event somewhere outside the commit
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Webhook posts events to a Slack or Discord incoming webhook
+type Webhook struct {
+	URL string
+}
+
+// Notify posts the event as a short chat message
+func (w *Webhook) Notify(ctx context.Context, event Event) error {
+	// Discord and Slack take the same message under different keys
+	key := "text"
+	if strings.Contains(w.URL, "discord.com/api/webhooks") || strings.Contains(w.URL, "discordapp.com/api/webhooks") {
+		key = "content"
+	}
+
+	body, err := json.Marshal(map[string]string{key: Message(event)})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Message describes the event in one line, e.g.
+// `🎵 "Song" by Artist (Spotify) while committing "fix: typo" to my-repo`
+func Message(event Event) string {
+	var sb strings.Builder
+
+	media := event.Media
+	fmt.Fprintf(&sb, "🎵 \"%s\"", media.Title)
+	if media.Artist != "" {
+		fmt.Fprintf(&sb, " by %s", media.Artist)
+	}
+	if media.Source != "" {
+		fmt.Fprintf(&sb, " (%s)", media.Source)
+	}
+
+	fmt.Fprintf(&sb, " while committing \"%s\"", event.Subject)
+	if event.Repo != "" {
+		fmt.Fprintf(&sb, " to %s", event.Repo)
+	}
+	return sb.String()
+}
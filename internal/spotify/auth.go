@@ -0,0 +1,197 @@
+package spotify
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pixare40/interactive-commit/internal/config"
+)
+
+const (
+	authorizeURL = "https://accounts.spotify.com/authorize"
+	tokenURL     = "https://accounts.spotify.com/api/token"
+
+	// RedirectURI must be added to the Spotify app's redirect URIs
+	RedirectURI = "http://127.0.0.1:8888/callback"
+
+	scopes = "user-read-currently-playing user-read-playback-state"
+)
+
+// ErrNotLoggedIn is returned when no Spotify token has been saved yet
+var ErrNotLoggedIn = errors.New("not logged in to Spotify - run 'interactive-commit spotify login'")
+
+// Token is the OAuth token saved by login and refreshed as needed
+type Token struct {
+	ClientID     string    `json:"client_id"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// TokenPath returns where the Spotify token is kept, next to the global config
+func TokenPath() (string, error) {
+	globalPath, err := config.GlobalPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(globalPath), "spotify-token.json"), nil
+}
+
+// LoadToken reads the saved token
+func LoadToken() (*Token, error) {
+	path, err := TokenPath()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotLoggedIn
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Spotify token: %w", err)
+	}
+
+	var token Token
+	if err := json.Unmarshal(content, &token); err != nil {
+		return nil, fmt.Errorf("failed to parse Spotify token %s: %w", path, err)
+	}
+	return &token, nil
+}
+
+// Save writes the token, readable only by the user
+func (t *Token) Save() error {
+	path, err := TokenPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	content, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0600)
+}
+
+// Logout removes the saved token
+func Logout() error {
+	path, err := TokenPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// NewVerifier returns a PKCE code verifier and its S256 challenge.
+// PKCE lets us log in without a client secret, which a CLI can't keep.
+func NewVerifier() (verifier, challenge string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	verifier = base64.RawURLEncoding.EncodeToString(buf)
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// AuthorizeURL is the page the user opens to grant access
+func AuthorizeURL(clientID, challenge, state string) string {
+	params := url.Values{
+		"client_id":             {clientID},
+		"response_type":         {"code"},
+		"redirect_uri":          {RedirectURI},
+		"scope":                 {scopes},
+		"state":                 {state},
+		"code_challenge_method": {"S256"},
+		"code_challenge":        {challenge},
+	}
+	return authorizeURL + "?" + params.Encode()
+}
+
+// Exchange trades the authorization code from the redirect for a token
+func Exchange(ctx context.Context, clientID, code, verifier string) (*Token, error) {
+	token, err := requestToken(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {RedirectURI},
+		"client_id":     {clientID},
+		"code_verifier": {verifier},
+	})
+	if err != nil {
+		return nil, err
+	}
+	token.ClientID = clientID
+	return token, nil
+}
+
+// refresh renews an expired access token. Spotify may rotate the refresh token too.
+func (t *Token) refresh(ctx context.Context) error {
+	fresh, err := requestToken(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {t.RefreshToken},
+		"client_id":     {t.ClientID},
+	})
+	if err != nil {
+		return err
+	}
+
+	t.AccessToken = fresh.AccessToken
+	t.Expiry = fresh.Expiry
+	if fresh.RefreshToken != "" {
+		t.RefreshToken = fresh.RefreshToken
+	}
+	return t.Save()
+}
+
+func requestToken(ctx context.Context, form url.Values) (*Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Spotify accounts: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse Spotify token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Spotify token request failed: %s %s", result.Error, result.ErrorDescription)
+	}
+
+	return &Token{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+		// Refresh a little early so a token never expires mid-request
+		Expiry: time.Now().Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute),
+	}, nil
+}
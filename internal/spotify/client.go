@@ -0,0 +1,206 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const apiURL = "https://api.spotify.com/v1"
+
+// Client calls the Spotify Web API with the saved token
+type Client struct {
+	token *Token
+}
+
+// NewClient loads the saved token; it fails with ErrNotLoggedIn before login
+func NewClient() (*Client, error) {
+	token, err := LoadToken()
+	if err != nil {
+		return nil, err
+	}
+	return &Client{token: token}, nil
+}
+
+// Playback is the user's current playback state
+type Playback struct {
+	IsPlaying   bool     `json:"is_playing"`
+	ProgressMS  int64    `json:"progress_ms"`
+	PlayingType string   `json:"currently_playing_type"` // "track", "episode", "ad" or "unknown"
+	Item        *Item    `json:"item"`
+	Context     *Context `json:"context"`
+	Device      struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"device"`
+}
+
+// Item is the playing track or episode
+type Item struct {
+	ID           string `json:"id"`
+	URI          string `json:"uri"`
+	Name         string `json:"name"`
+	DurationMS   int64  `json:"duration_ms"`
+	Explicit     bool   `json:"explicit"`
+	IsLocal      bool   `json:"is_local"` // A file from the user's own library rather than the catalog
+	ExternalURLs struct {
+		Spotify string `json:"spotify"`
+	} `json:"external_urls"`
+
+	// Tracks
+	Artists []struct {
+		Name string `json:"name"`
+	} `json:"artists"`
+	Album struct {
+		Name   string  `json:"name"`
+		Images []Image `json:"images"`
+	} `json:"album"`
+
+	// Episodes
+	Show struct {
+		Name      string `json:"name"`
+		Publisher string `json:"publisher"`
+	} `json:"show"`
+	Images []Image `json:"images"`
+}
+
+// Context is what the track is playing from: a playlist, album, artist or
+// the user's Liked Songs. Only its URI is given; ContextName resolves the name.
+type Context struct {
+	Type string `json:"type"` // "playlist", "album", "artist" or "collection"
+	URI  string `json:"uri"`
+}
+
+// Image is cover art, largest first
+type Image struct {
+	URL string `json:"url"`
+}
+
+// AudioFeatures are Spotify's audio analysis values for a track
+type AudioFeatures struct {
+	Energy  float64 `json:"energy"`  // 0-1, intensity and activity
+	Valence float64 `json:"valence"` // 0-1, musical positiveness
+	Tempo   float64 `json:"tempo"`   // Beats per minute
+}
+
+// Playback returns what's playing on any of the user's devices, or nil when nothing is
+func (c *Client) Playback(ctx context.Context) (*Playback, error) {
+	var playback Playback
+	found, err := c.get(ctx, "/me/player?additional_types=episode", &playback)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &playback, nil
+}
+
+// AudioFeatures fetches the audio features of a track
+func (c *Client) AudioFeatures(ctx context.Context, trackID string) (*AudioFeatures, error) {
+	var features AudioFeatures
+	found, err := c.get(ctx, "/audio-features/"+url.PathEscape(trackID), &features)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no audio features for track %s", trackID)
+	}
+	return &features, nil
+}
+
+// ContextName looks up the name of a playback context. Names are cached, so
+// committing to the same playlist doesn't cost an API call each time.
+func (c *Client) ContextName(ctx context.Context, playing *Context) (string, error) {
+	if playing.Type == "collection" {
+		return "Liked Songs", nil
+	}
+
+	// e.g. "spotify:playlist:37i9dQZEVXcJZyENOWUFo7"
+	parts := strings.Split(playing.URI, ":")
+	id := parts[len(parts)-1]
+	switch playing.Type {
+	case "playlist", "album", "artist":
+	default:
+		return "", fmt.Errorf("unsupported Spotify context %q", playing.Type)
+	}
+
+	if name, ok := cachedContextName(playing.URI); ok {
+		return name, nil
+	}
+
+	path := "/" + playing.Type + "s/" + url.PathEscape(id)
+	if playing.Type == "playlist" {
+		path += "?fields=name"
+	}
+	var result struct {
+		Name string `json:"name"`
+	}
+	found, err := c.get(ctx, path, &result)
+	if err != nil {
+		return "", err
+	}
+	if !found || result.Name == "" {
+		return "", fmt.Errorf("no name for Spotify %s %s", playing.Type, id)
+	}
+
+	cacheContextName(playing.URI, result.Name)
+	return result.Name, nil
+}
+
+// SearchTrack finds the URI of the best match for a track, or "" when
+// Spotify has nothing by that title and artist
+func (c *Client) SearchTrack(ctx context.Context, title, artist string) (string, error) {
+	query := "track:" + title
+	if artist != "" {
+		query += " artist:" + artist
+	}
+
+	var result struct {
+		Tracks struct {
+			Items []struct {
+				URI string `json:"uri"`
+			} `json:"items"`
+		} `json:"tracks"`
+	}
+	found, err := c.get(ctx, "/search?type=track&limit=1&q="+url.QueryEscape(query), &result)
+	if err != nil || !found || len(result.Tracks.Items) == 0 {
+		return "", err
+	}
+	return result.Tracks.Items[0].URI, nil
+}
+
+// get decodes an API response into out. It reports false for 204 No Content.
+func (c *Client) get(ctx context.Context, path string, out interface{}) (bool, error) {
+	if time.Now().After(c.token.Expiry) {
+		if err := c.token.refresh(ctx); err != nil {
+			return false, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+path, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token.AccessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to query Spotify: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		return false, nil
+	default:
+		return false, fmt.Errorf("Spotify API %s returned %s", path, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("failed to parse Spotify response: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,76 @@
+package spotify
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// contextNameTTL is how long a cached context name is trusted. Playlists get
+// renamed now and then, albums and artists hardly ever.
+const contextNameTTL = 7 * 24 * time.Hour
+
+// cachedContext is a context name as last fetched from the API
+type cachedContext struct {
+	Name    string    `json:"name"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// contextCachePath returns where context names are cached, next to the token
+func contextCachePath() (string, error) {
+	tokenPath, err := TokenPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(tokenPath), "spotify-contexts.json"), nil
+}
+
+// loadContextCache reads the cached names by context URI; a missing or
+// unreadable cache is empty
+func loadContextCache() map[string]cachedContext {
+	names := map[string]cachedContext{}
+	path, err := contextCachePath()
+	if err != nil {
+		return names
+	}
+	if content, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(content, &names)
+	}
+	return names
+}
+
+// cachedContextName returns the cached name of a context, if still fresh
+func cachedContextName(uri string) (string, bool) {
+	entry, ok := loadContextCache()[uri]
+	if !ok || time.Since(entry.Fetched) > contextNameTTL {
+		return "", false
+	}
+	return entry.Name, true
+}
+
+// cacheContextName remembers a context's name, dropping expired entries.
+// Failing to cache only costs an API call next time.
+func cacheContextName(uri, name string) {
+	path, err := contextCachePath()
+	if err != nil {
+		return
+	}
+
+	names := loadContextCache()
+	for key, entry := range names {
+		if time.Since(entry.Fetched) > contextNameTTL {
+			delete(names, key)
+		}
+	}
+	names[uri] = cachedContext{Name: name, Fetched: time.Now()}
+
+	content, err := json.MarshalIndent(names, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	os.WriteFile(path, content, 0644)
+}
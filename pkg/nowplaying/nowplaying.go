@@ -0,0 +1,62 @@
+// Package nowplaying detects the music, podcast or video playing on this
+// computer, with the same detectors interactive-commit tags commits with:
+// MPRIS on Linux, AppleScript on macOS, the Windows media session (from WSL
+// too), browser tabs, and the integrations enabled in the user's config.
+//
+//	media, err := nowplaying.NewDetector().Detect(ctx)
+//	if errors.Is(err, nowplaying.ErrNothingPlaying) {
+//		// Silence
+//	}
+//	fmt.Printf("%s by %s (%s)\n", media.Title, media.Artist, media.Source)
+package nowplaying
+
+import (
+	"context"
+
+	"github.com/pixare40/interactive-commit/internal/audio"
+	"github.com/pixare40/interactive-commit/internal/config"
+)
+
+// MediaInfo describes what's playing. Title and Source are always set; the
+// other fields are filled in when the player exposes them. Fields may be
+// added in later versions, but existing ones keep their meaning.
+type MediaInfo = audio.MediaInfo
+
+// ErrNothingPlaying is returned by Detect when no detector found anything.
+// When macOS automation permission or the Windows execution policy got in
+// the way, the returned error wraps that cause too.
+var ErrNothingPlaying = audio.ErrNoAudio
+
+// Detector finds what's playing. It is safe for concurrent use: overlapping
+// Detect calls share one detection.
+type Detector struct {
+	manager *audio.AudioManager
+}
+
+// NewDetector returns a Detector set up like the interactive-commit CLI,
+// from the user's interactive-commit config when there is one, so the
+// integrations they've enabled (the Spotify Web API, mpv, OBS, ...) are used.
+// Without a config, or with one that can't be read, defaults apply.
+func NewDetector() *Detector {
+	cfg, _ := config.Load()
+	return &Detector{manager: audio.NewAudioManager(cfg)}
+}
+
+// Detect returns what's playing, trying each available detector in turn.
+// Detection runs players' own tools (playerctl, osascript, PowerShell), so
+// give ctx a deadline; a few seconds is plenty.
+func (d *Detector) Detect(ctx context.Context) (*MediaInfo, error) {
+	media, err := d.manager.Detect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if media == nil {
+		return nil, ErrNothingPlaying // A detector reported silence with stop_on_empty
+	}
+	return media, nil
+}
+
+// Detect returns what's playing with a new Detector
+func Detect(ctx context.Context) (*MediaInfo, error) {
+	return NewDetector().Detect(ctx)
+}